@@ -0,0 +1,296 @@
+// Package client is a Go client for the transaction API. Every route has a
+// typed method, list routes also have an iterator going through every page,
+// and error responses are returned as *Error.
+//
+//	c := client.New("http://localhost:4000")
+//	for transaction, err := range c.AllTransactions(ctx, client.ListTransactionsOptions{
+//		Status: client.StatusPending,
+//		Sort:   client.Desc(client.SortAmount),
+//	}) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API at a base URL. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	header     http.Header
+	retry      RetryPolicy
+}
+
+// RetryPolicy tells how idempotent calls are retried. Requests that failed
+// before a response arrived, and responses with a status in Statuses, are
+// sent again up to MaxAttempts times in total. The wait doubles from
+// BaseDelay up to MaxDelay, or follows the Retry-After header when the
+// server sends one.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Statuses    []int
+}
+
+// DefaultRetryPolicy retries up to three times on rate limiting and on the
+// statuses a proxy or a restarting server answers with.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Statuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with httpClient instead of
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithHeader adds a header to every request, e.g. for authentication.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Add(key, value) }
+}
+
+// WithRetry replaces DefaultRetryPolicy. A MaxAttempts of 1 disables
+// retries.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// New creates a client for the API at baseURL, e.g. "http://localhost:4000".
+// It panics when baseURL isn't an absolute URL.
+func New(baseURL string, opts ...Option) *Client {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(fmt.Sprintf("client: invalid base URL %q", baseURL))
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+		retry:      DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Healthcheck returns the status and version of the server.
+func (c *Client) Healthcheck(ctx context.Context) (*Health, error) {
+	res, err := c.do(ctx, &request{method: http.MethodGet, path: "/healthcheck"})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var health Health
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("client: decoding GET /healthcheck response: %w", err)
+	}
+	return &health, nil
+}
+
+// request is one API call. body is sent as is, with contentType.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	contentType string
+}
+
+// jsonRequest creates a request with v encoded as its JSON body.
+func jsonRequest(method, path string, v any) (*request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+// do sends req, retrying it when its method is idempotent, and returns the
+// response of a 2xx status. Any other status is returned as *Error. The
+// caller closes the body.
+func (c *Client) do(ctx context.Context, req *request) (*http.Response, error) {
+	attempts := 1
+	if idempotent(req.method) {
+		attempts = max(c.retry.MaxAttempts, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := c.send(ctx, req)
+
+		var wait time.Duration
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil:
+			wait = c.retry.backoff(attempt)
+		case c.retry.retries(res.StatusCode):
+			wait = retryAfter(res.Header, c.retry.backoff(attempt))
+		}
+
+		if attempt >= attempts || (err == nil && wait == 0) {
+			if err != nil {
+				return nil, err
+			}
+			if res.StatusCode >= 300 {
+				defer res.Body.Close()
+				return nil, decodeError(res)
+			}
+			return res, nil
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	u := c.baseURL.JoinPath(req.path)
+	u.RawQuery = req.query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		httpReq.Header[key] = values
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// doJSON sends req and decodes the data and metadata of the response
+// envelope into data and metadata, either of which may be nil.
+func (c *Client) doJSON(ctx context.Context, req *request, data, metadata any) error {
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := decodeEnvelope(res, data, metadata); err != nil {
+		return fmt.Errorf("client: decoding %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// decodeEnvelope decodes the data and metadata members of the body of res.
+func decodeEnvelope(res *http.Response, data, metadata any) error {
+	var body struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	if data != nil && len(body.Data) > 0 {
+		if err := json.Unmarshal(body.Data, data); err != nil {
+			return fmt.Errorf("data: %w", err)
+		}
+	}
+	if metadata != nil && len(body.Metadata) > 0 {
+		if err := json.Unmarshal(body.Metadata, metadata); err != nil {
+			return fmt.Errorf("metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// idempotent reports whether sending a request with method twice has the
+// effect of sending it once, as defined by RFC 9110. A retried DELETE whose
+// first response was lost answers 404.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p RetryPolicy) retries(status int) bool {
+	for _, s := range p.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait after failed attempt n, counting from 1, with
+// jitter so clients failing together don't retry together.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	wait := p.BaseDelay
+	for i := 1; i < n && wait < p.MaxDelay; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.MaxDelay)
+	if wait <= 0 {
+		return time.Millisecond
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryAfter returns the wait asked for by the Retry-After header, in
+// seconds, or fallback.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return fallback
+	}
+	return max(time.Duration(seconds)*time.Second, time.Millisecond)
+}
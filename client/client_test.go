@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient serves handler and returns a client for it that retries
+// without waiting.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = time.Millisecond
+	return New(srv.URL, WithRetry(policy))
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Run("decodes the error envelope", func(t *testing.T) {
+		// Setup
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			io.WriteString(w, `{"error":{"code":"VALIDATION_FAILED","message":"unable to proccess request because some malformed input","details":{"amount":"amount must be 1 or greater"},"fields":{"amount":{"code":"BELOW_MINIMUM","message":"amount must be 1 or greater"}}}}`)
+		})
+
+		// Execute
+		_, err := c.CreateTransaction(context.Background(), CreateTransactionInput{UserID: 1})
+
+		// Assert
+		var apiErr *Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+		assert.Equal(t, map[string]string{"amount": "amount must be 1 or greater"}, apiErr.Fields)
+		assert.Equal(t, map[string]string{"amount": "BELOW_MINIMUM"}, apiErr.FieldCodes)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("decodes problem details", func(t *testing.T) {
+		// Setup
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			io.WriteString(w, `{"type":"tag:github.com,2026:ucok-man/tcsa/problems/validation","title":"Your request failed validation","status":422,"detail":"unable to proccess request because some malformed input","code":"VALIDATION_FAILED","errors":{"amount":"amount must be 1 or greater"},"fields":{"amount":{"code":"BELOW_MINIMUM","message":"amount must be 1 or greater"}}}`)
+		})
+
+		// Execute
+		_, err := c.CreateTransaction(context.Background(), CreateTransactionInput{UserID: 1})
+
+		// Assert
+		var apiErr *Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+		assert.Equal(t, "tag:github.com,2026:ucok-man/tcsa/problems/validation", apiErr.Type)
+		assert.Equal(t, "unable to proccess request because some malformed input", apiErr.Message)
+		assert.Equal(t, map[string]string{"amount": "amount must be 1 or greater"}, apiErr.Fields)
+		assert.Equal(t, map[string]string{"amount": "BELOW_MINIMUM"}, apiErr.FieldCodes)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("keeps a body that isn't an envelope", func(t *testing.T) {
+		// Setup
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "no such host")
+		})
+
+		// Execute
+		_, err := c.GetTransaction(context.Background(), 1)
+
+		// Assert
+		var apiErr *Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Not Found", apiErr.Code)
+		assert.Equal(t, "no such host", apiErr.Message)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	unavailable := func(failures int32, calls *atomic.Int32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= failures {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				io.WriteString(w, `{"error":{"code":"SERVICE_UNAVAILABLE","message":"try again"}}`)
+				return
+			}
+			io.WriteString(w, `{"data":{"id":1,"user_id":1,"amount":100,"status":"pending","version":1}}`)
+		}
+	}
+
+	t.Run("retries idempotent calls", func(t *testing.T) {
+		// Setup
+		var calls atomic.Int32
+		c := newTestClient(t, unavailable(2, &calls))
+
+		// Execute
+		transaction, err := c.GetTransaction(context.Background(), 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, transaction.ID)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		// Setup
+		var calls atomic.Int32
+		c := newTestClient(t, unavailable(5, &calls))
+
+		// Execute
+		_, err := c.GetTransaction(context.Background(), 1)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("never retries a create", func(t *testing.T) {
+		// Setup
+		var calls atomic.Int32
+		c := newTestClient(t, unavailable(1, &calls))
+
+		// Execute
+		_, err := c.CreateTransaction(context.Background(), CreateTransactionInput{UserID: 1, Amount: 100})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		// Setup
+		var calls atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"error":{"code":"VERSION_CONFLICT","message":"unable to update the record due to an edit conflict, please try again"}}`)
+		})
+
+		// Execute
+		_, err := c.UpdateTransaction(context.Background(), 1, UpdateTransactionInput{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEditConflict)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestClientPagination(t *testing.T) {
+	// Setup
+	var queries []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `{"data":[{"id":%d},{"id":%d}],"metadata":{"current_page":%d,"page_size":2,"first_page":1,"last_page":3,"total_records":6}}`,
+			page*2-1, page*2, page)
+	})
+
+	t.Run("iterates over every page", func(t *testing.T) {
+		queries = nil
+
+		// Execute
+		var ids []int
+		for transaction, err := range c.AllTransactions(context.Background(), ListTransactionsOptions{
+			PageSize: 2,
+			Sort:     Desc(SortAmount),
+			Status:   StatusPending,
+		}) {
+			require.NoError(t, err)
+			ids = append(ids, transaction.ID)
+		}
+
+		// Assert
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, ids)
+		assert.Equal(t, []string{
+			"page=1&page_size=2&sort_by=-amount&status=pending",
+			"page=2&page_size=2&sort_by=-amount&status=pending",
+			"page=3&page_size=2&sort_by=-amount&status=pending",
+		}, queries)
+	})
+
+	t.Run("fetches no further than needed", func(t *testing.T) {
+		queries = nil
+
+		// Execute
+		for transaction, err := range c.AllTransactions(context.Background(), ListTransactionsOptions{Page: 2}) {
+			require.NoError(t, err)
+			if transaction.ID == 3 {
+				break
+			}
+		}
+
+		// Assert
+		assert.Equal(t, []string{"page=2"}, queries)
+	})
+}
+
+func TestClientImport(t *testing.T) {
+	t.Run("returns the report of a small file", func(t *testing.T) {
+		// Setup
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			body, _ := io.ReadAll(file)
+
+			assert.Equal(t, "transactions.csv", header.Filename)
+			assert.Equal(t, "user_id,amount\n1,100\n", string(body))
+			io.WriteString(w, `{"data":{"total_rows":1,"imported":1,"rejected":0,"rejected_rows":[],"truncated":false}}`)
+		})
+
+		// Execute
+		result, err := c.ImportTransactions(context.Background(), "transactions.csv", strings.NewReader("user_id,amount\n1,100\n"))
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, result.Report)
+		assert.Nil(t, result.Job)
+		assert.Equal(t, 1, result.Report.Imported)
+	})
+
+	t.Run("returns the job of a large file", func(t *testing.T) {
+		// Setup
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", "/jobs/7")
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, `{"data":{"id":7,"kind":"transactions.import","status":"queued","links":{"self":"/jobs/7"}}}`)
+		})
+
+		// Execute
+		result, err := c.ImportTransactions(context.Background(), "transactions.csv", strings.NewReader("user_id,amount\n"))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, result.Report)
+		require.NotNil(t, result.Job)
+		assert.Equal(t, 7, result.Job.ID)
+		assert.Equal(t, JobQueued, result.Job.Status)
+	})
+}
+
+func TestClientStream(t *testing.T) {
+	// Setup
+	var lastEventID string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		lastEventID = r.Header.Get("Last-Event-ID")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "retry: 3000\n\n")
+		io.WriteString(w, `id: 5`+"\nevent: transaction.created\n"+`data: {"id":"a","type":"transaction.created","data":{"transaction":{"id":1}}}`+"\n\n")
+		io.WriteString(w, ": heartbeat\n\n")
+		io.WriteString(w, `id: 6`+"\nevent: transaction.deleted\n"+`data: {"id":"b","type":"transaction.deleted","data":{"transaction":{"id":1}}}`+"\n\n")
+	})
+
+	resume := 4
+
+	// Execute
+	stream, err := c.StreamTransactions(context.Background(), StreamOptions{LastEventID: &resume})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var events []*StreamEvent
+	for {
+		event, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+
+	// Assert
+	assert.Equal(t, "4", lastEventID)
+	require.Len(t, events, 2)
+	assert.Equal(t, 5, events[0].ID)
+	assert.Equal(t, EventTransactionCreated, events[0].Type)
+	assert.Equal(t, EventTransactionDeleted, events[1].Type)
+	assert.Equal(t, 6, stream.LastEventID())
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// SummaryOptions selects the summarized transactions. DateRange counts days
+// back from today, zero summarizes every transaction.
+type SummaryOptions struct {
+	Page      int
+	PageSize  int
+	Sort      Sort
+	DateRange int
+	UserID    int
+}
+
+func (o SummaryOptions) query() url.Values {
+	query := url.Values{}
+	setInt(query, "page", o.Page)
+	setInt(query, "page_size", o.PageSize)
+	setString(query, "sort_by", string(o.Sort))
+	setInt(query, "date_range", o.DateRange)
+	setInt(query, "user_id", o.UserID)
+	return query
+}
+
+// Summary returns the summary of the selected transactions, with one page of
+// them.
+func (c *Client) Summary(ctx context.Context, opts SummaryOptions) (*SummaryPage, error) {
+	var page SummaryPage
+
+	req := &request{method: http.MethodGet, path: "/dashboard/summary", query: opts.query()}
+	if err := c.doJSON(ctx, req, &page, &page.Metadata); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllSummaryTransactions iterates over the summarized transactions of every
+// page, starting at opts.Page.
+func (c *Client) AllSummaryTransactions(ctx context.Context, opts SummaryOptions) iter.Seq2[*Transaction, error] {
+	return paginate(opts.Page, func(page int) ([]*Transaction, Metadata, error) {
+		opts.Page = page
+		summary, err := c.Summary(ctx, opts)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		return summary.Transactions, summary.Metadata, nil
+	})
+}
+
+// WatchSummary connects to the live summary and calls fn with the current
+// summary, then again after every change to it. It returns when ctx is
+// done, when fn returns an error, or when the connection fails. The
+// connection is never retried, call WatchSummary again to reconnect.
+func (c *Client) WatchSummary(ctx context.Context, opts SummaryOptions, fn func(*SummaryPage) error) error {
+	u := c.baseURL.JoinPath("/dashboard/summary/ws")
+	u.RawQuery = opts.query().Encode()
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, u.String(), c.header.Clone())
+	if err != nil {
+		if res != nil {
+			defer res.Body.Close()
+			return decodeError(res)
+		}
+		return err
+	}
+	defer conn.Close()
+
+	// Unblocks the read below once ctx is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		var message struct {
+			Type     string          `json:"type"`
+			Data     json.RawMessage `json:"data"`
+			Metadata Metadata        `json:"metadata"`
+			Error    string          `json:"error"`
+		}
+		if err := conn.ReadJSON(&message); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if message.Type == "error" {
+			return fmt.Errorf("client: summary: %s", message.Error)
+		}
+
+		var page SummaryPage
+		if err := json.Unmarshal(message.Data, &page); err != nil {
+			return fmt.Errorf("client: decoding summary: %w", err)
+		}
+		page.Metadata = message.Metadata
+
+		if err := fn(&page); err != nil {
+			return err
+		}
+	}
+}
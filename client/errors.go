@@ -0,0 +1,160 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// Sentinels matched by *Error through errors.Is, e.g.
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+var (
+	ErrBadRequest         = errors.New("client: bad request")
+	ErrNotFound           = errors.New("client: not found")
+	ErrEditConflict       = errors.New("client: edit conflict")
+	ErrValidation         = errors.New("client: failed validation")
+	ErrRateLimited        = errors.New("client: rate limited")
+	ErrServiceUnavailable = errors.New("client: service unavailable")
+)
+
+// Error is an error response of the API, decoded from its
+// {"error": {"code", "message", "details", "fields"}} envelope or from its
+// application/problem+json problem details.
+type Error struct {
+	StatusCode int
+
+	// Code is the stable code of the error, like "VERSION_CONFLICT" or
+	// "TRANSACTION_NOT_FOUND", or the status text when the body had none.
+	Code    string
+	Message string
+
+	// Type is the problem type URI of problem details, like
+	// "tag:github.com,2026:ucok-man/tcsa/problems/edit_conflict", it is empty for
+	// envelopes.
+	Type string
+
+	// Details holds the details member as sent, it is empty when the
+	// response had none.
+	Details json.RawMessage
+
+	// Fields maps the invalid fields to their message when Details is a
+	// validation error map, as answered with 422.
+	Fields map[string]string
+
+	// FieldCodes maps the invalid fields to the code of the rule they
+	// failed, like "REQUIRED" or "BELOW_MINIMUM", which unlike the
+	// messages doesn't depend on the language.
+	FieldCodes map[string]string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("client: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	if len(e.Fields) > 0 {
+		msg += fmt.Sprintf(" %v", e.Fields)
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrEditConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServiceUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	default:
+		return false
+	}
+}
+
+// decodeError reads the error envelope of res. A body that isn't one, like
+// the page of a proxy, gives an Error with the status text as its code.
+func decodeError(res *http.Response) error {
+	apiErr := &Error{
+		StatusCode: res.StatusCode,
+		Code:       http.StatusText(res.StatusCode),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		apiErr.Message = err.Error()
+		return apiErr
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType == "application/problem+json" {
+		var problem struct {
+			Type   string                `json:"type"`
+			Detail string                `json:"detail"`
+			Code   string                `json:"code"`
+			Errors json.RawMessage       `json:"errors"`
+			Fields map[string]fieldError `json:"fields"`
+		}
+		if err := json.Unmarshal(body, &problem); err != nil || problem.Type == "" {
+			apiErr.Message = string(body)
+			return apiErr
+		}
+		apiErr.Type = problem.Type
+		if problem.Code != "" {
+			apiErr.Code = problem.Code
+		}
+		apiErr.Message = problem.Detail
+		apiErr.Details = problem.Errors
+		return withFields(apiErr, problem.Fields)
+	}
+
+	var envelope struct {
+		Error struct {
+			Code    string                `json:"code"`
+			Message string                `json:"message"`
+			Details json.RawMessage       `json:"details"`
+			Fields  map[string]fieldError `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error.Message == "" {
+		apiErr.Message = string(body)
+		return apiErr
+	}
+
+	if envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+	}
+	apiErr.Message = envelope.Error.Message
+	if string(envelope.Error.Details) != "null" {
+		apiErr.Details = envelope.Error.Details
+	}
+
+	return withFields(apiErr, envelope.Error.Fields)
+}
+
+// fieldError is the code and the message of an invalid field.
+type fieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// withFields sets the Fields of apiErr when its details are a validation
+// error map, and its FieldCodes from codes.
+func withFields(apiErr *Error, codes map[string]fieldError) error {
+	var fields map[string]string
+	if json.Unmarshal(apiErr.Details, &fields) == nil {
+		apiErr.Fields = fields
+	}
+	if len(codes) > 0 {
+		apiErr.FieldCodes = make(map[string]string, len(codes))
+		for field, reason := range codes {
+			apiErr.FieldCodes[field] = reason.Code
+		}
+	}
+	return apiErr
+}
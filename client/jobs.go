@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (c *Client) GetJob(ctx context.Context, id int) (*Job, error) {
+	var job Job
+
+	req := &request{method: http.MethodGet, path: jobPath(id)}
+	if err := c.doJSON(ctx, req, &job, nil); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobResult decodes the result of a completed job into v, e.g. an
+// *ImportReport for an import. A job that isn't completed answers
+// ErrNotFound.
+func (c *Client) GetJobResult(ctx context.Context, id int, v any) error {
+	req := &request{method: http.MethodGet, path: jobPath(id) + "/result"}
+	return c.doJSON(ctx, req, v, nil)
+}
+
+// WaitJob polls a job every interval until it is done, and returns it.
+func (c *Client) WaitJob(ctx context.Context, id int, interval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func jobPath(id int) string {
+	return "/jobs/" + strconv.Itoa(id)
+}
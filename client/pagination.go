@@ -0,0 +1,32 @@
+package client
+
+import "iter"
+
+// paginate iterates over the items of every page from start, fetched one at
+// a time as the iteration goes. It ends past the last page, or after
+// yielding the first error.
+func paginate[T any](start int, fetch func(page int) ([]T, Metadata, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		page := max(start, 1)
+		for {
+			items, metadata, err := fetch(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			// An empty page has empty metadata.
+			if len(items) == 0 || metadata.CurrentPage >= metadata.LastPage {
+				return
+			}
+			page = metadata.CurrentPage + 1
+		}
+	}
+}
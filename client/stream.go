@@ -0,0 +1,127 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// StreamOptions selects the streamed events. With LastEventID set, the
+// events after it that are still in the outbox are sent first.
+type StreamOptions struct {
+	Status      TransactionStatus
+	UserID      int
+	LastEventID *int
+}
+
+// StreamEvent is an event of the stream. ID is its position in the outbox,
+// pass the last one seen as StreamOptions.LastEventID to resume.
+type StreamEvent struct {
+	ID int
+	Event
+}
+
+// EventStream reads the events of StreamTransactions. It isn't safe for
+// concurrent use.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	lastID  int
+}
+
+// StreamTransactions follows the transaction events as they happen. The
+// stream lasts until ctx is done, Close is called or the server goes away,
+// so the *http.Client of the client must not have a Timeout.
+func (c *Client) StreamTransactions(ctx context.Context, opts StreamOptions) (*EventStream, error) {
+	query := url.Values{}
+	setString(query, "status", string(opts.Status))
+	setInt(query, "user_id", opts.UserID)
+
+	header := http.Header{"Accept": {"text/event-stream"}}
+	lastID := -1
+	if opts.LastEventID != nil {
+		lastID = *opts.LastEventID
+		header.Set("Last-Event-ID", strconv.Itoa(lastID))
+	}
+
+	req := &request{method: http.MethodGet, path: "/transactions/stream", query: query, header: header}
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	return &EventStream{body: res.Body, scanner: scanner, lastID: lastID}, nil
+}
+
+// Next blocks until the next event and returns it. It returns io.EOF once
+// the server ends the stream, the caller may then resume from LastEventID.
+func (s *EventStream) Next() (*StreamEvent, error) {
+	var id, data string
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+
+		// A blank line ends an event, comments like heartbeats and
+		// events without data are skipped.
+		if line == "" {
+			if data == "" {
+				id = ""
+				continue
+			}
+			return s.event(id, data)
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *EventStream) event(id, data string) (*StreamEvent, error) {
+	event := &StreamEvent{}
+	if err := json.Unmarshal([]byte(data), &event.Event); err != nil {
+		return nil, fmt.Errorf("client: decoding stream event %s: %w", id, err)
+	}
+
+	if id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("client: invalid stream event id %q", id)
+		}
+		event.ID = n
+		s.lastID = n
+	}
+	return event, nil
+}
+
+// LastEventID returns the id of the last event read, or of
+// StreamOptions.LastEventID before one is. It is -1 when there is neither.
+func (s *EventStream) LastEventID() int {
+	return s.lastID
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
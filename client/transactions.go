@@ -0,0 +1,353 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SortField is a column transactions can be sorted by.
+type SortField string
+
+const (
+	SortID        SortField = "id"
+	SortUserID    SortField = "user_id"
+	SortAmount    SortField = "amount"
+	SortStatus    SortField = "status"
+	SortCreatedAt SortField = "created_at"
+)
+
+// Sort is the sort_by parameter, made with Asc or Desc. The zero value
+// keeps the server default, by id ascending.
+type Sort string
+
+func Asc(field SortField) Sort {
+	return Sort(field)
+}
+
+func Desc(field SortField) Sort {
+	return Sort("-" + field)
+}
+
+// ListTransactionsOptions selects a page of transactions. Zero fields keep
+// the server defaults and don't filter.
+type ListTransactionsOptions struct {
+	Page     int
+	PageSize int
+	Sort     Sort
+	Status   TransactionStatus
+	UserID   int
+}
+
+func (o ListTransactionsOptions) query() url.Values {
+	query := url.Values{}
+	setInt(query, "page", o.Page)
+	setInt(query, "page_size", o.PageSize)
+	setString(query, "sort_by", string(o.Sort))
+	setString(query, "status", string(o.Status))
+	setInt(query, "user_id", o.UserID)
+	return query
+}
+
+// ListTransactions returns one page of transactions.
+func (c *Client) ListTransactions(ctx context.Context, opts ListTransactionsOptions) ([]*Transaction, Metadata, error) {
+	var transactions []*Transaction
+	var metadata Metadata
+
+	req := &request{method: http.MethodGet, path: "/transactions", query: opts.query()}
+	if err := c.doJSON(ctx, req, &transactions, &metadata); err != nil {
+		return nil, Metadata{}, err
+	}
+	return transactions, metadata, nil
+}
+
+// AllTransactions iterates over the transactions of every page, starting at
+// opts.Page. The iteration ends after the first error.
+func (c *Client) AllTransactions(ctx context.Context, opts ListTransactionsOptions) iter.Seq2[*Transaction, error] {
+	return paginate(opts.Page, func(page int) ([]*Transaction, Metadata, error) {
+		opts.Page = page
+		return c.ListTransactions(ctx, opts)
+	})
+}
+
+func (c *Client) GetTransaction(ctx context.Context, id int) (*Transaction, error) {
+	var transaction Transaction
+
+	req := &request{method: http.MethodGet, path: transactionPath(id)}
+	if err := c.doJSON(ctx, req, &transaction, nil); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+type CreateTransactionInput struct {
+	UserID int `json:"user_id"`
+	Amount int `json:"amount"`
+}
+
+// CreateTransaction creates a pending transaction. It is never retried.
+func (c *Client) CreateTransaction(ctx context.Context, input CreateTransactionInput) (*Transaction, error) {
+	var transaction Transaction
+
+	req, err := jsonRequest(http.MethodPost, "/transactions", input)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &transaction, nil); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// CreateTransactions creates every item or, when one is invalid, none of
+// them. The errors of the invalid items are in the Fields of the *Error,
+// keyed by "items[i].field".
+func (c *Client) CreateTransactions(ctx context.Context, items []CreateTransactionInput) ([]*Transaction, error) {
+	var transactions []*Transaction
+
+	req, err := jsonRequest(http.MethodPost, "/transactions/batch", map[string]any{
+		"mode":  "atomic",
+		"items": items,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &transactions, nil); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateTransactionsPartial creates the valid items and reports the outcome
+// of each one.
+func (c *Client) CreateTransactionsPartial(ctx context.Context, items []CreateTransactionInput) (*BatchResult, error) {
+	var result BatchResult
+	var metadata struct {
+		Created int `json:"created"`
+		Failed  int `json:"failed"`
+	}
+
+	req, err := jsonRequest(http.MethodPost, "/transactions/batch", map[string]any{
+		"mode":  "partial",
+		"items": items,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &result.Items, &metadata); err != nil {
+		return nil, err
+	}
+
+	result.Created = metadata.Created
+	result.Failed = metadata.Failed
+	return &result, nil
+}
+
+// UpdateTransactionInput changes the fields that are set.
+type UpdateTransactionInput struct {
+	Amount *int               `json:"amount,omitempty"`
+	Status *TransactionStatus `json:"status,omitempty"`
+}
+
+func (c *Client) UpdateTransaction(ctx context.Context, id int, input UpdateTransactionInput) (*Transaction, error) {
+	var transaction Transaction
+
+	req, err := jsonRequest(http.MethodPut, transactionPath(id), input)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &transaction, nil); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// DeleteTransaction deletes a transaction and returns it.
+func (c *Client) DeleteTransaction(ctx context.Context, id int) (*Transaction, error) {
+	var transaction Transaction
+
+	req := &request{method: http.MethodDelete, path: transactionPath(id)}
+	if err := c.doJSON(ctx, req, &transaction, nil); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// BulkItem selects a transaction of a bulk operation. A Version of zero
+// skips the version check.
+type BulkItem struct {
+	ID      int `json:"id"`
+	Version int `json:"version,omitempty"`
+}
+
+// BulkFilter selects transactions by status, user or both. One of them is
+// required.
+type BulkFilter struct {
+	Status TransactionStatus `json:"status,omitempty"`
+	UserID int               `json:"user_id,omitempty"`
+}
+
+// BulkUpdateInput selects transactions either by Items or by Filter.
+type BulkUpdateInput struct {
+	Items  []BulkItem        `json:"items,omitempty"`
+	Filter *BulkFilter       `json:"filter,omitempty"`
+	Status TransactionStatus `json:"status"`
+	DryRun bool              `json:"dry_run"`
+}
+
+// BulkDeleteInput selects transactions either by Items or by Filter.
+type BulkDeleteInput struct {
+	Items  []BulkItem  `json:"items,omitempty"`
+	Filter *BulkFilter `json:"filter,omitempty"`
+	DryRun bool        `json:"dry_run"`
+}
+
+// BulkUpdateTransactions changes the status of many transactions at once.
+func (c *Client) BulkUpdateTransactions(ctx context.Context, input BulkUpdateInput) (*BulkResult, error) {
+	return c.bulk(ctx, "/transactions/bulk-update", input)
+}
+
+// BulkDeleteTransactions deletes many transactions at once.
+func (c *Client) BulkDeleteTransactions(ctx context.Context, input BulkDeleteInput) (*BulkResult, error) {
+	return c.bulk(ctx, "/transactions/bulk-delete", input)
+}
+
+func (c *Client) bulk(ctx context.Context, path string, input any) (*BulkResult, error) {
+	var body struct {
+		Transactions []*Transaction `json:"transactions"`
+		Conflicts    []BulkConflict `json:"conflicts"`
+	}
+	var metadata struct {
+		Affected int  `json:"affected"`
+		DryRun   bool `json:"dry_run"`
+	}
+
+	req, err := jsonRequest(http.MethodPost, path, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &body, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &BulkResult{
+		Transactions: body.Transactions,
+		Conflicts:    body.Conflicts,
+		Affected:     metadata.Affected,
+		DryRun:       metadata.DryRun,
+	}, nil
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ExportOptions selects what is exported. Empty Columns exports every
+// column.
+type ExportOptions struct {
+	Format  ExportFormat
+	Columns []string
+	Sort    Sort
+	Status  TransactionStatus
+	UserID  int
+}
+
+// ExportTransactions streams the selected transactions. The caller closes
+// the returned reader.
+func (c *Client) ExportTransactions(ctx context.Context, opts ExportOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	setString(query, "format", string(opts.Format))
+	setString(query, "columns", strings.Join(opts.Columns, ","))
+	setString(query, "sort_by", string(opts.Sort))
+	setString(query, "status", string(opts.Status))
+	setInt(query, "user_id", opts.UserID)
+
+	req := &request{
+		method: http.MethodGet,
+		path:   "/transactions/export",
+		query:  query,
+		header: http.Header{"Accept": {"*/*"}},
+	}
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// ImportResult is the answer to an import. Small files are imported right
+// away and have a Report. Larger ones are imported by a Job, whose result is
+// the report once it completes, see WaitJob.
+type ImportResult struct {
+	Report *ImportReport
+	Job    *Job
+}
+
+// ImportTransactions uploads a CSV file of transactions. It is never
+// retried.
+func (c *Client) ImportTransactions(ctx context.Context, filename string, file io.Reader) (*ImportResult, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("client: reading %s: %w", filename, err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req := &request{
+		method:      http.MethodPost,
+		path:        "/transactions/import",
+		body:        body.Bytes(),
+		contentType: form.FormDataContentType(),
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var result ImportResult
+	var data any = &result.Report
+	if res.StatusCode == http.StatusAccepted {
+		data = &result.Job
+	}
+	if err := decodeEnvelope(res, data, nil); err != nil {
+		return nil, fmt.Errorf("client: decoding %s %s response: %w", req.method, req.path, err)
+	}
+	return &result, nil
+}
+
+func transactionPath(id int) string {
+	return "/transactions/" + strconv.Itoa(id)
+}
+
+// setString sets a query parameter unless value is empty.
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setInt sets a query parameter unless value is zero.
+func setInt(query url.Values, key string, value int) {
+	if value != 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type TransactionStatus string
+
+const (
+	StatusPending TransactionStatus = "pending"
+	StatusSuccess TransactionStatus = "success"
+	StatusFailed  TransactionStatus = "failed"
+)
+
+type Transaction struct {
+	ID        int               `json:"id"`
+	UserID    int               `json:"user_id"`
+	Amount    int               `json:"amount"`
+	Status    TransactionStatus `json:"status"`
+	Version   int               `json:"version"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Metadata describes the page of a list. It is empty when the page has no
+// records.
+type Metadata struct {
+	CurrentPage  int `json:"current_page"`
+	PageSize     int `json:"page_size"`
+	FirstPage    int `json:"first_page"`
+	LastPage     int `json:"last_page"`
+	TotalRecords int `json:"total_records"`
+}
+
+type StatusSummary struct {
+	Count          int     `json:"count"`
+	RatePercentage float64 `json:"rate_percentage"`
+}
+
+type Summary struct {
+	CountTotal int           `json:"total_transaction"`
+	Pending    StatusSummary `json:"pending"`
+	Success    StatusSummary `json:"success"`
+	Failed     StatusSummary `json:"failed"`
+}
+
+// SummaryPage is a page of the transactions of the summarized date range,
+// with the summary of all of them.
+type SummaryPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	Summary      Summary        `json:"summary"`
+	Metadata     Metadata       `json:"-"`
+}
+
+// BatchItemResult is the outcome of one item of a partial batch. Status is
+// "created", with the transaction, or "failed", with the errors by field.
+type BatchItemResult struct {
+	Index       int               `json:"index"`
+	Status      string            `json:"status"`
+	Transaction *Transaction      `json:"transaction,omitempty"`
+	Errors      map[string]string `json:"errors,omitempty"`
+}
+
+// BatchResult is the outcome of a partial batch.
+type BatchResult struct {
+	Items   []BatchItemResult
+	Created int
+	Failed  int
+}
+
+// BulkConflict is a transaction selected by id that a bulk operation left
+// untouched, because of its version or status.
+type BulkConflict struct {
+	ID             int               `json:"id"`
+	Reason         string            `json:"reason"`
+	CurrentVersion int               `json:"current_version,omitempty"`
+	CurrentStatus  TransactionStatus `json:"current_status,omitempty"`
+}
+
+// BulkResult is the outcome of a bulk operation. Transactions holds the
+// changed transactions and is empty on a dry run.
+type BulkResult struct {
+	Transactions []*Transaction
+	Conflicts    []BulkConflict
+	Affected     int
+	DryRun       bool
+}
+
+type ImportRejectedRow struct {
+	Line   int               `json:"line"`
+	Errors map[string]string `json:"errors"`
+}
+
+type ImportReport struct {
+	TotalRows    int                 `json:"total_rows"`
+	Imported     int                 `json:"imported"`
+	Rejected     int                 `json:"rejected"`
+	RejectedRows []ImportRejectedRow `json:"rejected_rows"`
+	Truncated    bool                `json:"truncated"`
+}
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+type Job struct {
+	ID          int               `json:"id"`
+	Kind        string            `json:"kind"`
+	Status      JobStatus         `json:"status"`
+	Payload     json.RawMessage   `json:"payload,omitempty"`
+	Progress    json.RawMessage   `json:"progress,omitempty"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	Error       string            `json:"error,omitempty"`
+	RunAt       time.Time         `json:"run_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Links       map[string]string `json:"links"`
+}
+
+// Done reports whether the job won't run again.
+func (j *Job) Done() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
+
+type EventType string
+
+const (
+	EventTransactionCreated       EventType = "transaction.created"
+	EventTransactionUpdated       EventType = "transaction.updated"
+	EventTransactionStatusChanged EventType = "transaction.status_changed"
+	EventTransactionDeleted       EventType = "transaction.deleted"
+)
+
+type Event struct {
+	ID         string          `json:"id"`
+	Type       EventType       `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// TransactionEventData is the data of every transaction event.
+// PreviousStatus is only set by transaction.status_changed.
+type TransactionEventData struct {
+	Transaction    *Transaction      `json:"transaction"`
+	PreviousStatus TransactionStatus `json:"previous_status,omitempty"`
+}
+
+// Webhook is a registered webhook. Its secret is only returned when it is
+// created.
+type Webhook struct {
+	ID         int         `json:"id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+type WebhookAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitzero"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int                   `json:"id"`
+	WebhookID     int                   `json:"webhook_id"`
+	EventID       string                `json:"event_id"`
+	EventType     EventType             `json:"event_type"`
+	Payload       json.RawMessage       `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Log           []*WebhookAttempt     `json:"log"`
+}
+
+type Health struct {
+	Status     string `json:"status"`
+	SystemInfo struct {
+		Environment string `json:"environment"`
+		Version     string `json:"version"`
+	} `json:"system_info"`
+}
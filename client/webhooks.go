@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateWebhookInput registers url for the event types. An empty Secret
+// lets the server generate one.
+type CreateWebhookInput struct {
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	Secret     string      `json:"secret,omitempty"`
+}
+
+// CreateWebhook registers a webhook. The returned webhook holds its secret,
+// which isn't returned again. It is never retried.
+func (c *Client) CreateWebhook(ctx context.Context, input CreateWebhookInput) (*Webhook, error) {
+	var webhook Webhook
+
+	req, err := jsonRequest(http.MethodPost, "/webhooks", input)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.doJSON(ctx, req, &webhook, nil); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooksOptions selects a page of webhooks. Zero fields keep the
+// server defaults.
+type ListWebhooksOptions struct {
+	Page     int
+	PageSize int
+}
+
+// ListWebhooks returns one page of webhooks.
+func (c *Client) ListWebhooks(ctx context.Context, opts ListWebhooksOptions) ([]*Webhook, Metadata, error) {
+	var webhooks []*Webhook
+	var metadata Metadata
+
+	query := url.Values{}
+	setInt(query, "page", opts.Page)
+	setInt(query, "page_size", opts.PageSize)
+
+	req := &request{method: http.MethodGet, path: "/webhooks", query: query}
+	if err := c.doJSON(ctx, req, &webhooks, &metadata); err != nil {
+		return nil, Metadata{}, err
+	}
+	return webhooks, metadata, nil
+}
+
+// AllWebhooks iterates over the webhooks of every page, starting at
+// opts.Page.
+func (c *Client) AllWebhooks(ctx context.Context, opts ListWebhooksOptions) iter.Seq2[*Webhook, error] {
+	return paginate(opts.Page, func(page int) ([]*Webhook, Metadata, error) {
+		opts.Page = page
+		return c.ListWebhooks(ctx, opts)
+	})
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var webhook Webhook
+
+	req := &request{method: http.MethodGet, path: webhookPath(id)}
+	if err := c.doJSON(ctx, req, &webhook, nil); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// DeleteWebhook deletes a webhook and returns it.
+func (c *Client) DeleteWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var webhook Webhook
+
+	req := &request{method: http.MethodDelete, path: webhookPath(id)}
+	if err := c.doJSON(ctx, req, &webhook, nil); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListDeliveriesOptions selects a page of deliveries. Zero fields keep the
+// server defaults and don't filter.
+type ListDeliveriesOptions struct {
+	Page     int
+	PageSize int
+	Status   WebhookDeliveryStatus
+}
+
+// ListWebhookDeliveries returns one page of the deliveries of a webhook,
+// with the log of their attempts.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID int, opts ListDeliveriesOptions) ([]*WebhookDelivery, Metadata, error) {
+	var deliveries []*WebhookDelivery
+	var metadata Metadata
+
+	query := url.Values{}
+	setInt(query, "page", opts.Page)
+	setInt(query, "page_size", opts.PageSize)
+	setString(query, "status", string(opts.Status))
+
+	req := &request{method: http.MethodGet, path: webhookPath(webhookID) + "/deliveries", query: query}
+	if err := c.doJSON(ctx, req, &deliveries, &metadata); err != nil {
+		return nil, Metadata{}, err
+	}
+	return deliveries, metadata, nil
+}
+
+// AllWebhookDeliveries iterates over the deliveries of every page, starting
+// at opts.Page.
+func (c *Client) AllWebhookDeliveries(ctx context.Context, webhookID int, opts ListDeliveriesOptions) iter.Seq2[*WebhookDelivery, error] {
+	return paginate(opts.Page, func(page int) ([]*WebhookDelivery, Metadata, error) {
+		opts.Page = page
+		return c.ListWebhookDeliveries(ctx, webhookID, opts)
+	})
+}
+
+func webhookPath(id int) string {
+	return "/webhooks/" + strconv.Itoa(id)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{name: "serve", summary: "Start the HTTP API server (default)", run: serveCommand},
+		{name: "migrate", summary: "Apply or inspect database migrations", run: migrateCommand},
+		{name: "seed", summary: "Insert generated transactions for development", run: seedCommand},
+		{name: "export", summary: "Export transactions as NDJSON or CSV", run: exportCommand},
+		{name: "purge", summary: "Delete transactions matching a filter", run: purgeCommand},
+		{name: "config", summary: "Inspect the resolved configuration", run: configCommand},
+		{name: "openapi", summary: "Generate the OpenAPI document of the routes", run: openapiCommand},
+		{name: "healthcheck", summary: "Probe a running server, exits non-zero when unhealthy", run: healthcheckCommand},
+	}
+}
+
+// run dispatches args to a subcommand. Without a subcommand the server is
+// started, so flags-only invocations keep working.
+func run(args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		switch {
+		case args[0] == "-h" || args[0] == "--help" || args[0] == "help":
+			printUsage(os.Stderr)
+			return pflag.ErrHelp
+		case !strings.HasPrefix(args[0], "-"):
+			name, args = args[0], args[1:]
+		}
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+
+	printUsage(os.Stderr)
+	return fmt.Errorf("unknown command %q", name)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage of %s:\n\n", os.Args[0])
+	fmt.Fprintf(w, "  %s <command> [options]\n\n", os.Args[0])
+
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for the options of a command.\n", os.Args[0])
+}
+
+// newFlagSet creates the flag set of a subcommand. Its usage lists the
+// command options followed by the environment variables read by NewConfig.
+func newFlagSet(name, usage string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	fs.SortFlags = false
+
+	fs.Usage = func() {
+		w := fs.Output()
+
+		fmt.Fprintf(w, "Usage of %s %s:\n\n", os.Args[0], name)
+		fmt.Fprintf(w, "  %s %s\n\n", os.Args[0], usage)
+
+		// Use PrintDefaults() to print the standard flag descriptions
+		fmt.Fprintln(w, "Options:")
+		fs.PrintDefaults()
+
+		// Print any custom postamble
+		printConfigEnv(w)
+	}
+
+	return fs
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "unknown command",
+			args:    []string{"frobnicate"},
+			wantErr: `unknown command "frobnicate"`,
+		},
+		{
+			name:    "unknown flag without a command",
+			args:    []string{"--no-such-flag"},
+			wantErr: "unknown flag: --no-such-flag",
+		},
+		{
+			name:    "serve unknown flag",
+			args:    []string{"serve", "--no-such-flag"},
+			wantErr: "unknown flag: --no-such-flag",
+		},
+		{
+			name:    "migrate unknown flag",
+			args:    []string{"migrate", "--no-such-flag"},
+			wantErr: "unknown flag: --no-such-flag",
+		},
+		{
+			name:    "seed invalid count",
+			args:    []string{"seed", "--count", "many"},
+			wantErr: `invalid argument "many" for "--count"`,
+		},
+		{
+			name:    "seed invalid now",
+			args:    []string{"seed", "--now", "yesterday"},
+			wantErr: "invalid --now",
+		},
+		{
+			name:    "export unknown column",
+			args:    []string{"export", "--columns", "id,nope"},
+			wantErr: `unknown column "nope"`,
+		},
+		{
+			name:    "purge without filter",
+			args:    []string{"purge", "--yes"},
+			wantErr: "refusing to purge every transaction without --all",
+		},
+		{
+			name:    "purge without confirmation",
+			args:    []string{"purge", "--status", "failed"},
+			wantErr: "refusing to purge without --yes",
+		},
+		{
+			name:    "purge invalid duration",
+			args:    []string{"purge", "--older-than", "a while"},
+			wantErr: `invalid argument "a while" for "--older-than"`,
+		},
+		{
+			name:    "config without action",
+			args:    []string{"config"},
+			wantErr: "usage: config print",
+		},
+		{
+			name: "config print",
+			args: []string{"config", "print"},
+		},
+		{
+			name: "openapi to file",
+			args: []string{"openapi", "-o", filepath.Join(t.TempDir(), "swagger.yaml")},
+		},
+		{
+			name:    "healthcheck invalid timeout",
+			args:    []string{"healthcheck", "--timeout", "soon"},
+			wantErr: `invalid argument "soon" for "--timeout"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TCSA_STORAGE", "memory")
+
+			err := run(tt.args)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		for _, args := range [][]string{{"help"}, {"--help"}, {"seed", "--help"}} {
+			assert.ErrorIs(t, run(args), pflag.ErrHelp, args)
+		}
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: 0},
+		{name: "help", err: pflag.ErrHelp, want: 0},
+		{name: "failure", err: errors.New("healthcheck failed"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
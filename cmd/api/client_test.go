@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/client"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// TestClient checks the client package against the routes it calls, so a
+// change to a response shape breaks here rather than in another service.
+func TestClient(t *testing.T) {
+	newClient := func(t *testing.T) *client.Client {
+		app := createTestApp(t, data.NewMemoryModels())
+		app.config.Database.Storage = "memory"
+		app.config.Batch.MaxItems = 100
+		app.config.Batch.MaxBodyBytes = 1 << 20
+		app.config.Import.MaxFileBytes = 1 << 20
+		app.config.Import.AsyncThresholdBytes = 1 << 20
+		app.config.Stream.HeartbeatInterval = time.Minute
+		app.config.Stream.BufferSize = 16
+		app.config.Stream.SummaryDebounce = 10 * time.Millisecond
+
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+		return client.New(srv.URL)
+	}
+
+	ctx := context.Background()
+
+	t.Run("creates, updates and lists transactions", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+
+		_, err := c.CreateTransactions(ctx, []client.CreateTransactionInput{
+			{UserID: 1, Amount: 100},
+			{UserID: 1, Amount: 300},
+			{UserID: 2, Amount: 200},
+		})
+		require.NoError(t, err)
+
+		// Execute
+		status := client.StatusSuccess
+		updated, err := c.UpdateTransaction(ctx, 1, client.UpdateTransactionInput{Status: &status})
+		require.NoError(t, err)
+
+		var ids []int
+		for transaction, err := range c.AllTransactions(ctx, client.ListTransactionsOptions{
+			PageSize: 1,
+			Sort:     client.Desc(client.SortAmount),
+			UserID:   1,
+		}) {
+			require.NoError(t, err)
+			ids = append(ids, transaction.ID)
+		}
+
+		// Assert
+		assert.Equal(t, client.StatusSuccess, updated.Status)
+		assert.Equal(t, 2, updated.Version)
+		assert.Equal(t, []int{2, 1}, ids)
+	})
+
+	t.Run("returns typed errors", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+
+		// Execute
+		_, notFound := c.GetTransaction(ctx, 42)
+		_, invalid := c.CreateTransaction(ctx, client.CreateTransactionInput{UserID: 1})
+
+		// Assert
+		assert.ErrorIs(t, notFound, client.ErrNotFound)
+
+		var apiErr *client.Error
+		require.ErrorAs(t, invalid, &apiErr)
+		assert.ErrorIs(t, invalid, client.ErrValidation)
+		assert.Contains(t, apiErr.Fields, "amount")
+	})
+
+	t.Run("reports partial batches and bulk updates", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+
+		// Execute
+		batch, err := c.CreateTransactionsPartial(ctx, []client.CreateTransactionInput{
+			{UserID: 1, Amount: 100},
+			{UserID: 1},
+			{UserID: 2, Amount: 200},
+		})
+		require.NoError(t, err)
+
+		bulk, err := c.BulkUpdateTransactions(ctx, client.BulkUpdateInput{
+			Filter: &client.BulkFilter{UserID: 1},
+			Status: client.StatusFailed,
+		})
+		require.NoError(t, err)
+
+		summary, err := c.Summary(ctx, client.SummaryOptions{DateRange: 1})
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 2, batch.Created)
+		assert.Equal(t, 1, batch.Failed)
+		assert.Equal(t, "failed", batch.Items[1].Status)
+		assert.Contains(t, batch.Items[1].Errors, "amount")
+
+		assert.Equal(t, 1, bulk.Affected)
+		require.Len(t, bulk.Transactions, 1)
+		assert.Equal(t, client.StatusFailed, bulk.Transactions[0].Status)
+
+		assert.Equal(t, 2, summary.Summary.CountTotal)
+		assert.Equal(t, 1, summary.Summary.Failed.Count)
+		assert.Equal(t, 2, summary.Metadata.TotalRecords)
+	})
+
+	t.Run("imports and exports csv", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+
+		// Execute
+		result, err := c.ImportTransactions(ctx, "transactions.csv", strings.NewReader("user_id,amount\n1,100\n2,0\n"))
+		require.NoError(t, err)
+
+		export, err := c.ExportTransactions(ctx, client.ExportOptions{
+			Format:  client.ExportCSV,
+			Columns: []string{"id", "amount"},
+		})
+		require.NoError(t, err)
+		defer export.Close()
+
+		body, err := io.ReadAll(export)
+		require.NoError(t, err)
+
+		// Assert
+		require.NotNil(t, result.Report)
+		assert.Equal(t, 1, result.Report.Imported)
+		assert.Equal(t, 1, result.Report.Rejected)
+		assert.Equal(t, "id,amount\n1,100\n", string(body))
+	})
+
+	t.Run("watches the summary", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+		_, err := c.CreateTransaction(ctx, client.CreateTransactionInput{UserID: 1, Amount: 100})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		done := errors.New("done")
+		var first *client.SummaryPage
+
+		// Execute
+		err = c.WatchSummary(ctx, client.SummaryOptions{}, func(page *client.SummaryPage) error {
+			first = page
+			return done
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, done)
+		require.NotNil(t, first)
+		assert.Equal(t, 1, first.Summary.CountTotal)
+	})
+
+	t.Run("answers the healthcheck", func(t *testing.T) {
+		// Setup
+		c := newClient(t)
+
+		// Execute
+		health, err := c.Healthcheck(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "available", health.Status)
+		assert.Equal(t, "test", health.SystemInfo.Environment)
+	})
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/openapi"
+	"github.com/ucok-man/tcsa/internal/seed"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+func serveCommand(args []string) error {
+	fs := newFlagSet("serve", "serve [options]")
+
+	loader, err := newConfigLoader(fs, args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+
+	app, err := newApplication(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.close()
+
+	app.loader = loader
+
+	// There is nothing to migrate in memory.
+	if cfg.AutoMigrate && !cfg.inMemory() {
+		err = autoMigrate(app.db, app.logger)
+		if err != nil {
+			app.logger.Errorj(tlog.JSON{"message": "failed applying database migrations", "error": err})
+			return err
+		}
+	}
+
+	err = app.serve()
+	if err != nil {
+		app.logger.Errorj(tlog.JSON{"message": "server has error occured", "error": err})
+		return err
+	}
+	return nil
+}
+
+func migrateCommand(args []string) error {
+	fs := newFlagSet("migrate", "migrate [options] up|down|status|version")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	app, err := newApplication(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.close()
+
+	if err := app.requireDatabase("migrate"); err != nil {
+		return err
+	}
+
+	return runMigrate(app.db, fs.Args(), os.Stdout)
+}
+
+func seedCommand(args []string) error {
+	fs := newFlagSet("seed", "seed [options]")
+	flags := newSeedFlags(fs)
+	clean := fs.Bool("clean", false, "Delete previously seeded transactions instead of inserting")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := flags.options(fs)
+	if err != nil {
+		return err
+	}
+
+	app, err := newApplication(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.close()
+
+	if err := app.requireDatabase("seed"); err != nil {
+		return err
+	}
+
+	if *clean {
+		deleted, err := app.models.Seeds.DeleteSeeded()
+		if err != nil {
+			return fmt.Errorf("failed to delete seeded transactions: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "deleted %d seeded transactions\n", deleted)
+		return nil
+	}
+
+	transactions, err := seed.Generate(opts)
+	if err != nil {
+		return fmt.Errorf("invalid seed options: %w", err)
+	}
+
+	err = app.models.Seeds.InsertSeeded(transactions)
+	if err != nil {
+		return fmt.Errorf("failed insert seeded transactions: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "inserted %d transactions\n", len(transactions))
+	return nil
+}
+
+// seedFlags are the generator options of the seed command.
+type seedFlags struct {
+	count        *int
+	users        *int
+	userMin      *int
+	seed         *uint64
+	now          *string
+	weights      *map[string]string
+	amountMin    *int
+	amountMax    *int
+	distribution *string
+	dateSpread   *time.Duration
+}
+
+func newSeedFlags(fs *pflag.FlagSet) *seedFlags {
+	defaults := seed.DefaultOptions()
+
+	return &seedFlags{
+		count:   fs.Int("count", defaults.Count, "Number of transactions to insert"),
+		users:   fs.Int("users", defaults.UserMax, "Number of distinct users, ids start at --user-min"),
+		userMin: fs.Int("user-min", defaults.UserMin, "Lowest generated user id"),
+		seed:    fs.Uint64("seed", defaults.Seed, "Random seed, equal seeds generate equal data"),
+		now:     fs.String("now", "", "Reference time of created_at (RFC 3339), defaults to the current time or a fixed time when --seed is set"),
+		weights: fs.StringToString("status-weights", map[string]string{
+			"pending": "0.2", "success": "0.7", "failed": "0.1",
+		}, "Relative weight of each status"),
+		amountMin:    fs.Int("amount-min", defaults.AmountMin, "Lowest generated amount"),
+		amountMax:    fs.Int("amount-max", defaults.AmountMax, "Highest generated amount"),
+		distribution: fs.String("amount-distribution", defaults.AmountDistribution, "Amount distribution (uniform/lognormal)"),
+		dateSpread:   fs.Duration("date-spread", defaults.DateSpread, "How far back created_at may go"),
+	}
+}
+
+// options returns the generator options of the parsed flags. An explicit
+// --seed without --now pins the reference time, so that the same command
+// inserts the same rows on every run.
+func (f *seedFlags) options(fs *pflag.FlagSet) (seed.Options, error) {
+	opts := seed.DefaultOptions()
+	opts.Seed = *f.seed
+	opts.Count = *f.count
+	opts.UserMin = *f.userMin
+	opts.UserMax = *f.userMin + *f.users - 1
+	opts.AmountMin = *f.amountMin
+	opts.AmountMax = *f.amountMax
+	opts.AmountDistribution = *f.distribution
+	opts.DateSpread = *f.dateSpread
+
+	switch {
+	case *f.now != "":
+		now, err := time.Parse(time.RFC3339, *f.now)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --now %q, expected an RFC 3339 time", *f.now)
+		}
+		opts.Now = now.UTC()
+	case fs.Changed("seed"):
+		opts.Now = seed.PinnedNow
+	}
+
+	var err error
+	opts.StatusWeights, err = parseStatusWeights(*f.weights)
+	return opts, err
+}
+
+func parseStatusWeights(values map[string]string) (seed.StatusWeights, error) {
+	var weights seed.StatusWeights
+
+	for status, value := range values {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return weights, fmt.Errorf("invalid weight %q for status %s", value, status)
+		}
+
+		switch data.TransactionStatus(status) {
+		case data.TransactionStatusPending:
+			weights.Pending = weight
+		case data.TransactionStatusSucces:
+			weights.Success = weight
+		case data.TransactionStatusFailed:
+			weights.Failed = weight
+		default:
+			return weights, fmt.Errorf("unknown status %q in --status-weights", status)
+		}
+	}
+
+	return weights, nil
+}
+
+func exportCommand(args []string) error {
+	fs := newFlagSet("export", "export [options]")
+	format := fs.String("format", "ndjson", "Output format (csv/ndjson)")
+	columns := fs.String("columns", "", "Comma separated columns to export, all when empty")
+	status := fs.String("status", "", "Only export transactions with this status (pending/failed/success)")
+	userId := fs.Int("user-id", 0, "Only export transactions of this user")
+	output := fs.StringP("output", "o", "-", "File to write to, - for stdout")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	selected, err := parseExportColumns(*columns)
+	if err != nil {
+		return err
+	}
+
+	app, err := newApplication(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.close()
+
+	if err := app.requireDatabase("export"); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	writer, err := newExportWriter(*format, selected, w)
+	if err != nil {
+		return err
+	}
+
+	param := data.TransactionExportParam{
+		SortColumn:    "id",
+		SortDirection: "ASC",
+		FilterStatus:  *status,
+		FilterUserId:  *userId,
+	}
+
+	err = app.models.Transactions.Export(context.Background(), param, writer.Write)
+	if err != nil {
+		return fmt.Errorf("failed export transactions: %w", err)
+	}
+	return writer.Flush()
+}
+
+func purgeCommand(args []string) error {
+	fs := newFlagSet("purge", "purge [options] --yes")
+	status := fs.String("status", "", "Only purge transactions with this status (pending/failed/success)")
+	userId := fs.Int("user-id", 0, "Only purge transactions of this user")
+	olderThan := fs.Duration("older-than", 0, "Only purge transactions created longer ago than this")
+	all := fs.Bool("all", false, "Allow purging without any filter")
+	yes := fs.Bool("yes", false, "Confirm the purge")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *status == "" && *userId == 0 && *olderThan == 0 && !*all {
+		return errors.New("refusing to purge every transaction without --all")
+	}
+	if !*yes {
+		return errors.New("refusing to purge without --yes")
+	}
+
+	app, err := newApplication(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.close()
+
+	if err := app.requireDatabase("purge"); err != nil {
+		return err
+	}
+
+	param := data.TransactionDeleteManyParam{
+		FilterStatus: *status,
+		FilterUserId: *userId,
+	}
+	if *olderThan > 0 {
+		param.FilterCreatedBefore = time.Now().Add(-*olderThan)
+	}
+
+	deleted, err := app.models.Transactions.DeleteMany(param)
+	if err != nil {
+		return fmt.Errorf("failed to delete transactions after deleting %d: %w", deleted, err)
+	}
+
+	fmt.Fprintf(os.Stdout, "deleted %d transactions\n", deleted)
+	return nil
+}
+
+func configCommand(args []string) error {
+	fs := newFlagSet("config", "config [options] print")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 || fs.Arg(0) != "print" {
+		return errors.New("usage: config print")
+	}
+
+	printConfig(os.Stdout, cfg)
+	return nil
+}
+
+func openapiCommand(args []string) error {
+	fs := newFlagSet("openapi", "openapi [options]")
+	output := fs.StringP("output", "o", "-", "File to write to, - for stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	spec, err := openapi.Marshal(newOpenAPIDocument())
+	if err != nil {
+		return fmt.Errorf("failed marshal openapi document: %w", err)
+	}
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(spec)
+		return err
+	}
+	return os.WriteFile(*output, spec, 0o644)
+}
+
+func healthcheckCommand(args []string) error {
+	fs := newFlagSet("healthcheck", "healthcheck [options]")
+	timeout := fs.Duration("timeout", 3*time.Second, "Time to wait for the server to respond")
+	clientCert := fs.String("client-cert", "", "Client certificate to present when the server requires mTLS")
+	clientKey := fs.String("client-key", "", "Key of --client-cert")
+
+	cfg, err := NewConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	scheme := "http"
+
+	if cfg.tlsEnabled() {
+		// The server certificate is issued for its public name, not for the
+		// loopback address the check runs against.
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if *clientCert != "" {
+			cert, err := tls.LoadX509KeyPair(*clientCert, *clientKey)
+			if err != nil {
+				return fmt.Errorf("load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		scheme = "https"
+	}
+
+	res, err := client.Get(fmt.Sprintf("%s://127.0.0.1:%d/healthcheck", scheme, cfg.Port))
+	if err != nil {
+		return fmt.Errorf("healthcheck failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthcheck failed: server responded with %s", res.Status)
+	}
+
+	fmt.Fprintln(os.Stdout, "available")
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/seed"
+)
+
+func parseSeedOptions(t *testing.T, args ...string) (seed.Options, error) {
+	t.Helper()
+
+	fs := newFlagSet("seed", "seed [options]")
+	flags := newSeedFlags(fs)
+	require.NoError(t, fs.Parse(args))
+
+	return flags.options(fs)
+}
+
+func TestSeedFlags(t *testing.T) {
+	t.Run("same seed generates same rows on every run", func(t *testing.T) {
+		// Setup
+		first, err := parseSeedOptions(t, "--seed", "42", "--count", "20")
+		require.NoError(t, err)
+
+		second, err := parseSeedOptions(t, "--seed", "42", "--count", "20")
+		require.NoError(t, err)
+
+		// Execute
+		firstRows, err := seed.Generate(first)
+		require.NoError(t, err)
+
+		secondRows, err := seed.Generate(second)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, seed.PinnedNow, first.Now)
+		assert.Len(t, firstRows, 20)
+		assert.Equal(t, firstRows, secondRows)
+	})
+
+	t.Run("uses the given now", func(t *testing.T) {
+		// Execute
+		opts, err := parseSeedOptions(t, "--seed", "42", "--now", "2025-12-14T15:00:00+07:00")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 12, 14, 8, 0, 0, 0, time.UTC), opts.Now)
+	})
+
+	t.Run("uses the current time without a seed", func(t *testing.T) {
+		// Execute
+		opts, err := parseSeedOptions(t, "--count", "10")
+
+		// Assert
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), opts.Now, time.Minute)
+	})
+
+	t.Run("rejects an invalid now", func(t *testing.T) {
+		// Execute
+		_, err := parseSeedOptions(t, "--now", "yesterday")
+
+		// Assert
+		assert.ErrorContains(t, err, "invalid --now")
+	})
+
+	t.Run("rejects an unknown status weight", func(t *testing.T) {
+		// Execute
+		_, err := parseSeedOptions(t, "--status-weights", "refunded=1")
+
+		// Assert
+		assert.ErrorContains(t, err, "unknown status")
+	})
+}
+
+func TestHealthcheckCommand(t *testing.T) {
+	// portOf returns the --port argument that points the healthcheck at srv.
+	portOf := func(t *testing.T, srv *httptest.Server) string {
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+		return u.Port()
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		closed   bool
+		wantCode int
+	}{
+		{name: "healthy server", status: http.StatusOK, wantCode: 0},
+		{name: "unhealthy server", status: http.StatusServiceUnavailable, wantCode: 1},
+		{name: "server error", status: http.StatusInternalServerError, wantCode: 1},
+		{name: "unreachable server", closed: true, wantCode: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Setenv("TCSA_STORAGE", "memory")
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/healthcheck" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			port := portOf(t, srv)
+			if tt.closed {
+				srv.Close()
+			} else {
+				defer srv.Close()
+			}
+
+			// Execute
+			err := run([]string{"healthcheck", "--port", port, "--timeout", "1s"})
+
+			// Assert
+			assert.Equal(t, tt.wantCode, exitCode(err), err)
+		})
+	}
+}
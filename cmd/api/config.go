@@ -1,8 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -22,78 +28,415 @@ func init() {
 }
 
 type Config struct {
-	Port     uint   `mapstructure:"PORT" validate:"required,port"`
-	Env      string `mapstructure:"ENV" validate:"required,oneof=development staging production"`
+	Port        uint   `mapstructure:"PORT" validate:"required,port"`
+	Env         string `mapstructure:"ENV" validate:"required,oneof=development staging production"`
+	AutoMigrate bool   `mapstructure:"AUTO_MIGRATE"`
+	Server      struct {
+		ReadTimeout     time.Duration `mapstructure:"SERVER_READ_TIMEOUT" validate:"required,min=1s"`
+		WriteTimeout    time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT" validate:"required,min=1s"`
+		IdleTimeout     time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT" validate:"required,min=1s"`
+		ShutdownTimeout time.Duration `mapstructure:"SERVER_SHUTDOWN_TIMEOUT" validate:"required,min=1s"`
+	} `mapstructure:",squash"`
+	TLS struct {
+		CertFile     string `mapstructure:"TLS_CERT_FILE" validate:"required_with=KeyFile,required_unless=ClientAuth none,omitempty,file"`
+		KeyFile      string `mapstructure:"TLS_KEY_FILE" validate:"required_with=CertFile,omitempty,file"`
+		MinVersion   string `mapstructure:"TLS_MIN_VERSION" validate:"required,oneof=1.2 1.3"`
+		ClientCAFile string `mapstructure:"TLS_CLIENT_CA_FILE" validate:"required_unless=ClientAuth none,omitempty,file"`
+		ClientAuth   string `mapstructure:"TLS_CLIENT_AUTH" validate:"required,oneof=none optional require"`
+		H2C          bool   `mapstructure:"H2C_ENABLED"`
+	} `mapstructure:",squash"`
 	Database struct {
-		Dsn         string        `mapstructure:"DB_DSN" validate:"required,url"`
-		MaxOpenConn int           `mapstructure:"DB_MAX_OPEN_CONN" validate:"required,min=1,max=100"`
-		MaxIdleConn int           `mapstructure:"DB_MAX_IDLE_CONN" validate:"required,min=1,max=100"`
-		MaxIdleTime time.Duration `mapstructure:"DB_MAX_IDLE_TIME" validate:"required,min=1s"`
+		Storage        string        `mapstructure:"STORAGE" validate:"required,oneof=postgres memory"`
+		Dsn            string        `mapstructure:"DB_DSN" validate:"required_if=Storage postgres,omitempty,url" secret:"true"`
+		MaxOpenConn    int           `mapstructure:"DB_MAX_OPEN_CONN" validate:"required,min=1,max=100"`
+		MaxIdleConn    int           `mapstructure:"DB_MAX_IDLE_CONN" validate:"required,min=1,max=100"`
+		MaxIdleTime    time.Duration `mapstructure:"DB_MAX_IDLE_TIME" validate:"required,min=1s"`
+		ConnectTimeout time.Duration `mapstructure:"DB_CONNECT_TIMEOUT" validate:"required,min=1s"`
+		QueryTimeout   time.Duration `mapstructure:"DB_QUERY_TIMEOUT" validate:"required,min=100ms"`
 	} `mapstructure:",squash"`
 	Log struct {
-		Level string `mapstructure:"LOG_LEVEL" validate:"required,oneof=debug info warn error"`
+		Level string `mapstructure:"LOG_LEVEL" validate:"required,oneof=debug info warn error" reload:"true"`
 	} `mapstructure:",squash"`
 	Cors struct {
-		TrustedOrigins []string `mapstructure:"CORS_TRUSTED_ORIGINS" validate:"omitempty,dive,url"`
+		TrustedOrigins []string `mapstructure:"CORS_TRUSTED_ORIGINS" validate:"omitempty,dive,url" reload:"true"`
+	} `mapstructure:",squash"`
+	RateLimit struct {
+		Enabled bool    `mapstructure:"RATE_LIMIT_ENABLED" reload:"true"`
+		Rps     float64 `mapstructure:"RATE_LIMIT_RPS" validate:"gt=0" reload:"true"`
+		Burst   int     `mapstructure:"RATE_LIMIT_BURST" validate:"min=1" reload:"true"`
+	} `mapstructure:",squash"`
+	Features struct {
+		Flags []string `mapstructure:"FEATURE_FLAGS" validate:"omitempty,dive,required" reload:"true"`
+	} `mapstructure:",squash"`
+	Webhook struct {
+		Enabled      bool          `mapstructure:"WEBHOOK_ENABLED"`
+		PollInterval time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL" validate:"required,min=100ms"`
+		Timeout      time.Duration `mapstructure:"WEBHOOK_TIMEOUT" validate:"required,min=1s"`
+		MaxAttempts  int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS" validate:"required,min=1,max=50"`
+		BackoffBase  time.Duration `mapstructure:"WEBHOOK_BACKOFF_BASE" validate:"required,min=1s"`
+		BackoffMax   time.Duration `mapstructure:"WEBHOOK_BACKOFF_MAX" validate:"required,gtefield=BackoffBase"`
+	} `mapstructure:",squash"`
+	Outbox struct {
+		Enabled      bool          `mapstructure:"OUTBOX_ENABLED"`
+		Publishers   []string      `mapstructure:"OUTBOX_PUBLISHERS" validate:"unique,dive,oneof=stdout webhook bus"`
+		PollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL" validate:"required,min=10ms"`
+		BatchSize    int           `mapstructure:"OUTBOX_BATCH_SIZE" validate:"required,min=1,max=1000"`
+		Retention    time.Duration `mapstructure:"OUTBOX_RETENTION" validate:"min=0"`
+	} `mapstructure:",squash"`
+	Batch struct {
+		MaxItems     int   `mapstructure:"BATCH_MAX_ITEMS" validate:"required,min=1,max=10000"`
+		MaxBodyBytes int64 `mapstructure:"BATCH_MAX_BODY_BYTES" validate:"required,min=1048576"`
+	} `mapstructure:",squash"`
+	Stream struct {
+		HeartbeatInterval time.Duration `mapstructure:"STREAM_HEARTBEAT_INTERVAL" validate:"required,min=1s"`
+		BufferSize        int           `mapstructure:"STREAM_BUFFER_SIZE" validate:"required,min=1,max=10000"`
+		SummaryDebounce   time.Duration `mapstructure:"STREAM_SUMMARY_DEBOUNCE" validate:"required,min=10ms"`
+	} `mapstructure:",squash"`
+	Import struct {
+		MaxFileBytes        int64 `mapstructure:"IMPORT_MAX_FILE_BYTES" validate:"required,min=1024"`
+		AsyncThresholdBytes int64 `mapstructure:"IMPORT_ASYNC_THRESHOLD_BYTES" validate:"min=0"`
+	} `mapstructure:",squash"`
+	Jobs struct {
+		Enabled      bool          `mapstructure:"JOB_ENABLED"`
+		Workers      int           `mapstructure:"JOB_WORKERS" validate:"required,min=1,max=100"`
+		PollInterval time.Duration `mapstructure:"JOB_POLL_INTERVAL" validate:"required,min=100ms"`
+		Lease        time.Duration `mapstructure:"JOB_LEASE" validate:"required,min=10s"`
+		MaxAttempts  int           `mapstructure:"JOB_MAX_ATTEMPTS" validate:"required,min=1,max=50"`
+		BackoffBase  time.Duration `mapstructure:"JOB_BACKOFF_BASE" validate:"required,min=1s"`
+		BackoffMax   time.Duration `mapstructure:"JOB_BACKOFF_MAX" validate:"required,gtefield=BackoffBase"`
+		Retention    time.Duration `mapstructure:"JOB_RETENTION" validate:"min=0"`
+	} `mapstructure:",squash"`
+	Docs struct {
+		Enabled bool `mapstructure:"DOCS_ENABLED"`
+	} `mapstructure:",squash"`
+	OpenAPI struct {
+		ValidateRequests  bool `mapstructure:"OPENAPI_VALIDATE_REQUESTS"`
+		ValidateResponses bool `mapstructure:"OPENAPI_VALIDATE_RESPONSES"`
+	} `mapstructure:",squash"`
+	Errors struct {
+		ProblemJSON bool `mapstructure:"ERROR_PROBLEM_JSON"`
 	} `mapstructure:",squash"`
 }
 
-func NewConfig() (Config, error) {
-	// Bind environment variables
-	viper.SetEnvPrefix("TCSA")
-	viper.AutomaticEnv()
-
-	pflag.Uint("port", 3000, "API server port")
-	pflag.String("env", "development", "Environment (development/staging/production)")
-	pflag.String("db-dsn", "", "Database connection string")
-	pflag.Int("db-max-open-conn", 25, "Database max open connections")
-	pflag.Int("db-max-idle-conn", 25, "Database max idle connections")
-	pflag.Duration("db-max-idle-time", 15*time.Minute, "Database max idle time")
-	pflag.String("log-level", "debug", "Log level (debug/info/warn/error)")
-	pflag.StringSlice("cors-trusted-origins", []string{}, "Trusted CORS origins (comma separated)")
-
-	pflag.Usage = func() {
-		w := pflag.CommandLine.Output()
-
-		fmt.Fprintf(w, "Usage of %s:\n\n", os.Args[0])
-
-		// Use PrintDefaults() to print the standard flag descriptions
-		fmt.Fprintln(w, "Options:")
-		pflag.PrintDefaults()
-
-		// Print any custom postamble
-		fmt.Fprintf(w, "\nEnvironment Variables:\n")
-		fmt.Fprintln(w, "      TCSA_PORT")
-		fmt.Fprintln(w, "      TCSA_ENV")
-		fmt.Fprintln(w, "      TCSA_DB_DSN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_OPEN_CONN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_CONN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_TIME")
-		fmt.Fprintln(w, "      TCSA_LOG_LEVEL")
-		fmt.Fprintln(w, "      TCSA_CORS_TRUSTED_ORIGINS")
+// inMemory reports whether the transactions are kept in memory rather than in
+// Postgres.
+func (cfg Config) inMemory() bool {
+	return cfg.Database.Storage == "memory"
+}
+
+// NewConfig registers the shared configuration flags on fs, parses args and
+// loads the configuration. Command specific flags must be registered on fs
+// before calling it.
+//
+// Every key can come from several sources. From highest to lowest precedence:
+//
+//  1. command line flags, e.g. --db-dsn
+//  2. environment variables, e.g. TCSA_DB_DSN, including .env files
+//  3. the config file given by --config or TCSA_CONFIG, e.g. db_dsn
+//  4. the flag defaults
+func NewConfig(fs *pflag.FlagSet, args []string) (Config, error) {
+	loader, err := newConfigLoader(fs, args)
+	if err != nil {
+		return Config{}, err
 	}
+	return loader.Load()
+}
+
+// configLoader keeps the sources of a configuration around, so it can be
+// loaded again when the config file changes.
+type configLoader struct {
+	v    *viper.Viper
+	file string
+}
+
+func newConfigLoader(fs *pflag.FlagSet, args []string) (*configLoader, error) {
+	// Bind environment variables
+	v := viper.New()
+	v.SetEnvPrefix("TCSA")
+	v.AutomaticEnv()
 
-	pflag.Parse()
+	fs.StringP("config", "c", "", "Config file (yaml/toml/json)")
+	fs.Uint("port", 3000, "API server port")
+	fs.String("env", "development", "Environment (development/staging/production)")
+	fs.Bool("auto-migrate", false, "Apply pending database migrations before starting the server")
+	fs.Duration("server-read-timeout", 5*time.Second, "Server read timeout")
+	fs.Duration("server-write-timeout", 10*time.Second, "Server write timeout")
+	fs.Duration("server-idle-timeout", time.Minute, "Server keep-alive idle timeout")
+	fs.Duration("server-shutdown-timeout", 30*time.Second, "Time to wait for in-flight requests on shutdown")
+	fs.String("tls-cert-file", "", "TLS certificate file, enables HTTPS together with --tls-key-file")
+	fs.String("tls-key-file", "", "TLS private key file")
+	fs.String("tls-min-version", "1.2", "Minimum TLS version (1.2/1.3)")
+	fs.String("tls-client-ca-file", "", "CA bundle used to verify client certificates")
+	fs.String("tls-client-auth", "none", "Client certificate policy (none/optional/require)")
+	fs.Bool("h2c-enabled", false, "Accept HTTP/2 without TLS (prior knowledge) on the plain HTTP listener")
+	fs.String("storage", "postgres", "Where transactions are stored (postgres/memory)")
+	fs.String("db-dsn", "", "Database connection string")
+	fs.Int("db-max-open-conn", 25, "Database max open connections")
+	fs.Int("db-max-idle-conn", 25, "Database max idle connections")
+	fs.Duration("db-max-idle-time", 15*time.Minute, "Database max idle time")
+	fs.Duration("db-connect-timeout", 5*time.Second, "Database connect timeout")
+	fs.Duration("db-query-timeout", 3*time.Second, "Database query timeout")
+	fs.String("log-level", "debug", "Log level (debug/info/warn/error)")
+	fs.StringSlice("cors-trusted-origins", []string{}, "Trusted CORS origins (comma separated)")
+	fs.Bool("rate-limit-enabled", false, "Enable per client IP rate limiting")
+	fs.Float64("rate-limit-rps", 10, "Rate limiter requests per second")
+	fs.Int("rate-limit-burst", 20, "Rate limiter burst size")
+	fs.StringSlice("feature-flags", []string{}, "Enabled feature flags (comma separated)")
+	fs.Bool("webhook-enabled", true, "Deliver webhooks from this instance")
+	fs.Duration("webhook-poll-interval", time.Second, "How often due webhook deliveries are looked up")
+	fs.Duration("webhook-timeout", 10*time.Second, "Timeout of a single webhook request")
+	fs.Int("webhook-max-attempts", 8, "Attempts before a webhook delivery is dead-lettered")
+	fs.Duration("webhook-backoff-base", 30*time.Second, "Wait after the first failed webhook attempt, doubled per attempt")
+	fs.Duration("webhook-backoff-max", 6*time.Hour, "Longest wait between webhook attempts")
+	fs.Bool("outbox-enabled", true, "Relay outbox events from this instance")
+	fs.StringSlice("outbox-publishers", []string{"webhook", "bus"}, "Where outbox events are published: stdout, webhook, bus (comma separated)")
+	fs.Duration("outbox-poll-interval", 500*time.Millisecond, "How often unpublished outbox events are looked up")
+	fs.Int("outbox-batch-size", 100, "Maximum number of outbox events relayed at once")
+	fs.Duration("outbox-retention", 7*24*time.Hour, "How long published outbox events are kept, 0 keeps them forever")
+	fs.Int("batch-max-items", 1000, "Maximum number of items in a batch request")
+	fs.Int64("batch-max-body-bytes", 16<<20, "Maximum body size of a batch request in bytes")
+	fs.Duration("stream-heartbeat-interval", 15*time.Second, "How often idle event streams get a heartbeat")
+	fs.Int("stream-buffer-size", 64, "Events buffered per stream client before it is disconnected")
+	fs.Duration("stream-summary-debounce", time.Second, "Shortest interval between live dashboard summary updates")
+	fs.Int64("import-max-file-bytes", 64<<20, "Maximum size of an uploaded import file in bytes")
+	fs.Int64("import-async-threshold-bytes", 1<<20, "Import files larger than this run as background jobs")
+	fs.Bool("job-enabled", true, "Run background jobs on this instance")
+	fs.Int("job-workers", 4, "Number of background jobs run at once by this instance")
+	fs.Duration("job-poll-interval", time.Second, "How often due background jobs are looked up")
+	fs.Duration("job-lease", time.Minute, "How long a job of a stopped instance waits before it is run elsewhere")
+	fs.Int("job-max-attempts", 5, "Attempts before a background job fails")
+	fs.Duration("job-backoff-base", 10*time.Second, "Wait after the first failed job attempt, doubled per attempt")
+	fs.Duration("job-backoff-max", 10*time.Minute, "Longest wait between job attempts")
+	fs.Duration("job-retention", 7*24*time.Hour, "How long finished jobs can be looked up, 0 keeps them forever")
+	fs.Bool("docs-enabled", true, "Serve the API documentation at /docs and /swagger.yaml")
+	fs.Bool("openapi-validate-requests", true, "Reject requests that don't match the OpenAPI document with 422")
+	fs.Bool("openapi-validate-responses", false, "Answer 500 for responses that don't match the OpenAPI document, always on in development")
+	fs.Bool("error-problem-json", false, "Answer every error as application/problem+json, not only to clients asking for it")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
 
 	// Bind flags to Viper keys, flags override environment
-	viper.BindPFlag("PORT", pflag.Lookup("port"))
-	viper.BindPFlag("ENV", pflag.Lookup("env"))
-	viper.BindPFlag("DB_DSN", pflag.Lookup("db-dsn"))
-	viper.BindPFlag("DB_MAX_OPEN_CONN", pflag.Lookup("db-max-open-conn"))
-	viper.BindPFlag("DB_MAX_IDLE_CONN", pflag.Lookup("db-max-idle-conn"))
-	viper.BindPFlag("DB_MAX_IDLE_TIME", pflag.Lookup("db-max-idle-time"))
-	viper.BindPFlag("LOG_LEVEL", pflag.Lookup("log-level"))
-	viper.BindPFlag("CORS_TRUSTED_ORIGINS", pflag.Lookup("cors-trusted-origins"))
+	v.BindPFlag("PORT", fs.Lookup("port"))
+	v.BindPFlag("ENV", fs.Lookup("env"))
+	v.BindPFlag("AUTO_MIGRATE", fs.Lookup("auto-migrate"))
+	v.BindPFlag("SERVER_READ_TIMEOUT", fs.Lookup("server-read-timeout"))
+	v.BindPFlag("SERVER_WRITE_TIMEOUT", fs.Lookup("server-write-timeout"))
+	v.BindPFlag("SERVER_IDLE_TIMEOUT", fs.Lookup("server-idle-timeout"))
+	v.BindPFlag("SERVER_SHUTDOWN_TIMEOUT", fs.Lookup("server-shutdown-timeout"))
+	v.BindPFlag("TLS_CERT_FILE", fs.Lookup("tls-cert-file"))
+	v.BindPFlag("TLS_KEY_FILE", fs.Lookup("tls-key-file"))
+	v.BindPFlag("TLS_MIN_VERSION", fs.Lookup("tls-min-version"))
+	v.BindPFlag("TLS_CLIENT_CA_FILE", fs.Lookup("tls-client-ca-file"))
+	v.BindPFlag("TLS_CLIENT_AUTH", fs.Lookup("tls-client-auth"))
+	v.BindPFlag("H2C_ENABLED", fs.Lookup("h2c-enabled"))
+	v.BindPFlag("STORAGE", fs.Lookup("storage"))
+	v.BindPFlag("DB_DSN", fs.Lookup("db-dsn"))
+	v.BindPFlag("DB_MAX_OPEN_CONN", fs.Lookup("db-max-open-conn"))
+	v.BindPFlag("DB_MAX_IDLE_CONN", fs.Lookup("db-max-idle-conn"))
+	v.BindPFlag("DB_MAX_IDLE_TIME", fs.Lookup("db-max-idle-time"))
+	v.BindPFlag("DB_CONNECT_TIMEOUT", fs.Lookup("db-connect-timeout"))
+	v.BindPFlag("DB_QUERY_TIMEOUT", fs.Lookup("db-query-timeout"))
+	v.BindPFlag("LOG_LEVEL", fs.Lookup("log-level"))
+	v.BindPFlag("CORS_TRUSTED_ORIGINS", fs.Lookup("cors-trusted-origins"))
+	v.BindPFlag("RATE_LIMIT_ENABLED", fs.Lookup("rate-limit-enabled"))
+	v.BindPFlag("RATE_LIMIT_RPS", fs.Lookup("rate-limit-rps"))
+	v.BindPFlag("RATE_LIMIT_BURST", fs.Lookup("rate-limit-burst"))
+	v.BindPFlag("FEATURE_FLAGS", fs.Lookup("feature-flags"))
+	v.BindPFlag("WEBHOOK_ENABLED", fs.Lookup("webhook-enabled"))
+	v.BindPFlag("WEBHOOK_POLL_INTERVAL", fs.Lookup("webhook-poll-interval"))
+	v.BindPFlag("WEBHOOK_TIMEOUT", fs.Lookup("webhook-timeout"))
+	v.BindPFlag("WEBHOOK_MAX_ATTEMPTS", fs.Lookup("webhook-max-attempts"))
+	v.BindPFlag("WEBHOOK_BACKOFF_BASE", fs.Lookup("webhook-backoff-base"))
+	v.BindPFlag("WEBHOOK_BACKOFF_MAX", fs.Lookup("webhook-backoff-max"))
+	v.BindPFlag("OUTBOX_ENABLED", fs.Lookup("outbox-enabled"))
+	v.BindPFlag("OUTBOX_PUBLISHERS", fs.Lookup("outbox-publishers"))
+	v.BindPFlag("OUTBOX_POLL_INTERVAL", fs.Lookup("outbox-poll-interval"))
+	v.BindPFlag("OUTBOX_BATCH_SIZE", fs.Lookup("outbox-batch-size"))
+	v.BindPFlag("OUTBOX_RETENTION", fs.Lookup("outbox-retention"))
+	v.BindPFlag("BATCH_MAX_ITEMS", fs.Lookup("batch-max-items"))
+	v.BindPFlag("BATCH_MAX_BODY_BYTES", fs.Lookup("batch-max-body-bytes"))
+	v.BindPFlag("STREAM_HEARTBEAT_INTERVAL", fs.Lookup("stream-heartbeat-interval"))
+	v.BindPFlag("STREAM_BUFFER_SIZE", fs.Lookup("stream-buffer-size"))
+	v.BindPFlag("STREAM_SUMMARY_DEBOUNCE", fs.Lookup("stream-summary-debounce"))
+	v.BindPFlag("IMPORT_MAX_FILE_BYTES", fs.Lookup("import-max-file-bytes"))
+	v.BindPFlag("IMPORT_ASYNC_THRESHOLD_BYTES", fs.Lookup("import-async-threshold-bytes"))
+	v.BindPFlag("JOB_ENABLED", fs.Lookup("job-enabled"))
+	v.BindPFlag("JOB_WORKERS", fs.Lookup("job-workers"))
+	v.BindPFlag("JOB_POLL_INTERVAL", fs.Lookup("job-poll-interval"))
+	v.BindPFlag("JOB_LEASE", fs.Lookup("job-lease"))
+	v.BindPFlag("JOB_MAX_ATTEMPTS", fs.Lookup("job-max-attempts"))
+	v.BindPFlag("JOB_BACKOFF_BASE", fs.Lookup("job-backoff-base"))
+	v.BindPFlag("JOB_BACKOFF_MAX", fs.Lookup("job-backoff-max"))
+	v.BindPFlag("JOB_RETENTION", fs.Lookup("job-retention"))
+	v.BindPFlag("DOCS_ENABLED", fs.Lookup("docs-enabled"))
+	v.BindPFlag("OPENAPI_VALIDATE_REQUESTS", fs.Lookup("openapi-validate-requests"))
+	v.BindPFlag("OPENAPI_VALIDATE_RESPONSES", fs.Lookup("openapi-validate-responses"))
+	v.BindPFlag("ERROR_PROBLEM_JSON", fs.Lookup("error-problem-json"))
 
+	configFile, _ := fs.GetString("config")
+	if configFile == "" {
+		configFile = os.Getenv("TCSA_CONFIG")
+	}
+
+	return &configLoader{v: v, file: configFile}, nil
+}
+
+// Load reads the config file, if any, and decodes the configuration.
+func (l *configLoader) Load() (Config, error) {
+	if l.file != "" {
+		l.v.SetConfigFile(l.file)
+		if err := l.v.ReadInConfig(); err != nil {
+			return Config{}, fmt.Errorf("unable to read config file: %w", err)
+		}
+	}
+
+	return decodeConfig(l.v)
+}
+
+// decodeConfig unmarshals and validates the configuration held by v.
+func decodeConfig(v *viper.Viper) (Config, error) {
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, fmt.Errorf("unable to decode config: %w", err)
 	}
 
 	validate := validator.New()
+	validate.UseTagNames("mapstructure")
+
 	if err := validate.Struct(&cfg); err != nil {
-		return Config{}, fmt.Errorf("config validation failed: %w", err)
+		var errmap validator.ValidationErrorMap
+		if !errors.As(err, &errmap) {
+			return Config{}, fmt.Errorf("config validation failed: %w", err)
+		}
+
+		// List every offending key as the environment variable a user
+		// would set to fix it.
+		keys := make([]string, 0, len(errmap))
+		for namespace := range errmap {
+			keys = append(keys, namespace)
+		}
+		sort.Strings(keys)
+
+		var builder strings.Builder
+		builder.WriteString("config validation failed:")
+		for _, namespace := range keys {
+			key := namespace[strings.LastIndex(namespace, ".")+1:]
+			fmt.Fprintf(&builder, "\n  TCSA_%s: %s", key, errmap[namespace])
+		}
+		return Config{}, errors.New(builder.String())
 	}
 
 	return cfg, nil
 }
+
+// printConfigEnv prints the environment variables read by NewConfig, used as
+// the postamble of every command's usage.
+func printConfigEnv(w io.Writer) {
+	fmt.Fprintf(w, "\nEnvironment Variables:\n")
+	fmt.Fprintln(w, "      TCSA_PORT")
+	fmt.Fprintln(w, "      TCSA_ENV")
+	fmt.Fprintln(w, "      TCSA_CONFIG")
+	fmt.Fprintln(w, "      TCSA_AUTO_MIGRATE")
+	fmt.Fprintln(w, "      TCSA_SERVER_READ_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_SERVER_WRITE_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_SERVER_IDLE_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_SERVER_SHUTDOWN_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_TLS_CERT_FILE")
+	fmt.Fprintln(w, "      TCSA_TLS_KEY_FILE")
+	fmt.Fprintln(w, "      TCSA_TLS_MIN_VERSION")
+	fmt.Fprintln(w, "      TCSA_TLS_CLIENT_CA_FILE")
+	fmt.Fprintln(w, "      TCSA_TLS_CLIENT_AUTH")
+	fmt.Fprintln(w, "      TCSA_H2C_ENABLED")
+	fmt.Fprintln(w, "      TCSA_STORAGE")
+	fmt.Fprintln(w, "      TCSA_DB_DSN")
+	fmt.Fprintln(w, "      TCSA_DB_MAX_OPEN_CONN")
+	fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_CONN")
+	fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_TIME")
+	fmt.Fprintln(w, "      TCSA_DB_CONNECT_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_DB_QUERY_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_LOG_LEVEL")
+	fmt.Fprintln(w, "      TCSA_CORS_TRUSTED_ORIGINS")
+	fmt.Fprintln(w, "      TCSA_RATE_LIMIT_ENABLED")
+	fmt.Fprintln(w, "      TCSA_RATE_LIMIT_RPS")
+	fmt.Fprintln(w, "      TCSA_RATE_LIMIT_BURST")
+	fmt.Fprintln(w, "      TCSA_FEATURE_FLAGS")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_ENABLED")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_POLL_INTERVAL")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_TIMEOUT")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_MAX_ATTEMPTS")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_BACKOFF_BASE")
+	fmt.Fprintln(w, "      TCSA_WEBHOOK_BACKOFF_MAX")
+	fmt.Fprintln(w, "      TCSA_OUTBOX_ENABLED")
+	fmt.Fprintln(w, "      TCSA_OUTBOX_PUBLISHERS")
+	fmt.Fprintln(w, "      TCSA_OUTBOX_POLL_INTERVAL")
+	fmt.Fprintln(w, "      TCSA_OUTBOX_BATCH_SIZE")
+	fmt.Fprintln(w, "      TCSA_OUTBOX_RETENTION")
+	fmt.Fprintln(w, "      TCSA_BATCH_MAX_ITEMS")
+	fmt.Fprintln(w, "      TCSA_BATCH_MAX_BODY_BYTES")
+	fmt.Fprintln(w, "      TCSA_STREAM_HEARTBEAT_INTERVAL")
+	fmt.Fprintln(w, "      TCSA_STREAM_BUFFER_SIZE")
+	fmt.Fprintln(w, "      TCSA_STREAM_SUMMARY_DEBOUNCE")
+	fmt.Fprintln(w, "      TCSA_IMPORT_MAX_FILE_BYTES")
+	fmt.Fprintln(w, "      TCSA_IMPORT_ASYNC_THRESHOLD_BYTES")
+	fmt.Fprintln(w, "      TCSA_JOB_ENABLED")
+	fmt.Fprintln(w, "      TCSA_JOB_WORKERS")
+	fmt.Fprintln(w, "      TCSA_JOB_POLL_INTERVAL")
+	fmt.Fprintln(w, "      TCSA_JOB_LEASE")
+	fmt.Fprintln(w, "      TCSA_JOB_MAX_ATTEMPTS")
+	fmt.Fprintln(w, "      TCSA_JOB_BACKOFF_BASE")
+	fmt.Fprintln(w, "      TCSA_JOB_BACKOFF_MAX")
+	fmt.Fprintln(w, "      TCSA_JOB_RETENTION")
+	fmt.Fprintln(w, "      TCSA_DOCS_ENABLED")
+	fmt.Fprintln(w, "      TCSA_OPENAPI_VALIDATE_REQUESTS")
+	fmt.Fprintln(w, "      TCSA_OPENAPI_VALIDATE_RESPONSES")
+	fmt.Fprintln(w, "      TCSA_ERROR_PROBLEM_JSON")
+}
+
+// printConfig writes cfg as TCSA_* environment assignments, redacting every
+// field tagged `secret:"true"`.
+func printConfig(w io.Writer, cfg Config) {
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			key := field.Tag.Get("mapstructure")
+
+			if field.Type.Kind() == reflect.Struct && key == ",squash" {
+				walk(v.Field(i))
+				continue
+			}
+
+			value := fmt.Sprintf("%v", v.Field(i).Interface())
+			if values, ok := v.Field(i).Interface().([]string); ok {
+				value = strings.Join(values, ",")
+			}
+			if field.Tag.Get("secret") == "true" {
+				value = redactSecret(value)
+			}
+
+			fmt.Fprintf(w, "TCSA_%s=%q\n", key, value)
+		}
+	}
+	walk(reflect.ValueOf(cfg))
+}
+
+// redactSecret masks a secret value. URLs keep everything but their password
+// so a redacted DSN is still useful for debugging.
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", "xxxxx")
+			u.RawQuery = query.Encode()
+		}
+		return u.Redacted()
+	}
+	return "xxxxx"
+}
@@ -0,0 +1,5 @@
+package dto
+
+type JobParamIdDTO struct {
+	JobId int `param:"id" validate:"required,min=1"`
+}
@@ -5,6 +5,13 @@ type TransactionCreateDTO struct {
 	Amount int `json:"amount" validate:"required,min=1"`
 }
 
+// TransactionBatchCreateDTO items are validated one by one, with the rules of
+// TransactionCreateDTO, so errors can be reported per item.
+type TransactionBatchCreateDTO struct {
+	Mode  *string                `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []TransactionCreateDTO `json:"items" validate:"required,min=1"`
+}
+
 type TransactionUpdateDTO struct {
 	TransactionId int     `param:"id" validate:"required,min=1"`
 	Amount        *int    `json:"amount" validate:"omitempty,min=1"`
@@ -25,6 +32,13 @@ type TransactionGetAllDTO struct {
 	}
 }
 
+type TransactionStreamDTO struct {
+	Filter struct {
+		Status *string `query:"status" validate:"omitempty,oneof=pending failed success"`
+		UserId *int    `query:"user_id" validate:"omitempty,min=1"`
+	}
+}
+
 type TransactionParamIdDTO struct {
 	TransactionId int `param:"id" validate:"required,min=1"`
 }
@@ -42,3 +56,43 @@ type TransactionSummaryDTO struct {
 		UserId    *int `query:"user_id" validate:"omitempty,min=1"`
 	}
 }
+
+type TransactionBulkItemDTO struct {
+	ID      int  `json:"id" validate:"required,min=1"`
+	Version *int `json:"version" validate:"omitempty,min=1"`
+}
+
+// TransactionBulkFilterDTO has the filters of TransactionGetAllDTO. One of
+// them is required, so a bulk operation can't touch every transaction by
+// mistake.
+type TransactionBulkFilterDTO struct {
+	Status *string `json:"status" validate:"required_without=UserId,omitempty,oneof=pending failed success"`
+	UserId *int    `json:"user_id" validate:"omitempty,min=1"`
+}
+
+// TransactionBulkUpdateDTO selects transactions either by Items or by Filter.
+type TransactionBulkUpdateDTO struct {
+	Items  []TransactionBulkItemDTO  `json:"items" validate:"required_without=Filter,excluded_with=Filter,omitempty,min=1,unique=ID,dive"`
+	Filter *TransactionBulkFilterDTO `json:"filter" validate:"required_without=Items"`
+	Status string                    `json:"status" validate:"required,oneof=pending failed success"`
+	DryRun bool                      `json:"dry_run"`
+}
+
+// TransactionBulkDeleteDTO selects transactions either by Items or by Filter.
+type TransactionBulkDeleteDTO struct {
+	Items  []TransactionBulkItemDTO  `json:"items" validate:"required_without=Filter,excluded_with=Filter,omitempty,min=1,unique=ID,dive"`
+	Filter *TransactionBulkFilterDTO `json:"filter" validate:"required_without=Items"`
+	DryRun bool                      `json:"dry_run"`
+}
+
+type TransactionExportDTO struct {
+	Format  *string `query:"format" validate:"omitempty,oneof=csv ndjson"`
+	Columns *string `query:"columns"`
+	Sort    struct {
+		Value *string `query:"sort_by" validate:"omitempty,oneof=id user_id amount status created_at -id -user_id -amount -status -created_at"`
+	}
+	Filter struct {
+		Status *string `query:"status" validate:"omitempty,oneof=pending failed success"`
+		UserId *int    `query:"user_id" validate:"omitempty,min=1"`
+	}
+}
@@ -0,0 +1,29 @@
+package dto
+
+type WebhookCreateDTO struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,unique,dive,oneof=transaction.created transaction.updated transaction.status_changed transaction.deleted"`
+	Secret     *string  `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+type WebhookGetAllDTO struct {
+	Pagination struct {
+		Page     *int `query:"page" validate:"omitempty,min=1,max=1000"`
+		PageSize *int `query:"page_size" validate:"omitempty,min=1,max=100"`
+	}
+}
+
+type WebhookParamIdDTO struct {
+	WebhookId int `param:"id" validate:"required,min=1"`
+}
+
+type WebhookDeliveryGetAllDTO struct {
+	WebhookId  int `param:"id" validate:"required,min=1"`
+	Pagination struct {
+		Page     *int `query:"page" validate:"omitempty,min=1,max=1000"`
+		PageSize *int `query:"page_size" validate:"omitempty,min=1,max=100"`
+	}
+	Filter struct {
+		Status *string `query:"status" validate:"omitempty,oneof=pending succeeded dead"`
+	}
+}
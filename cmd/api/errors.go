@@ -1,31 +1,156 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/ucok-man/tcsa/internal/tlog"
+	"github.com/ucok-man/tcsa/internal/validator"
 )
 
+// errorResponse is sent under "error" for every error.
+type errorResponse struct {
+	Code    errorCode                       `json:"code"`
+	Message string                          `json:"message"`
+	Details any                             `json:"details,omitempty"`
+	Fields  map[string]validator.FieldError `json:"fields,omitempty" doc:"Code and message by field, on validation errors"`
+}
+
+// errorCode is the stable, machine-readable code of an error. Clients switch
+// on it rather than on the status or the message, which may change.
+type errorCode string
+
+// Error lets a code be the internal error of the echo.HTTPError it is sent
+// with, see withCode.
+func (c errorCode) Error() string {
+	return string(c)
+}
+
+const (
+	codeBadRequest           errorCode = "BAD_REQUEST"
+	codeValidationFailed     errorCode = "VALIDATION_FAILED"
+	codeForbidden            errorCode = "FORBIDDEN"
+	codeNotFound             errorCode = "NOT_FOUND"
+	codeTransactionNotFound  errorCode = "TRANSACTION_NOT_FOUND"
+	codeWebhookNotFound      errorCode = "WEBHOOK_NOT_FOUND"
+	codeJobNotFound          errorCode = "JOB_NOT_FOUND"
+	codeJobResultNotFound    errorCode = "JOB_RESULT_NOT_FOUND"
+	codeMethodNotAllowed     errorCode = "METHOD_NOT_ALLOWED"
+	codeVersionConflict      errorCode = "VERSION_CONFLICT"
+	codeUnsupportedMediaType errorCode = "UNSUPPORTED_MEDIA_TYPE"
+	codeRateLimited          errorCode = "RATE_LIMITED"
+	codeInternalError        errorCode = "INTERNAL_ERROR"
+	codeServiceUnavailable   errorCode = "SERVICE_UNAVAILABLE"
+)
+
+// errorCodes is the catalogue of error codes with the status they are sent
+// with. The first code of a status is used for errors raised without one,
+// like those of the router.
+var errorCodes = []struct {
+	code        errorCode
+	status      int
+	description string
+}{
+	{codeBadRequest, http.StatusBadRequest, "The request is malformed, like invalid JSON or a parameter of the wrong type"},
+	{codeForbidden, http.StatusForbidden, "The request is not allowed"},
+	{codeNotFound, http.StatusNotFound, "No route matches the path"},
+	{codeTransactionNotFound, http.StatusNotFound, "The transaction doesn't exist"},
+	{codeWebhookNotFound, http.StatusNotFound, "The webhook doesn't exist"},
+	{codeJobNotFound, http.StatusNotFound, "The job doesn't exist, or it finished longer than `TCSA_JOB_RETENTION` ago"},
+	{codeJobResultNotFound, http.StatusNotFound, "The job has no result yet, as it is not completed"},
+	{codeMethodNotAllowed, http.StatusMethodNotAllowed, "The route doesn't support the method"},
+	{codeVersionConflict, http.StatusConflict, "The record was changed by another request since it was read"},
+	{codeUnsupportedMediaType, http.StatusUnsupportedMediaType, "The body has a content type the route doesn't read"},
+	{codeValidationFailed, http.StatusUnprocessableEntity, "A parameter or a field of the body is invalid, see `fields`"},
+	{codeRateLimited, http.StatusTooManyRequests, "Too many requests from the client"},
+	{codeInternalError, http.StatusInternalServerError, "The server encountered a problem"},
+	{codeServiceUnavailable, http.StatusServiceUnavailable, "The route is unavailable, like while shutting down"},
+}
+
+// codeOf returns the code of err, or the first code of status when it has
+// none. Statuses outside the catalogue are BAD_REQUEST or INTERNAL_ERROR.
+func codeOf(err error, status int) errorCode {
+	var code errorCode
+	if errors.As(err, &code) {
+		return code
+	}
+	for _, entry := range errorCodes {
+		if entry.status == status {
+			return entry.code
+		}
+	}
+	if status < http.StatusInternalServerError {
+		return codeBadRequest
+	}
+	return codeInternalError
+}
+
+// withCode sends he with code.
+func withCode(he *echo.HTTPError, code errorCode) error {
+	return he.SetInternal(code)
+}
+
+// fieldErrors returns the code and the message of every field of validation
+// details. Fields of details without codes are INVALID.
+func fieldErrors(details any) map[string]validator.FieldError {
+	switch details := details.(type) {
+	case validator.ValidationErrors:
+		return details.Fields()
+	case validator.ValidationErrorMap:
+		return validator.ValidationErrors{ValidationErrorMap: details}.Fields()
+	case map[string]string:
+		fields := make(map[string]validator.FieldError, len(details))
+		for field, message := range details {
+			fields[field] = validator.FieldError{Code: validator.FieldInvalid, Message: message}
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// headerAcceptLanguage is the header the locale of validation messages is
+// negotiated by.
+const headerAcceptLanguage = "Accept-Language"
+
+// localize words verrs in the locale the request prefers, see
+// validator.Locale. Messages without a translation stay in English.
+func (app *application) localize(ctx echo.Context, verrs validator.ValidationErrors) validator.ValidationErrors {
+	v, ok := ctx.Echo().Validator.(*validator.Validator)
+	if !ok {
+		return verrs
+	}
+	header := ctx.Response().Header()
+	if !slices.Contains(header.Values(echo.HeaderVary), headerAcceptLanguage) {
+		header.Add(echo.HeaderVary, headerAcceptLanguage)
+	}
+	return v.Localize(verrs, v.Locale(ctx.Request().Header.Get(headerAcceptLanguage)))
+}
+
 func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 	if ctx.Response().Committed {
 		return
 	}
 
-	var response struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
-		Details any    `json:"details,omitempty"`
-	}
+	status := http.StatusInternalServerError
+	var response errorResponse
 
 	if he, ok := err.(*echo.HTTPError); ok {
-		response.Code = http.StatusText(he.Code)
+		status = he.Code
 		switch he.Code {
 		case http.StatusUnprocessableEntity:
 			response.Message = "unable to proccess request because some malformed input"
 			response.Details = he.Message
+			if verrs, ok := he.Message.(validator.ValidationErrors); ok {
+				response.Details = app.localize(ctx, verrs)
+			}
+			response.Fields = fieldErrors(response.Details)
 
 		// Change default notfound and method not allowed.
 		case http.StatusNotFound:
@@ -33,6 +158,12 @@ func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 		case http.StatusMethodNotAllowed:
 			response.Message = fmt.Sprintf("the %s method is not supported for this resource", ctx.Request().Method)
 		default:
+			if detailed, ok := he.Message.(errorWithDetails); ok {
+				response.Message = detailed.message
+				response.Details = detailed.details
+				break
+			}
+
 			msg, ok := he.Message.(string)
 			if !ok {
 				msg = fmt.Sprintf("%v", he.Message)
@@ -48,29 +179,22 @@ func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 			}
 			response.Message = msg
 		}
-
-		err = ctx.JSON(he.Code, envelope{"error": response})
-		if err != nil {
-			app.logger.Errorj(tlog.JSON{
-				"message": "error sending json response",
-				"error":   err,
-			})
-			ctx.Response().WriteHeader(http.StatusInternalServerError)
-		}
-		return
+	} else {
+		// Uncaught Error
+		app.logger.Errorj(tlog.JSON{
+			"message": "unhandled error occured",
+			"error":   err,
+		})
+		response.Message = "the server encountered a problem and could not process your request"
 	}
+	response.Code = codeOf(err, status)
 
-	// Uncaught Error
-	app.logger.Errorj(tlog.JSON{
-		"message": "unhandled error occured",
-		"error":   err,
-	})
-	ctx.Response().Status = 500
-	ctx.Response().WriteHeader(500)
-
-	response.Code = http.StatusText(http.StatusInternalServerError)
-	response.Message = "the server encountered a problem and could not process your request"
-	err = ctx.JSON(http.StatusInternalServerError, envelope{"error": response})
+	if app.config.Errors.ProblemJSON || acceptsProblem(ctx.Request().Header.Get(echo.HeaderAccept)) {
+		err = app.writeProblem(ctx, status, response)
+	} else {
+		ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+		err = ctx.JSON(status, envelope{"error": response})
+	}
 	if err != nil {
 		app.logger.Errorj(tlog.JSON{
 			"message": "error sending json response",
@@ -78,7 +202,119 @@ func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 		})
 		ctx.Response().WriteHeader(http.StatusInternalServerError)
 	}
+}
+
+// problemJSON is the media type of RFC 9457 problem details.
+const problemJSON = "application/problem+json"
+
+// problem is an error as RFC 9457 problem details. The code of the error is
+// an extension member, as are the messages and codes by field of validation
+// errors.
+type problem struct {
+	Type     string                          `json:"type"`
+	Title    string                          `json:"title"`
+	Status   int                             `json:"status"`
+	Detail   string                          `json:"detail,omitempty"`
+	Instance string                          `json:"instance,omitempty"`
+	Code     errorCode                       `json:"code"`
+	Errors   any                             `json:"errors,omitempty"`
+	Fields   map[string]validator.FieldError `json:"fields,omitempty"`
+}
+
+// problemType is a kind of error clients can tell apart by its type URI.
+type problemType struct {
+	Kind        string
+	Type        string
+	Title       string
+	Status      int
+	Description string
+}
+
+// problemTypePrefix starts the URIs of problemTypes. They are tag URIs, which
+// name the kinds without pointing to a page that may not be served.
+const problemTypePrefix = "tag:github.com,2026:ucok-man/tcsa/problems/"
+
+// problemTypes are the kinds of errors with a type of their own, by status.
+// Their URIs are stable and described in the API reference by kind, other
+// errors are about:blank with the status text as title.
+var problemTypes = map[int]problemType{
+	http.StatusUnprocessableEntity: {
+		Kind: "validation", Type: problemTypePrefix + "validation", Title: "Your request failed validation", Status: http.StatusUnprocessableEntity,
+		Description: "A parameter or a field of the body is invalid. The `errors` member holds the message by field.",
+	},
+	http.StatusConflict: {
+		Kind: "edit_conflict", Type: problemTypePrefix + "edit_conflict", Title: "The record was changed meanwhile", Status: http.StatusConflict,
+		Description: "The record was changed by another request since it was read. Fetch it again and retry.",
+	},
+	http.StatusNotFound: {
+		Kind: "not_found", Type: problemTypePrefix + "not_found", Title: "The resource could not be found", Status: http.StatusNotFound,
+		Description: "No resource exists at the path, or the record was deleted.",
+	},
+	http.StatusTooManyRequests: {
+		Kind: "rate_limited", Type: problemTypePrefix + "rate_limited", Title: "Too many requests", Status: http.StatusTooManyRequests,
+		Description: "The client sent more requests than allowed. Retry after `Retry-After` seconds.",
+	},
+}
+
+func (app *application) writeProblem(ctx echo.Context, status int, response errorResponse) error {
+	body := problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   response.Message,
+		Instance: ctx.Request().URL.Path,
+		Code:     response.Code,
+		Errors:   response.Details,
+		Fields:   response.Fields,
+	}
+	if kind, ok := problemTypes[status]; ok {
+		body.Type = kind.Type
+		body.Title = kind.Title
+	}
 
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, problemJSON)
+	header.Add(echo.HeaderVary, echo.HeaderAccept)
+	return ctx.JSON(status, body)
+}
+
+// acceptsProblem reports whether an Accept header prefers problem details
+// over the error envelope, that is it lists application/problem+json with a
+// quality no lower than application/json.
+func acceptsProblem(accept string) bool {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		quality[mediaType] = q
+	}
+
+	q, ok := quality[problemJSON]
+	return ok && q > 0 && q >= quality[echo.MIMEApplicationJSON]
+}
+
+// errorWithDetails is the message of an error answered with details, other
+// than a failed validation.
+type errorWithDetails struct {
+	message string
+	details any
+}
+
+// ErrResponseMismatch reports a response that doesn't match the OpenAPI
+// document, with the mismatch as details.
+func (app *application) ErrResponseMismatch(details validator.ValidationErrors) error {
+	return withCode(echo.NewHTTPError(http.StatusInternalServerError, errorWithDetails{
+		message: "the response does not match the openapi document",
+		details: details,
+	}), codeInternalError)
 }
 
 func (app *application) ErrInternalServer(err error, message string, req *http.Request) error {
@@ -89,44 +325,46 @@ func (app *application) ErrInternalServer(err error, message string, req *http.R
 		"method":  req.Method,
 		"error":   err,
 	})
-	return echo.NewHTTPError(
+	return withCode(echo.NewHTTPError(
 		http.StatusInternalServerError,
 		"the server encountered a problem and could not process your request",
-	)
+	), codeInternalError)
 }
 
-func (app *application) ErrNotFound(customMsg ...string) error {
-	message := "the requested resource could not be found"
-	if len(customMsg) > 0 && customMsg[0] != "" {
-		message = customMsg[0]
-	}
-	return echo.NewHTTPError(http.StatusNotFound, message)
+// ErrNotFound reports a missing resource by the code of its kind, like
+// codeTransactionNotFound.
+func (app *application) ErrNotFound(code errorCode) error {
+	return withCode(echo.NewHTTPError(http.StatusNotFound, "the requested resource could not be found"), code)
 }
 
 func (app *application) ErrMethodNotAllowed(method string) error {
-	return echo.NewHTTPError(
+	return withCode(echo.NewHTTPError(
 		http.StatusMethodNotAllowed,
 		fmt.Sprintf("the %s method is not supported for this resource", method),
-	)
+	), codeMethodNotAllowed)
 }
 
 func (app *application) ErrBadRequest(message string) error {
-	return echo.NewHTTPError(http.StatusBadRequest, message)
+	return withCode(echo.NewHTTPError(http.StatusBadRequest, message), codeBadRequest)
 }
 
 func (app *application) ErrFailedValidation(errmap any) error {
-	return echo.NewHTTPError(http.StatusUnprocessableEntity, errmap)
+	return withCode(echo.NewHTTPError(http.StatusUnprocessableEntity, errmap), codeValidationFailed)
 }
 
 func (app *application) ErrEditConflict() error {
-	return echo.NewHTTPError(
+	return withCode(echo.NewHTTPError(
 		http.StatusConflict,
 		"unable to update the record due to an edit conflict, please try again",
-	)
+	), codeVersionConflict)
 }
 
 func (app *application) ErrRateLimitExceeded() error {
-	return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+	return withCode(echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded"), codeRateLimited)
+}
+
+func (app *application) ErrServiceUnavailable(message string) error {
+	return withCode(echo.NewHTTPError(http.StatusServiceUnavailable, message), codeServiceUnavailable)
 }
 
 func (app *application) ErrForbidden(message ...string) error {
@@ -134,5 +372,5 @@ func (app *application) ErrForbidden(message ...string) error {
 	if len(message) > 0 && message[0] != "" {
 		msg = message[0]
 	}
-	return echo.NewHTTPError(http.StatusForbidden, msg)
+	return withCode(echo.NewHTTPError(http.StatusForbidden, msg), codeForbidden)
 }
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+func TestProblemDetails(t *testing.T) {
+	serve := func(t *testing.T, problemJSON bool) func(method, path, body, accept string) (*http.Response, map[string]any) {
+		app := createTestApp(t, data.NewMemoryModels())
+		app.config.Errors.ProblemJSON = problemJSON
+
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+
+		return func(method, path, body, accept string) (*http.Response, map[string]any) {
+			req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			var decoded map[string]any
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&decoded))
+			return res, decoded
+		}
+	}
+
+	t.Run("sends the envelope by default", func(t *testing.T) {
+		// Setup
+		do := serve(t, false)
+
+		// Execute
+		res, body := do(http.MethodGet, "/transactions/99", "", "")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.Contains(t, res.Header.Values("Vary"), "Accept")
+		assert.Contains(t, body, "error")
+	})
+
+	t.Run("sends problem details to clients asking for them", func(t *testing.T) {
+		// Setup
+		do := serve(t, false)
+
+		// Execute
+		res, body := do(http.MethodGet, "/transactions/99", "", "application/problem+json")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+		assert.Equal(t, map[string]any{
+			"type":     "tag:github.com,2026:ucok-man/tcsa/problems/not_found",
+			"title":    "The resource could not be found",
+			"status":   float64(http.StatusNotFound),
+			"detail":   "the requested resource could not be found",
+			"instance": "/transactions/99",
+			"code":     "TRANSACTION_NOT_FOUND",
+		}, body)
+	})
+
+	t.Run("carries validation errors as an extension member", func(t *testing.T) {
+		// Setup
+		do := serve(t, false)
+
+		// Execute
+		res, body := do(http.MethodPost, "/transactions", `{"user_id":1,"amount":0}`, "application/problem+json, application/json;q=0.9")
+
+		// Assert
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, problemTypePrefix+"validation", body["type"])
+		assert.Equal(t, "VALIDATION_FAILED", body["code"])
+		assert.Contains(t, body["errors"], "amount")
+		assert.Equal(t, "BELOW_MINIMUM", body["fields"].(map[string]any)["amount"].(map[string]any)["code"])
+	})
+
+	t.Run("uses about:blank for other errors", func(t *testing.T) {
+		// Setup
+		do := serve(t, false)
+
+		// Execute
+		res, body := do(http.MethodPost, "/transactions", `{"user_id":`, "application/problem+json")
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "about:blank", body["type"])
+		assert.Equal(t, "Bad Request", body["title"])
+		assert.NotContains(t, body, "errors")
+	})
+
+	t.Run("sends problem details to every client when configured", func(t *testing.T) {
+		// Setup
+		do := serve(t, true)
+
+		// Execute
+		res, body := do(http.MethodGet, "/transactions/99", "", "application/json")
+
+		// Assert
+		assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+		assert.Equal(t, problemTypePrefix+"not_found", body["type"])
+	})
+}
+
+func TestErrorCodes(t *testing.T) {
+	// Setup
+	app := createTestApp(t, data.NewMemoryModels())
+	do := serveTestApp(t, app)
+	do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+
+	decode := func(t *testing.T, res *http.Response) errorResponse {
+		var body struct {
+			Error errorResponse `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return body.Error
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   errorCode
+	}{
+		{"unknown route", http.MethodGet, "/nope", "", http.StatusNotFound, codeNotFound},
+		{"missing transaction", http.MethodGet, "/transactions/99", "", http.StatusNotFound, codeTransactionNotFound},
+		{"malformed body", http.MethodPost, "/transactions", `{"user_id":`, http.StatusBadRequest, codeBadRequest},
+		{"invalid body", http.MethodPost, "/transactions", `{"user_id":1,"amount":0}`, http.StatusUnprocessableEntity, codeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			res := do(tt.method, tt.path, tt.body)
+
+			// Assert
+			assert.Equal(t, tt.status, res.StatusCode)
+			assert.Equal(t, tt.code, decode(t, res).Code)
+		})
+	}
+
+	t.Run("codes every field of a validation error", func(t *testing.T) {
+		// Execute
+		res := do(http.MethodPost, "/transactions", `{"amount":0}`)
+
+		// Assert
+		assert.Equal(t, map[string]validator.FieldError{
+			"user_id": {Code: validator.FieldRequired, Message: "user_id is a required field"},
+			"amount":  {Code: validator.FieldBelowMinimum, Message: "amount must be 1 or greater"},
+		}, decode(t, res).Fields)
+	})
+
+	t.Run("answers edit conflicts with their code", func(t *testing.T) {
+		// Execute
+		err := app.ErrEditConflict()
+
+		// Assert
+		assert.Equal(t, codeVersionConflict, codeOf(err, http.StatusConflict))
+	})
+
+	t.Run("lists every code once", func(t *testing.T) {
+		// Execute
+		seen := map[errorCode]bool{}
+		for _, entry := range errorCodes {
+			// Assert
+			assert.False(t, seen[entry.code], entry.code)
+			assert.NotEmpty(t, entry.description, entry.code)
+			seen[entry.code] = true
+		}
+	})
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json", true},
+		{"application/json, application/problem+json;q=0.5", false},
+		{"application/problem+json;q=0", false},
+		{"application/problem+json;q=x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			// Execute & Assert
+			assert.Equal(t, tt.want, acceptsProblem(tt.accept))
+		})
+	}
+}
+
+func TestValidationLanguage(t *testing.T) {
+	// Setup
+	app := createTestApp(t, data.NewMemoryModels())
+	app.config.Batch.MaxItems = 3
+	app.config.Batch.MaxBodyBytes = 1 << 20
+	srv := httptest.NewServer(app.routes())
+	t.Cleanup(srv.Close)
+
+	do := func(t *testing.T, path, body, acceptLanguage string) (*http.Response, map[string]any) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var decoded map[string]any
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&decoded))
+		return res, decoded
+	}
+
+	t.Run("words messages in English by default", func(t *testing.T) {
+		// Execute
+		res, body := do(t, "/transactions", `{"user_id":1,"amount":0}`, "")
+
+		// Assert
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, map[string]any{"amount": "amount must be 1 or greater"}, body["error"].(map[string]any)["details"])
+	})
+
+	t.Run("words messages in the language of the request", func(t *testing.T) {
+		// Execute
+		res, body := do(t, "/transactions", `{"user_id":1,"amount":0}`, "id-ID,id;q=0.9,en;q=0.8")
+
+		// Assert
+		require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Contains(t, res.Header.Values("Vary"), "Accept-Language")
+		errBody := body["error"].(map[string]any)
+		assert.Equal(t, map[string]any{"amount": "amount harus 1 atau lebih besar"}, errBody["details"])
+		assert.Equal(t, "BELOW_MINIMUM", errBody["fields"].(map[string]any)["amount"].(map[string]any)["code"])
+	})
+
+	t.Run("words the failures of batch items", func(t *testing.T) {
+		// Execute
+		res, body := do(t, "/transactions/batch", `{"mode":"partial","items":[{"amount":100}]}`, "id")
+
+		// Assert
+		require.Equal(t, http.StatusMultiStatus, res.StatusCode)
+		item := body["data"].([]any)[0].(map[string]any)
+		assert.Equal(t, map[string]any{"user_id": "user_id wajib diisi"}, item["errors"])
+	})
+}
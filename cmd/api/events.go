@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ucok-man/tcsa/internal/outbox"
+	"github.com/ucok-man/tcsa/internal/webhook"
+)
+
+func (app *application) newWebhookDispatcher() *webhook.Dispatcher {
+	opts := webhook.DefaultOptions()
+	opts.PollInterval = app.config.Webhook.PollInterval
+	opts.Timeout = app.config.Webhook.Timeout
+	opts.MaxAttempts = app.config.Webhook.MaxAttempts
+	opts.BackoffBase = app.config.Webhook.BackoffBase
+	opts.BackoffMax = app.config.Webhook.BackoffMax
+
+	return webhook.NewDispatcher(app.models.WebhookDeliveries, app.logger, opts)
+}
+
+// newOutboxRelay creates the relay publishing the transaction events to the
+// publishers named in OUTBOX_PUBLISHERS.
+func (app *application) newOutboxRelay() *outbox.Relay {
+	opts := outbox.DefaultOptions()
+	opts.PollInterval = app.config.Outbox.PollInterval
+	opts.BatchSize = app.config.Outbox.BatchSize
+	opts.Retention = app.config.Outbox.Retention
+
+	relay := outbox.NewRelay(app.models.Outbox, app.logger, opts)
+	for _, name := range app.config.Outbox.Publishers {
+		switch name {
+		case "stdout":
+			relay.Add(name, outbox.NewWriterPublisher(os.Stdout))
+		case "webhook":
+			relay.Add(name, webhook.NewPublisher(app.models.WebhookDeliveries))
+		case "bus":
+			relay.Add(name, app.events)
+		}
+	}
+	return relay
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// exportColumn is a transaction field that can be exported.
+type exportColumn struct {
+	name  string
+	value func(*data.Transaction) any
+}
+
+var exportColumns = []exportColumn{
+	{"id", func(t *data.Transaction) any { return t.ID }},
+	{"user_id", func(t *data.Transaction) any { return t.UserId }},
+	{"amount", func(t *data.Transaction) any { return t.Amount }},
+	{"status", func(t *data.Transaction) any { return t.Status }},
+	{"version", func(t *data.Transaction) any { return t.Version }},
+	{"created_at", func(t *data.Transaction) any { return t.CreatedAt }},
+	{"updated_at", func(t *data.Transaction) any { return t.UpdatedAt }},
+}
+
+// parseExportColumns resolves a comma separated list of column names, in the
+// given order. An empty list selects every column.
+func parseExportColumns(list string) ([]exportColumn, error) {
+	if strings.TrimSpace(list) == "" {
+		return exportColumns, nil
+	}
+
+	var columns []exportColumn
+	seen := map[string]bool{}
+
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is listed twice", name)
+		}
+		seen[name] = true
+
+		found := false
+		for _, column := range exportColumns {
+			if column.name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return columns, nil
+}
+
+// exportWriter encodes transactions in one of the export formats. Output is
+// buffered until Flush.
+type exportWriter interface {
+	Write(transaction *data.Transaction) error
+	Flush() error
+}
+
+func newExportWriter(format string, columns []exportColumn, w io.Writer) (exportWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVExportWriter(columns, w), nil
+	case "ndjson":
+		return &ndjsonExportWriter{columns: columns, w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// exportContentType is the media type of an export format.
+func exportContentType(format string) string {
+	if format == "csv" {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+type csvExportWriter struct {
+	columns []exportColumn
+	w       *csv.Writer
+	header  bool
+	record  []string
+}
+
+func newCSVExportWriter(columns []exportColumn, w io.Writer) *csvExportWriter {
+	return &csvExportWriter{
+		columns: columns,
+		w:       csv.NewWriter(w),
+		record:  make([]string, len(columns)),
+	}
+}
+
+func (cw *csvExportWriter) Write(transaction *data.Transaction) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	for i, column := range cw.columns {
+		switch value := column.value(transaction).(type) {
+		case int:
+			cw.record[i] = strconv.Itoa(value)
+		case time.Time:
+			cw.record[i] = value.Format(time.RFC3339Nano)
+		default:
+			cw.record[i] = fmt.Sprint(value)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+// Flush also writes the header of an export without rows.
+func (cw *csvExportWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvExportWriter) writeHeader() error {
+	if cw.header {
+		return nil
+	}
+	cw.header = true
+
+	for i, column := range cw.columns {
+		cw.record[i] = column.name
+	}
+	return cw.w.Write(cw.record)
+}
+
+// ndjsonExportWriter writes one JSON object per line, with the keys in column
+// order.
+type ndjsonExportWriter struct {
+	columns []exportColumn
+	w       io.Writer
+	buf     bytes.Buffer
+}
+
+func (nw *ndjsonExportWriter) Write(transaction *data.Transaction) error {
+	nw.buf.WriteByte('{')
+	for i, column := range nw.columns {
+		if i > 0 {
+			nw.buf.WriteByte(',')
+		}
+
+		value, err := json.Marshal(column.value(transaction))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&nw.buf, "%q:", column.name)
+		nw.buf.Write(value)
+	}
+	nw.buf.WriteString("}\n")
+
+	if nw.buf.Len() < 32*1024 {
+		return nil
+	}
+	return nw.Flush()
+}
+
+func (nw *ndjsonExportWriter) Flush() error {
+	_, err := nw.w.Write(nw.buf.Bytes())
+	nw.buf.Reset()
+	return err
+}
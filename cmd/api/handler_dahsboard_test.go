@@ -3,13 +3,18 @@ package main
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/validator"
 )
 
 func TestSummaryTransactionHandler(t *testing.T) {
@@ -571,3 +576,146 @@ func TestSummaryTransactionHandler(t *testing.T) {
 		mockModel.AssertExpectations(t)
 	})
 }
+
+// dialSummaryWebSocket serves the summary WebSocket of app and connects to it
+// with query.
+func dialSummaryWebSocket(t *testing.T, app *application, query string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	e.HTTPErrorHandler = app.HTTPErrorHandler
+	e.GET("/dashboard/summary/ws", app.summaryWebSocketHandler)
+
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/dashboard/summary/ws" + query
+	conn, res, err := websocket.DefaultDialer.Dial(url, nil)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, res, err
+}
+
+func readSummaryMessage(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message map[string]any
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestSummaryWebSocketHandler(t *testing.T) {
+	expectedParam := data.TransactionSummaryParam{
+		Page:            1,
+		PageSize:        10,
+		PageOffset:      0,
+		SortColumn:      "id",
+		SortDirection:   "ASC",
+		FilterDateRange: 0,
+		FilterUserId:    7,
+	}
+	summary := &data.TransactionSummary{Transactions: []*data.Transaction{}, Summary: data.Summary{CountTotal: 1}}
+	metadata := &data.Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 1, TotalRecords: 1}
+
+	newApp := func(t *testing.T, mockModel *data.MockTransactionModel) *application {
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Stream.BufferSize = 8
+		app.config.Stream.HeartbeatInterval = time.Minute
+		app.config.Stream.SummaryDebounce = 50 * time.Millisecond
+		return app
+	}
+
+	t.Run("sends summary on connect and once per burst of changes", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockModel.On("Summary", expectedParam).Return(summary, metadata, nil).Times(2)
+		app := newApp(t, mockModel)
+
+		conn, _, err := dialSummaryWebSocket(t, app, "?user_id=7")
+		require.NoError(t, err)
+
+		// Execute
+		first := readSummaryMessage(t, conn)
+
+		for id := range 3 {
+			app.broker.Publish(testOutboxMessage(t, id+1, data.EventTransactionCreated, 7, data.TransactionStatusPending))
+		}
+		second := readSummaryMessage(t, conn)
+
+		// Assert
+		assert.Equal(t, "summary", first["type"])
+		assert.Equal(t, float64(1), first["data"].(map[string]any)["summary"].(map[string]any)["total_transaction"])
+		assert.Equal(t, "summary", second["type"])
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("closes with going away on shutdown", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockModel.On("Summary", expectedParam).Return(summary, metadata, nil).Once()
+		app := newApp(t, mockModel)
+
+		conn, _, err := dialSummaryWebSocket(t, app, "?user_id=7")
+		require.NoError(t, err)
+		readSummaryMessage(t, conn)
+
+		// Execute
+		app.broker.Close()
+
+		// Assert
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+	})
+
+	t.Run("returns validation error before upgrading", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := newApp(t, mockModel)
+
+		// Execute
+		_, res, err := dialSummaryWebSocket(t, app, "?date_range=400")
+
+		// Assert
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Zero(t, app.broker.Len())
+		mockModel.AssertNotCalled(t, "Summary", mock.Anything)
+	})
+}
+
+func TestSummaryFilterAffects(t *testing.T) {
+	now := time.Date(2026, 10, 22, 9, 0, 0, 0, time.UTC)
+
+	event := func(userId int, createdAt time.Time) data.Event {
+		event, err := data.NewEvent(data.EventTransactionUpdated, data.TransactionEventData{
+			Transaction: &data.Transaction{ID: 1, UserId: userId, CreatedAt: createdAt},
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	tests := []struct {
+		name   string
+		filter summaryFilter
+		event  data.Event
+		want   bool
+	}{
+		{name: "no filter", filter: summaryFilter{}, event: event(1, now.AddDate(-1, 0, 0)), want: true},
+		{name: "same user", filter: summaryFilter{userId: 1}, event: event(1, now), want: true},
+		{name: "other user", filter: summaryFilter{userId: 1}, event: event(2, now), want: false},
+		{name: "inside date range", filter: summaryFilter{dateRange: 7}, event: event(1, now.AddDate(0, 0, -6)), want: true},
+		{name: "within a day of the date range", filter: summaryFilter{dateRange: 7}, event: event(1, now.AddDate(0, 0, -7).Add(-time.Hour)), want: true},
+		{name: "outside date range", filter: summaryFilter{dateRange: 7}, event: event(1, now.AddDate(0, 0, -8).Add(-time.Minute)), want: false},
+		{name: "unreadable data", filter: summaryFilter{userId: 1}, event: data.Event{Data: []byte(`"x"`)}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.affects(tt.event, now))
+		})
+	}
+}
@@ -1,15 +1,176 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/ucok-man/tcsa/cmd/api/dto"
 	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
 	"github.com/ucok-man/tcsa/internal/utility"
 )
 
 func (app *application) summaryTransactionHandler(ctx echo.Context) error {
+	dto, err := app.bindSummaryDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary, metadata, err := app.models.Transactions.Summary(app.summaryParam(dto))
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get transaction summary", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data":     summary,
+		"metadata": metadata,
+	})
+}
+
+const (
+	// summaryWriteTimeout bounds sending one message to a WebSocket client.
+	summaryWriteTimeout = 10 * time.Second
+	// summaryReadLimit is the largest message accepted from a client. Clients
+	// have nothing to say beyond control frames.
+	summaryReadLimit = 512
+)
+
+var summaryUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// summaryWebSocketHandler sends the dashboard summary over a WebSocket, once
+// on connect and again whenever a change may affect it. The filters are the
+// query parameters of GET /dashboard/summary. Changes are coalesced: the
+// summary is recomputed at most once per STREAM_SUMMARY_DEBOUNCE.
+func (app *application) summaryWebSocketHandler(ctx echo.Context) error {
+	dto, err := app.bindSummaryDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	sub, err := app.broker.Subscribe(app.config.Stream.BufferSize)
+	if err != nil {
+		return app.ErrServiceUnavailable("the server is shutting down, please reconnect")
+	}
+	defer func() { sub.Close() }()
+
+	upgrader := summaryUpgrader
+	upgrader.CheckOrigin = app.checkWebSocketOrigin
+
+	// Upgrade answers the request itself when it fails.
+	conn, err := upgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	param := app.summaryParam(dto)
+	filter := summaryFilter{dateRange: param.FilterDateRange, userId: param.FilterUserId}
+	pingInterval := app.config.Stream.HeartbeatInterval
+
+	// The reader only handles control frames, and tells when the client is
+	// gone. Closing conn on return ends it.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+
+		conn.SetReadLimit(summaryReadLimit)
+		conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		})
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func() bool {
+		summary, metadata, err := app.models.Transactions.Summary(param)
+		if err != nil {
+			app.logger.Errorj(tlog.JSON{"message": "failed get transaction summary", "error": err})
+			return app.writeWebSocketJSON(conn, envelope{
+				"type":  "error",
+				"error": "the server encountered a problem and could not compute the summary",
+			})
+		}
+		return app.writeWebSocketJSON(conn, envelope{
+			"type":     "summary",
+			"data":     summary,
+			"metadata": metadata,
+		})
+	}
+
+	if !send() {
+		return nil
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	// debounce is armed by the first relevant change and fires once for
+	// every change up to then.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+	pending := false
+	arm := func() {
+		if !pending {
+			pending = true
+			debounce.Reset(app.config.Stream.SummaryDebounce)
+		}
+	}
+
+	for {
+		select {
+		case <-gone:
+			return nil
+
+		case message, ok := <-sub.C():
+			if !ok {
+				if !sub.Evicted() {
+					app.closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+					return nil
+				}
+
+				// Changes may have been missed, recompute to be safe.
+				sub, err = app.broker.Subscribe(app.config.Stream.BufferSize)
+				if err != nil {
+					app.closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+					return nil
+				}
+				arm()
+				continue
+			}
+			if filter.affects(message.Event, time.Now()) {
+				arm()
+			}
+
+		case <-debounce.C:
+			pending = false
+			if !send() {
+				return nil
+			}
+
+		case <-ping.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(summaryWriteTimeout))
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (app *application) bindSummaryDTO(ctx echo.Context) (dto.TransactionSummaryDTO, error) {
 	var dto dto.TransactionSummaryDTO
 
 	// Set Default Value
@@ -18,14 +179,17 @@ func (app *application) summaryTransactionHandler(ctx echo.Context) error {
 	dto.Sort.Value = utility.SetPtrValue("id")
 
 	if err := ctx.Bind(&dto); err != nil {
-		return app.ErrBadRequest(err.Error())
+		return dto, app.ErrBadRequest(err.Error())
 	}
 
 	if err := ctx.Validate(&dto); err != nil {
-		return app.ErrFailedValidation(err)
+		return dto, app.ErrFailedValidation(err)
 	}
+	return dto, nil
+}
 
-	summary, metadata, err := app.models.Transactions.Summary(data.TransactionSummaryParam{
+func (app *application) summaryParam(dto dto.TransactionSummaryDTO) data.TransactionSummaryParam {
+	return data.TransactionSummaryParam{
 		Page:            *dto.Pagination.Page,
 		PageSize:        *dto.Pagination.PageSize,
 		PageOffset:      app.PageOffset(*dto.Pagination.Page, *dto.Pagination.PageSize),
@@ -33,13 +197,64 @@ func (app *application) summaryTransactionHandler(ctx echo.Context) error {
 		SortDirection:   app.SortDirection(*dto.Sort.Value),
 		FilterDateRange: utility.DerefOrDefault(dto.Filter.DateRange, 0),
 		FilterUserId:    utility.DerefOrDefault(dto.Filter.UserId, 0),
-	})
+	}
+}
+
+// checkWebSocketOrigin accepts the same origins as CORS, and requests without
+// an Origin header, which don't come from browsers.
+func (app *application) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	origins := app.liveConfig().Cors.TrustedOrigins
+	return len(origins) == 0 || slices.Contains(origins, origin)
+}
+
+// writeWebSocketJSON sends v as a text message. It returns false when the
+// client is gone.
+func (app *application) writeWebSocketJSON(conn *websocket.Conn, v any) bool {
+	payload, err := json.Marshal(v)
 	if err != nil {
-		return app.ErrInternalServer(err, "failed get transaction summary", ctx.Request())
+		app.logger.Errorj(tlog.JSON{"message": "failed encoding websocket message", "error": err})
+		return false
 	}
 
-	return ctx.JSON(http.StatusOK, envelope{
-		"data":     summary,
-		"metadata": metadata,
-	})
+	conn.SetWriteDeadline(time.Now().Add(summaryWriteTimeout))
+	return conn.WriteMessage(websocket.TextMessage, payload) == nil
+}
+
+func (app *application) closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(summaryWriteTimeout))
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		app.logger.Debugj(tlog.JSON{"message": "failed closing websocket", "error": err})
+	}
+}
+
+// summaryFilter tells whether an event may change a summary with the same
+// filters. A needless recompute is cheap, a missed one leaves the dashboard
+// wrong, so doubtful cases count as affecting it.
+type summaryFilter struct {
+	dateRange int
+	userId    int
+}
+
+func (f summaryFilter) affects(event data.Event, now time.Time) bool {
+	var eventData data.TransactionEventData
+	if err := json.Unmarshal(event.Data, &eventData); err != nil || eventData.Transaction == nil {
+		return true
+	}
+
+	if f.userId != 0 && eventData.Transaction.UserId != f.userId {
+		return false
+	}
+
+	if f.dateRange != 0 {
+		// The database decides what "today" is, allow a day for time zones.
+		since := now.AddDate(0, 0, -f.dateRange-1)
+		if eventData.Transaction.CreatedAt.Before(since) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/internal/openapi"
+)
+
+//go:embed docs/swagger.yaml
+var swaggerFile embed.FS
+
+// docsFiles are the template and the assets of /docs, which renders the
+// OpenAPI document without scripts or third-party resources.
+//
+//go:embed docs/ui
+var docsFiles embed.FS
+
+// docsPolicy allows the page nothing but its own stylesheet.
+const docsPolicy = "default-src 'none'; style-src 'self'; img-src 'self' data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// docsAsset is a file served with a strong validator.
+type docsAsset struct {
+	body        []byte
+	contentType string
+	etag        string
+}
+
+func newDocsAsset(body []byte, contentType string) docsAsset {
+	sum := sha256.Sum256(body)
+	return docsAsset{body: body, contentType: contentType, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+}
+
+// serve answers 304 when the client holds the current version. Assets
+// requested by version never change and are cached for good, the rest must
+// be revalidated.
+func (a docsAsset) serve(ctx echo.Context, immutable bool) error {
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentSecurityPolicy, docsPolicy)
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("ETag", a.etag)
+	if immutable {
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	if slices.Contains(strings.Split(ctx.Request().Header.Get("If-None-Match"), ", "), a.etag) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.Blob(http.StatusOK, a.contentType, a.body)
+}
+
+// docs holds everything /docs serves, built once from the embedded files.
+var docs = sync.OnceValues(func() (map[string]docsAsset, error) {
+	assets := map[string]docsAsset{}
+
+	spec, err := swaggerFile.ReadFile("docs/swagger.yaml")
+	if err != nil {
+		return nil, err
+	}
+	assets["swagger.yaml"] = newDocsAsset(spec, "application/yaml")
+
+	stylesheet, err := docsFiles.ReadFile("docs/ui/docs.css")
+	if err != nil {
+		return nil, err
+	}
+	assets["docs.css"] = newDocsAsset(stylesheet, "text/css; charset=utf-8")
+
+	tmpl, err := template.New("index.html").Funcs(template.FuncMap{
+		"markdown":    docsMarkdown,
+		"refName":     path.Base,
+		"constraints": docsConstraints,
+		"has":         slices.Contains[[]string],
+	}).ParseFS(docsFiles, "docs/ui/index.html")
+	if err != nil {
+		return nil, err
+	}
+
+	page := newDocsPage(embeddedOpenAPI())
+	page.Stylesheet = "/docs/assets/docs.css?v=" + strings.Trim(assets["docs.css"].etag, `"`)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	assets["index.html"] = newDocsAsset(buf.Bytes(), echo.MIMETextHTMLCharsetUTF8)
+
+	return assets, nil
+})
+
+func (app *application) serveDocsHandler(ctx echo.Context) error {
+	return app.serveDocsAsset(ctx, "index.html", false)
+}
+
+func (app *application) serveDocsAssetHandler(ctx echo.Context) error {
+	name := ctx.Param("name")
+	if name == "index.html" || name == "swagger.yaml" {
+		return app.ErrNotFound(codeNotFound)
+	}
+
+	assets, err := docs()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed rendering documentation", ctx.Request())
+	}
+	asset, ok := assets[name]
+	if !ok {
+		return app.ErrNotFound(codeNotFound)
+	}
+	return asset.serve(ctx, ctx.QueryParam("v") == strings.Trim(asset.etag, `"`))
+}
+
+func (app *application) serveSwaggerHandler(ctx echo.Context) error {
+	return app.serveDocsAsset(ctx, "swagger.yaml", false)
+}
+
+func (app *application) serveDocsAsset(ctx echo.Context, name string, immutable bool) error {
+	assets, err := docs()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed rendering documentation", ctx.Request())
+	}
+	return assets[name].serve(ctx, immutable)
+}
+
+// docsPage is what the template of /docs renders, the operations grouped by
+// their tag in the order of the document.
+type docsPage struct {
+	Info       openapi.Info
+	Stylesheet string
+	Tags       []docsTag
+	Problems   []problemType
+	ErrorCodes []docsErrorCode
+	Schemas    []docsSchema
+}
+
+type docsTag struct {
+	Name        string
+	Description string
+	Operations  []docsOperation
+}
+
+type docsOperation struct {
+	*openapi.Operation
+	Method    string
+	Path      string
+	Responses []docsResponse
+}
+
+type docsResponse struct {
+	*openapi.Response
+	Status string
+}
+
+type docsErrorCode struct {
+	Code        errorCode
+	Status      int
+	Description string
+}
+
+type docsSchema struct {
+	Name   string
+	Schema *openapi.Schema
+}
+
+func newDocsPage(doc *openapi.Document) docsPage {
+	page := docsPage{Info: doc.Info}
+
+	tags := map[string]*docsTag{}
+	for _, tag := range doc.Tags {
+		page.Tags = append(page.Tags, docsTag{Name: tag.Name, Description: tag.Description})
+	}
+	for i := range page.Tags {
+		tags[page.Tags[i].Name] = &page.Tags[i]
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	for _, p := range paths {
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			op := doc.Paths[p].Operation(method)
+			if op == nil || len(op.Tags) == 0 || tags[op.Tags[0]] == nil {
+				continue
+			}
+
+			view := docsOperation{Operation: op, Method: method, Path: p}
+			statuses := make([]string, 0, len(op.Responses))
+			for status := range op.Responses {
+				statuses = append(statuses, status)
+			}
+			slices.Sort(statuses)
+			for _, status := range statuses {
+				response := op.Responses[status]
+				if name, ok := strings.CutPrefix(response.Ref, "#/components/responses/"); ok {
+					response = doc.Components.Responses[name]
+				}
+				view.Responses = append(view.Responses, docsResponse{Response: response, Status: status})
+			}
+
+			tag := tags[op.Tags[0]]
+			tag.Operations = append(tag.Operations, view)
+		}
+	}
+
+	for _, kind := range problemTypes {
+		page.Problems = append(page.Problems, kind)
+	}
+	slices.SortFunc(page.Problems, func(a, b problemType) int { return strings.Compare(a.Kind, b.Kind) })
+
+	for _, entry := range errorCodes {
+		page.ErrorCodes = append(page.ErrorCodes, docsErrorCode{Code: entry.code, Status: entry.status, Description: entry.description})
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		page.Schemas = append(page.Schemas, docsSchema{Name: name, Schema: schema})
+	}
+	slices.SortFunc(page.Schemas, func(a, b docsSchema) int { return strings.Compare(a.Name, b.Name) })
+
+	return page
+}
+
+var docsCode = regexp.MustCompile("`([^`]+)`")
+
+// docsMarkdown renders the little markdown descriptions use, paragraphs
+// and code spans.
+func docsMarkdown(text string) template.HTML {
+	var buf strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		buf.WriteString("<p>")
+		buf.WriteString(docsCode.ReplaceAllString(html.EscapeString(paragraph), "<code>$1</code>"))
+		buf.WriteString("</p>")
+	}
+	return template.HTML(buf.String())
+}
+
+// docsConstraints renders the constraints of a schema next to its type.
+func docsConstraints(schema *openapi.Schema) template.HTML {
+	var parts []string
+	if len(schema.Enum) > 0 {
+		values := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			values[i] = "<code>" + html.EscapeString(fmt.Sprint(value)) + "</code>"
+		}
+		parts = append(parts, "one of "+strings.Join(values, ", "))
+	}
+	if schema.Minimum != nil {
+		parts = append(parts, "≥ "+fmt.Sprint(*schema.Minimum))
+	}
+	if schema.Maximum != nil {
+		parts = append(parts, "≤ "+fmt.Sprint(*schema.Maximum))
+	}
+	if schema.MinLength != nil {
+		parts = append(parts, "at least "+fmt.Sprint(*schema.MinLength)+" characters")
+	}
+	if schema.MaxLength != nil {
+		parts = append(parts, "at most "+fmt.Sprint(*schema.MaxLength)+" characters")
+	}
+	if schema.MinItems != nil {
+		parts = append(parts, "at least "+fmt.Sprint(*schema.MinItems)+" items")
+	}
+	if schema.MaxItems != nil {
+		parts = append(parts, "at most "+fmt.Sprint(*schema.MaxItems)+" items")
+	}
+	if schema.UniqueItems {
+		parts = append(parts, "unique")
+	}
+	if schema.Nullable {
+		parts = append(parts, "nullable")
+	}
+
+	var out string
+	if len(parts) > 0 {
+		out = ` <span class="constraints">` + strings.Join(parts, ", ") + `</span>`
+	}
+	if schema.Description != "" {
+		out += string(docsMarkdown(schema.Description))
+	}
+	return template.HTML(out)
+}
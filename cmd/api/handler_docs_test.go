@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func TestDocs(t *testing.T) {
+	serve := func(t *testing.T, enabled bool) func(path string, header http.Header) (*http.Response, string) {
+		app := createTestApp(t, data.NewMemoryModels())
+		app.config.Docs.Enabled = enabled
+
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+
+		return func(path string, header http.Header) (*http.Response, string) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+			require.NoError(t, err)
+			if header != nil {
+				req.Header = header
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			return res, string(body)
+		}
+	}
+
+	t.Run("renders every operation without third-party resources", func(t *testing.T) {
+		// Setup
+		get := serve(t, true)
+
+		// Execute
+		res, body := get("/docs", nil)
+
+		// Assert
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, res.Header.Get("Content-Security-Policy"), "default-src 'none'")
+		assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+
+		for _, route := range apiRoutes() {
+			assert.Contains(t, body, `id="`+route.id+`"`)
+		}
+		for _, entry := range errorCodes {
+			assert.Contains(t, body, "<code>"+string(entry.code)+"</code>")
+		}
+		for _, kind := range problemTypes {
+			assert.Contains(t, body, `id="problem-`+kind.Kind+`"`)
+		}
+		assert.NotContains(t, body, "<script")
+		assert.NotContains(t, body, "https://")
+	})
+
+	t.Run("revalidates with the etag", func(t *testing.T) {
+		// Setup
+		get := serve(t, true)
+		first, _ := get("/swagger.yaml", nil)
+
+		// Execute
+		res, body := get("/swagger.yaml", http.Header{"If-None-Match": {first.Header.Get("ETag")}})
+
+		// Assert
+		assert.Equal(t, "application/yaml", first.Header.Get("Content-Type"))
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+		assert.Empty(t, body)
+	})
+
+	t.Run("caches versioned assets for good", func(t *testing.T) {
+		// Setup
+		get := serve(t, true)
+		_, page := get("/docs", nil)
+		stylesheet := regexp.MustCompile(`href="(/docs/assets/docs\.css\?v=\w+)"`).FindStringSubmatch(page)
+		require.Len(t, stylesheet, 2)
+
+		// Execute
+		versioned, css := get(stylesheet[1], nil)
+		unversioned, _ := get("/docs/assets/docs.css", nil)
+		missing, _ := get("/docs/assets/index.html", nil)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, versioned.StatusCode)
+		assert.True(t, strings.HasPrefix(versioned.Header.Get("Content-Type"), "text/css"))
+		assert.Contains(t, versioned.Header.Get("Cache-Control"), "immutable")
+		assert.NotEmpty(t, css)
+		assert.Equal(t, "no-cache", unversioned.Header.Get("Cache-Control"))
+		assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+	})
+
+	t.Run("serves nothing when disabled", func(t *testing.T) {
+		// Setup
+		get := serve(t, false)
+
+		// Execute & Assert
+		for _, path := range []string{"/docs", "/swagger.yaml", "/docs/assets/docs.css"} {
+			res, _ := get(path, nil)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode, path)
+		}
+	})
+}
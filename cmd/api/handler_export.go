@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+	"github.com/ucok-man/tcsa/internal/utility"
+)
+
+// exportFlushRows is how many rows are sent to the client at once.
+const exportFlushRows = 500
+
+// exportTransactionHandler streams every transaction matching the filters of
+// GET /transactions as CSV or NDJSON. Rows go straight from a database cursor
+// to the client, however many there are.
+func (app *application) exportTransactionHandler(ctx echo.Context) error {
+	var dto dto.TransactionExportDTO
+
+	// Set Default Value
+	dto.Format = utility.SetPtrValue("csv")
+	dto.Sort.Value = utility.SetPtrValue("id")
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	columns, err := parseExportColumns(utility.DerefOrDefault(dto.Columns, ""))
+	if err != nil {
+		return app.ErrFailedValidation(map[string]string{"columns": err.Error()})
+	}
+
+	param := data.TransactionExportParam{
+		SortColumn:    app.SortColumn(*dto.Sort.Value),
+		SortDirection: app.SortDirection(*dto.Sort.Value),
+		FilterStatus:  utility.DerefOrDefault(dto.Filter.Status, ""),
+		FilterUserId:  utility.DerefOrDefault(dto.Filter.UserId, 0),
+	}
+
+	res := ctx.Response()
+	writer, err := newExportWriter(*dto.Format, columns, res)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed creating export writer", ctx.Request())
+	}
+
+	filename := fmt.Sprintf("transactions-%s.%s", time.Now().UTC().Format("20060102T150405Z"), *dto.Format)
+	res.Header().Set(echo.HeaderContentType, exportContentType(*dto.Format))
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.Header().Set(echo.HeaderCacheControl, "no-store")
+
+	// The write timeout is meant for one response, not for an export of any
+	// size. It is pushed back on every flush instead, so only a client that
+	// stops reading is cut off.
+	rc := http.NewResponseController(res)
+	extendDeadline := func() {
+		if app.config.Server.WriteTimeout > 0 {
+			rc.SetWriteDeadline(time.Now().Add(app.config.Server.WriteTimeout))
+		}
+	}
+
+	flush := func() error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		res.Flush()
+		extendDeadline()
+		return nil
+	}
+
+	rows := 0
+	err = app.models.Transactions.Export(ctx.Request().Context(), param, func(transaction *data.Transaction) error {
+		if rows == 0 {
+			extendDeadline()
+			res.WriteHeader(http.StatusOK)
+		}
+
+		if err := writer.Write(transaction); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+
+	switch {
+	case err != nil && !res.Committed:
+		return app.ErrInternalServer(err, "failed exporting transactions", ctx.Request())
+	case err != nil:
+		// The status is sent already, abort the response so the client can't
+		// take a truncated export for a complete one.
+		app.logger.Errorj(tlog.JSON{"message": "export aborted", "rows": rows, "error": err})
+		panic(http.ErrAbortHandler)
+	}
+
+	if err := flush(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
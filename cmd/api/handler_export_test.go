@@ -0,0 +1,207 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func exportRows(transactions ...*data.Transaction) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(2).(func(*data.Transaction) error)
+		for _, transaction := range transactions {
+			if err := fn(transaction); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestExportTransactionHandler(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	first := &data.Transaction{ID: 1, UserId: 3, Amount: 10000, Status: data.TransactionStatusPending, Version: 1, CreatedAt: createdAt, UpdatedAt: createdAt}
+	second := &data.Transaction{ID: 2, UserId: 3, Amount: 25000, Status: data.TransactionStatusSucces, Version: 2, CreatedAt: createdAt, UpdatedAt: createdAt}
+
+	t.Run("successfully exports csv with every column", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		param := data.TransactionExportParam{SortColumn: "id", SortDirection: "ASC"}
+		mockModel.On("Export", mock.Anything, param, mock.Anything).Run(exportRows(first, second)).Return(nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/transactions/export", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Regexp(t, `^attachment; filename="transactions-\d{8}T\d{6}Z\.csv"$`, rec.Header().Get(echo.HeaderContentDisposition))
+
+		expected := "id,user_id,amount,status,version,created_at,updated_at\n" +
+			"1,3,10000,pending,1,2026-10-01T09:30:00Z,2026-10-01T09:30:00Z\n" +
+			"2,3,25000,success,2,2026-10-01T09:30:00Z,2026-10-01T09:30:00Z\n"
+		assert.Equal(t, expected, rec.Body.String())
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("successfully exports ndjson with chosen columns and filters", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		param := data.TransactionExportParam{SortColumn: "amount", SortDirection: "DESC", FilterStatus: "success", FilterUserId: 3}
+		mockModel.On("Export", mock.Anything, param, mock.Anything).Run(exportRows(second)).Return(nil)
+
+		path := "/transactions/export?format=ndjson&columns=amount,id&status=success&user_id=3&sort_by=-amount"
+		ctx, rec := createTestContext(http.MethodGet, path, "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), ".ndjson")
+		assert.Equal(t, "{\"amount\":25000,\"id\":2}\n", rec.Body.String())
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("writes csv header without rows", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		mockModel.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/transactions/export?columns=id,status", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "id,status\n", rec.Body.String())
+	})
+
+	t.Run("streams more rows than one flush", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		rows := make([]*data.Transaction, exportFlushRows*2+1)
+		for i := range rows {
+			rows[i] = first
+		}
+		mockModel.On("Export", mock.Anything, mock.Anything, mock.Anything).Run(exportRows(rows...)).Return(nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/transactions/export?columns=id", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, len(rows)+1, strings.Count(rec.Body.String(), "\n"))
+	})
+
+	t.Run("returns validation error for unknown column", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/export?columns=id,secret", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		mockModel.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns validation error for invalid format", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/export?format=xlsx", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns error when export fails before any row", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		mockModel.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/export", "")
+
+		// Execute
+		err := app.exportTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("aborts the response when export fails midway", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		mockModel.On("Export", mock.Anything, mock.Anything, mock.Anything).Run(exportRows(first)).Return(assert.AnError)
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/export", "")
+
+		// Execute & Assert
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			app.exportTransactionHandler(ctx)
+		})
+	})
+}
+
+func TestParseExportColumns(t *testing.T) {
+	t.Run("selects every column by default", func(t *testing.T) {
+		columns, err := parseExportColumns("")
+
+		require.NoError(t, err)
+		assert.Len(t, columns, len(exportColumns))
+	})
+
+	t.Run("keeps the given order", func(t *testing.T) {
+		columns, err := parseExportColumns("status, id")
+
+		require.NoError(t, err)
+		require.Len(t, columns, 2)
+		assert.Equal(t, "status", columns[0].name)
+		assert.Equal(t, "id", columns[1].name)
+	})
+
+	t.Run("rejects duplicate column", func(t *testing.T) {
+		_, err := parseExportColumns("id,id")
+
+		assert.Error(t, err)
+	})
+}
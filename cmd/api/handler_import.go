@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/jobs"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+// importTransactionHandler creates transactions from an uploaded CSV file,
+// sent as the "file" field of a multipart form. Files up to
+// IMPORT_ASYNC_THRESHOLD_BYTES are imported right away and answered with the
+// report. Larger files are imported by a background job, the response points
+// to the job to follow, unless the transactions are kept in memory.
+func (app *application) importTransactionHandler(ctx echo.Context) error {
+	maxBytes := app.config.Import.MaxFileBytes
+
+	// Leave room for the multipart framing around the file.
+	req := ctx.Request()
+	req.Body = http.MaxBytesReader(ctx.Response(), req.Body, maxBytes+64<<10)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return app.ErrBadRequest(fmt.Sprintf("file must not be larger than %d bytes", maxBytes))
+		}
+		return app.ErrBadRequest("body must be a multipart form with a CSV file in the field \"file\"")
+	}
+	if fileHeader.Size > maxBytes {
+		return app.ErrBadRequest(fmt.Sprintf("file must not be larger than %d bytes", maxBytes))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed opening uploaded file", req)
+	}
+	defer file.Close()
+
+	// A bad header fails the whole file, report it now rather than from a
+	// job.
+	if _, err := readImportHeader(csv.NewReader(file)); err != nil {
+		return app.ErrFailedValidation(map[string]string{"file": err.Error()})
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return app.ErrInternalServer(err, "failed reading uploaded file", req)
+	}
+
+	// Jobs are kept in the database, without one every file is imported
+	// right away.
+	if fileHeader.Size > app.config.Import.AsyncThresholdBytes && !app.config.inMemory() {
+		return app.startImportJob(ctx, fileHeader, file)
+	}
+
+	importer := transactionImporter{
+		store:    app.models.Transactions,
+		validate: ctx.Echo().Validator.Validate,
+	}
+
+	report, err := importer.run(req.Context(), file, nil)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed importing transactions", req)
+	}
+	for i, row := range report.RejectedRows {
+		report.RejectedRows[i].Errors = app.localize(ctx, row.verrs).ValidationErrorMap
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": report,
+	})
+}
+
+// importJobPayload describes an import job, the file is its input.
+type importJobPayload struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// importJobProgress is what a running import job reports.
+type importJobProgress struct {
+	RowsProcessed int   `json:"rows_processed"`
+	Imported      int   `json:"imported"`
+	Rejected      int   `json:"rejected"`
+	BytesRead     int64 `json:"bytes_read"`
+	TotalBytes    int64 `json:"total_bytes"`
+}
+
+// startImportJob queues the import of the upload as a job. The file is stored
+// with the job, since any instance may run it.
+func (app *application) startImportJob(ctx echo.Context, fileHeader *multipart.FileHeader, file multipart.File) error {
+	input, err := io.ReadAll(file)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed reading uploaded file", ctx.Request())
+	}
+
+	payload := importJobPayload{Filename: fileHeader.Filename, Size: fileHeader.Size}
+	job, err := jobs.New(jobKindTransactionImport, payload, app.config.Jobs.MaxAttempts)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed creating import job", ctx.Request())
+	}
+
+	if err := app.models.Jobs.Insert(job, input); err != nil {
+		return app.ErrInternalServer(err, "failed queueing import job", ctx.Request())
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, jobLocation(job.ID))
+
+	return ctx.JSON(http.StatusAccepted, envelope{
+		"data": newJobResponse(job),
+	})
+}
+
+// runImportJob imports the file of an import job. Every committed chunk is
+// checkpointed, a job run again, after a failure or a shutdown, resumes
+// after the last checkpoint. A chunk committed after it isn't inserted again.
+// Its result is the import report.
+func (app *application) runImportJob(ctx context.Context, job *data.Job, progress jobs.Progress) (any, error) {
+	input, err := app.models.Jobs.GetInput(job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from importCheckpoint
+	if len(job.Checkpoint) > 0 {
+		if err := json.Unmarshal(job.Checkpoint, &from); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid checkpoint: %w", err))
+		}
+	}
+
+	importer := transactionImporter{
+		store:    app.models.Transactions,
+		validate: validator.New().Validate,
+		job:      job.ID,
+		progress: func(checkpoint importCheckpoint) error {
+			return progress(importJobProgress{
+				RowsProcessed: checkpoint.Report.TotalRows,
+				Imported:      checkpoint.Report.Imported,
+				Rejected:      checkpoint.Report.Rejected,
+				BytesRead:     checkpoint.Offset,
+				TotalBytes:    int64(len(input)),
+			}, checkpoint)
+		},
+	}
+
+	report, err := importer.run(ctx, bytes.NewReader(input), &from)
+	if err != nil {
+		var headerErr errImportHeader
+		if errors.As(err, &headerErr) {
+			return nil, jobs.Permanent(err)
+		}
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/jobs"
+	"github.com/ucok-man/tcsa/internal/serializer"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+// createImportContext creates a context for a multipart upload of content as
+// the file field.
+func createImportContext(t *testing.T, content string) (echo.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "transactions.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	e := echo.New()
+	e.JSONSerializer = serializer.New()
+	e.Validator = validator.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/transactions/import", &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func createImportTestApp(t *testing.T, mockModel *data.MockTransactionModel) *application {
+	t.Helper()
+
+	app := createTestApp(t, data.Models{Transactions: mockModel})
+	app.config.Import.MaxFileBytes = 1 << 20
+	app.config.Import.AsyncThresholdBytes = 1 << 20
+	app.config.Jobs.MaxAttempts = 3
+	return app
+}
+
+func TestImportTransactionHandler(t *testing.T) {
+	t.Run("successfully imports valid rows and reports rejected ones", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.MatchedBy(func(txs []*data.Transaction) bool {
+			return len(txs) == 2 && txs[0].UserId == 1 && txs[1].Amount == 300 &&
+				txs[1].Status == data.TransactionStatusPending
+		})).Return(nil)
+
+		content := "\ufeffAmount,note,User_Id\n" +
+			"100,first,1\n" +
+			"abc,second,2\n" +
+			"-5,third,\n" +
+			"300,fourth,3\n"
+		ctx, rec := createImportContext(t, content)
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Data importReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+		report := response.Data
+		assert.Equal(t, 4, report.TotalRows)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, 2, report.Rejected)
+		require.Len(t, report.RejectedRows, 2)
+
+		assert.Equal(t, 3, report.RejectedRows[0].Line)
+		assert.Equal(t, "amount must be an integer", report.RejectedRows[0].Errors["amount"])
+		assert.Equal(t, 4, report.RejectedRows[1].Line)
+		assert.Contains(t, report.RejectedRows[1].Errors, "amount")
+		assert.Contains(t, report.RejectedRows[1].Errors, "user_id")
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("inserts in chunks", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.MatchedBy(func(txs []*data.Transaction) bool {
+			return len(txs) == importChunkRows
+		})).Return(nil).Once()
+		mockModel.On("InsertMany", mock.MatchedBy(func(txs []*data.Transaction) bool {
+			return len(txs) == 1
+		})).Return(nil).Once()
+
+		var content strings.Builder
+		content.WriteString("user_id,amount\n")
+		for i := range importChunkRows + 1 {
+			content.WriteString("1," + strconv.Itoa(i+1) + "\n")
+		}
+		ctx, rec := createImportContext(t, content.String())
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("reports rejected rows in the language of the request", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		ctx, rec := createImportContext(t, "user_id,amount\n1,abc\n-1,100\n")
+		ctx.Request().Header.Set("Accept-Language", "id-ID, en;q=0.5")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"Accept-Language"}, rec.Header().Values(echo.HeaderVary))
+
+		var response struct {
+			Data importReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+		require.Len(t, response.Data.RejectedRows, 2)
+		assert.Equal(t, "amount harus berupa bilangan bulat", response.Data.RejectedRows[0].Errors["amount"])
+		assert.Equal(t, "user_id harus 1 atau lebih besar", response.Data.RejectedRows[1].Errors["user_id"])
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns validation error for missing header column", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		ctx, _ := createImportContext(t, "user_id,total\n1,100\n")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		httpErr := err.(*echo.HTTPError)
+		assert.Equal(t, http.StatusUnprocessableEntity, httpErr.Code)
+		assert.Equal(t, `header is missing column "amount"`, httpErr.Message.(map[string]string)["file"])
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns validation error for empty file", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		ctx, _ := createImportContext(t, "")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns error without multipart file", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/import", `{"user_id": 1}`)
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns error for file over the limit", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+		app.config.Import.MaxFileBytes = 16
+
+		ctx, _ := createImportContext(t, "user_id,amount\n1,100\n2,200\n")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns error when database insert fails", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createImportTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.Anything).Return(assert.AnError)
+
+		ctx, _ := createImportContext(t, "user_id,amount\n1,100\n")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("queues large file as a background job", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+		app.config.Import.AsyncThresholdBytes = 0
+
+		content := "user_id,amount\n1,100\n2,0\n"
+
+		mockJobs.On("Insert", mock.MatchedBy(func(job *data.Job) bool {
+			return job.Kind == jobKindTransactionImport && job.MaxAttempts == 3 &&
+				string(job.Payload) == `{"filename":"transactions.csv","size":25}`
+		}), []byte(content)).Run(func(args mock.Arguments) {
+			job := args.Get(0).(*data.Job)
+			job.ID = 7
+			job.Status = data.JobQueued
+		}).Return(nil)
+
+		ctx, rec := createImportContext(t, content)
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "/jobs/7", rec.Header().Get(echo.HeaderLocation))
+		assert.Contains(t, rec.Body.String(), `"status":"queued"`)
+		assert.Contains(t, rec.Body.String(), `"self":"/jobs/7"`)
+
+		mockJobs.AssertExpectations(t)
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns error when queueing job fails", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+		app.config.Import.AsyncThresholdBytes = 0
+
+		mockJobs.On("Insert", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		ctx, _ := createImportContext(t, "user_id,amount\n1,100\n")
+
+		// Execute
+		err := app.importTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestRunImportJob(t *testing.T) {
+	t.Run("successfully imports and checkpoints every chunk", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+
+		content := "user_id,amount\n1,100\n2,abc\n"
+		mockJobs.On("GetInput", 7).Return([]byte(content), nil)
+		mockModel.On("InsertImported", 7, mock.Anything).Return(nil)
+
+		var progresses []any
+		var checkpoints []any
+		progress := func(progress, checkpoint any) error {
+			progresses = append(progresses, progress)
+			checkpoints = append(checkpoints, checkpoint)
+			return nil
+		}
+
+		// Execute
+		result, err := app.runImportJob(context.Background(), &data.Job{ID: 7}, progress)
+
+		// Assert
+		require.NoError(t, err)
+		report := result.(*importReport)
+		assert.Equal(t, 2, report.TotalRows)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 1, report.Rejected)
+
+		require.Len(t, progresses, 1)
+		assert.Equal(t, importJobProgress{
+			RowsProcessed: 2,
+			Imported:      1,
+			Rejected:      1,
+			BytesRead:     int64(len(content)),
+			TotalBytes:    int64(len(content)),
+		}, progresses[0])
+		assert.Equal(t, int64(len(content)), checkpoints[0].(importCheckpoint).Offset)
+	})
+
+	t.Run("resumes after the checkpoint", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+
+		done := "user_id,amount\n1,100\n"
+		content := done + "2,200\n3,abc\n"
+		mockJobs.On("GetInput", 7).Return([]byte(content), nil)
+		mockModel.On("InsertImported", 7, mock.MatchedBy(func(rows []data.TransactionImportRow) bool {
+			return len(rows) == 1 && rows[0].Line == 3 && rows[0].Transaction.UserId == 2
+		})).Return(nil).Once()
+
+		checkpoint, err := json.Marshal(importCheckpoint{
+			Offset: int64(len(done)),
+			Report: importReport{TotalRows: 1, Imported: 1},
+		})
+		require.NoError(t, err)
+
+		job := &data.Job{ID: 7, Checkpoint: checkpoint}
+		progress := func(progress, checkpoint any) error { return nil }
+
+		// Execute
+		result, err := app.runImportJob(context.Background(), job, progress)
+
+		// Assert
+		require.NoError(t, err)
+		report := result.(*importReport)
+		assert.Equal(t, 3, report.TotalRows)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, 1, report.Rejected)
+		require.Len(t, report.RejectedRows, 1)
+		assert.Equal(t, 4, report.RejectedRows[0].Line)
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("fails for good on a bad header", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+
+		mockJobs.On("GetInput", 7).Return([]byte("user_id\n1\n"), nil)
+		progress := func(progress, checkpoint any) error { return nil }
+
+		// Execute
+		_, err := app.runImportJob(context.Background(), &data.Job{ID: 7}, progress)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, jobs.IsPermanent(err))
+		assert.Contains(t, err.Error(), `header is missing column "amount"`)
+	})
+
+	t.Run("stops after the chunk being imported on shutdown", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+
+		var content strings.Builder
+		content.WriteString("user_id,amount\n")
+		for i := range importChunkRows * 2 {
+			content.WriteString("1," + strconv.Itoa(i+1) + "\n")
+		}
+		mockJobs.On("GetInput", 7).Return([]byte(content.String()), nil)
+		mockModel.On("InsertImported", 7, mock.Anything).Return(nil).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		saved := 0
+		progress := func(progress, checkpoint any) error {
+			saved++
+			cancel()
+			return nil
+		}
+
+		// Execute
+		_, err := app.runImportJob(ctx, &data.Job{ID: 7}, progress)
+
+		// Assert
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, saved)
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("inserts nothing once shut down", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		mockJobs := new(data.MockJobModel)
+		app := createImportTestApp(t, mockModel)
+		app.models.Jobs = mockJobs
+
+		mockJobs.On("GetInput", 7).Return([]byte("user_id,amount\n1,100\n"), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		progress := func(progress, checkpoint any) error { return nil }
+
+		// Execute
+		_, err := app.runImportJob(ctx, &data.Job{ID: 7}, progress)
+
+		// Assert
+		require.ErrorIs(t, err, context.Canceled)
+		mockModel.AssertNotCalled(t, "InsertImported", mock.Anything, mock.Anything)
+	})
+}
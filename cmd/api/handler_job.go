@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func (app *application) getByIdJobHandler(ctx echo.Context) error {
+	var dto dto.JobParamIdDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	job, err := app.models.Jobs.GetById(dto.JobId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return app.ErrNotFound(codeJobNotFound)
+		default:
+			return app.ErrInternalServer(err, "failed getting job", ctx.Request())
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": newJobResponse(job),
+	})
+}
+
+// getResultJobHandler returns the result of a completed job. A job that is
+// not completed has no result and is answered as not found.
+func (app *application) getResultJobHandler(ctx echo.Context) error {
+	var dto dto.JobParamIdDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	job, err := app.models.Jobs.GetById(dto.JobId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return app.ErrNotFound(codeJobNotFound)
+		default:
+			return app.ErrInternalServer(err, "failed getting job", ctx.Request())
+		}
+	}
+
+	if job.Status != data.JobCompleted {
+		return app.ErrNotFound(codeJobResultNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": job.Result,
+	})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func TestGetByIdJobHandler(t *testing.T) {
+	t.Run("successfully gets running job with its progress", func(t *testing.T) {
+		// Setup
+		mockJobs := new(data.MockJobModel)
+		app := createTestApp(t, data.Models{Jobs: mockJobs})
+
+		startedAt := time.Now()
+		mockJobs.On("GetById", 7).Return(&data.Job{
+			ID:          7,
+			Kind:        jobKindTransactionImport,
+			Status:      data.JobRunning,
+			Payload:     json.RawMessage(`{"filename":"transactions.csv","size":2048}`),
+			Progress:    json.RawMessage(`{"rows_processed":1000}`),
+			Attempts:    1,
+			MaxAttempts: 5,
+			StartedAt:   &startedAt,
+		}, nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/jobs/7", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("7")
+
+		// Execute
+		err := app.getByIdJobHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Data struct {
+				Status   string            `json:"status"`
+				Progress map[string]int    `json:"progress"`
+				Links    map[string]string `json:"links"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+		assert.Equal(t, "running", response.Data.Status)
+		assert.Equal(t, 1000, response.Data.Progress["rows_processed"])
+		assert.Equal(t, map[string]string{"self": "/jobs/7"}, response.Data.Links)
+	})
+
+	t.Run("links the result of completed job", func(t *testing.T) {
+		// Setup
+		mockJobs := new(data.MockJobModel)
+		app := createTestApp(t, data.Models{Jobs: mockJobs})
+
+		mockJobs.On("GetById", 7).Return(&data.Job{ID: 7, Status: data.JobCompleted}, nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/jobs/7", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("7")
+
+		// Execute
+		err := app.getByIdJobHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"result":"/jobs/7/result"`)
+	})
+
+	t.Run("returns 404 when job not found", func(t *testing.T) {
+		// Setup
+		mockJobs := new(data.MockJobModel)
+		app := createTestApp(t, data.Models{Jobs: mockJobs})
+
+		mockJobs.On("GetById", 7).Return(nil, data.ErrRecordNotFound)
+
+		ctx, _ := createTestContext(http.MethodGet, "/jobs/7", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("7")
+
+		// Execute
+		err := app.getByIdJobHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for invalid id", func(t *testing.T) {
+		// Setup
+		app := createTestApp(t, data.Models{})
+
+		ctx, _ := createTestContext(http.MethodGet, "/jobs/0", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("0")
+
+		// Execute
+		err := app.getByIdJobHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestGetResultJobHandler(t *testing.T) {
+	t.Run("successfully gets result of completed job", func(t *testing.T) {
+		// Setup
+		mockJobs := new(data.MockJobModel)
+		app := createTestApp(t, data.Models{Jobs: mockJobs})
+
+		mockJobs.On("GetById", 7).Return(&data.Job{
+			ID:     7,
+			Status: data.JobCompleted,
+			Result: json.RawMessage(`{"imported":2}`),
+		}, nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/jobs/7/result", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("7")
+
+		// Execute
+		err := app.getResultJobHandler(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"imported":2}}`, rec.Body.String())
+	})
+
+	t.Run("returns 404 when job is not completed", func(t *testing.T) {
+		// Setup
+		mockJobs := new(data.MockJobModel)
+		app := createTestApp(t, data.Models{Jobs: mockJobs})
+
+		mockJobs.On("GetById", 7).Return(&data.Job{ID: 7, Status: data.JobRunning}, nil)
+
+		ctx, _ := createTestContext(http.MethodGet, "/jobs/7/result", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("7")
+
+		// Execute
+		err := app.getResultJobHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+	})
+}
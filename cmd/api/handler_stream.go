@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+	"github.com/ucok-man/tcsa/internal/utility"
+)
+
+const (
+	// streamReplayPageSize is how many missed events are loaded at once when
+	// a client resumes with Last-Event-ID.
+	streamReplayPageSize = 500
+	// streamResumeLookback is how many outbox IDs before Last-Event-ID are
+	// read again on resume. IDs are assigned when a change is written but
+	// show up when it commits, so a message below the last one sent may have
+	// committed after it.
+	streamResumeLookback = 1000
+	// streamReplayMaxRows bounds the events replayed by one stream. A client
+	// further behind is disconnected after them and resumes from there.
+	streamReplayMaxRows = 10000
+	// streamReplayMaxAge is the age of the oldest event replayed.
+	streamReplayMaxAge = 24 * time.Hour
+	// streamRetry is the reconnect delay suggested to clients, in
+	// milliseconds.
+	streamRetry = 3000
+)
+
+// streamTransactionHandler sends transaction events as Server-Sent Events.
+// The SSE id is the outbox ID, so a client reconnecting with Last-Event-ID
+// first gets the events it missed, as long as they are still in the outbox.
+// The events of the lookback before it are sent again, clients skip the IDs
+// they got already. Clients falling behind are disconnected and resume the
+// same way.
+func (app *application) streamTransactionHandler(ctx echo.Context) error {
+	var dto dto.TransactionStreamDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	lastEventId := -1
+	if header := ctx.Request().Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil || id < 0 {
+			return app.ErrBadRequest("Last-Event-ID must be a non-negative integer")
+		}
+		lastEventId = id
+	}
+
+	// Subscribe before replaying so nothing is lost in between, replayed
+	// events are skipped once they arrive live.
+	sub, err := app.broker.Subscribe(app.config.Stream.BufferSize)
+	if err != nil {
+		return app.ErrServiceUnavailable("the server is shutting down, please reconnect")
+	}
+	defer sub.Close()
+
+	filter := transactionStreamFilter{
+		status: utility.DerefOrDefault(dto.Filter.Status, ""),
+		userId: utility.DerefOrDefault(dto.Filter.UserId, 0),
+	}
+	replayed := newReplayedIds()
+
+	var replay []*data.OutboxMessage
+	replaySince := time.Now().Add(-streamReplayMaxAge)
+	if lastEventId >= 0 {
+		replay, err = app.models.Outbox.GetAfterSince(max(lastEventId-streamResumeLookback, 0), replaySince, streamReplayPageSize)
+		if err != nil {
+			return app.ErrInternalServer(err, "failed loading missed events", ctx.Request())
+		}
+	}
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	// Streams outlive the server write timeout. Not every writer supports
+	// deadlines, the stream works without it.
+	http.NewResponseController(res).SetWriteDeadline(time.Time{})
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", streamRetry); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	for sent := 0; len(replay) > 0; {
+		for _, message := range replay {
+			replayed.add(message.ID)
+			if err := writeStreamEvent(res, message, filter); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+
+		sent += len(replay)
+		last := replay[len(replay)-1].ID
+		if len(replay) < streamReplayPageSize {
+			break
+		}
+		if sent >= streamReplayMaxRows {
+			// An id without data moves Last-Event-ID past the events the
+			// filter skipped, the client resumes from there.
+			fmt.Fprintf(res, "id: %d\n\n", last)
+			res.Flush()
+			return nil
+		}
+
+		replay, err = app.models.Outbox.GetAfterSince(last, replaySince, streamReplayPageSize)
+		if err != nil {
+			// The client resumes from the last event it got.
+			app.logger.Errorj(tlog.JSON{"message": "failed loading missed events", "error": err})
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(app.config.Stream.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+
+		case message, ok := <-sub.C():
+			if !ok {
+				if sub.Evicted() {
+					app.logger.Infoj(tlog.JSON{"message": "disconnected lagging event stream", "remote_addr": ctx.RealIP()})
+				}
+				return nil
+			}
+			if replayed.has(message.ID) {
+				continue
+			}
+			replayed.forget(message.ID - streamResumeLookback)
+			if err := writeStreamEvent(res, message, filter); err != nil {
+				return nil
+			}
+			res.Flush()
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// replayedIds are the IDs of the replayed events, so the stream skips them
+// when they arrive live as well.
+type replayedIds struct {
+	set map[int]struct{}
+	// order holds the IDs of set ascending, as they are replayed.
+	order []int
+}
+
+func newReplayedIds() *replayedIds {
+	return &replayedIds{set: map[int]struct{}{}}
+}
+
+func (r *replayedIds) add(id int) {
+	r.set[id] = struct{}{}
+	r.order = append(r.order, id)
+}
+
+func (r *replayedIds) has(id int) bool {
+	_, ok := r.set[id]
+	return ok
+}
+
+// forget drops the IDs up to watermark, which are too far behind a live
+// message to arrive live anymore.
+func (r *replayedIds) forget(watermark int) {
+	for len(r.order) > 0 && r.order[0] <= watermark {
+		delete(r.set, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+type transactionStreamFilter struct {
+	status string
+	userId int
+}
+
+func (f transactionStreamFilter) match(event data.Event) bool {
+	if f.status == "" && f.userId == 0 {
+		return true
+	}
+
+	var eventData data.TransactionEventData
+	if err := json.Unmarshal(event.Data, &eventData); err != nil || eventData.Transaction == nil {
+		return false
+	}
+
+	if f.status != "" && string(eventData.Transaction.Status) != f.status {
+		return false
+	}
+	if f.userId != 0 && eventData.Transaction.UserId != f.userId {
+		return false
+	}
+	return true
+}
+
+// writeStreamEvent writes message in SSE format if it passes filter.
+func writeStreamEvent(w io.Writer, message *data.OutboxMessage, filter transactionStreamFilter) error {
+	if !filter.match(message.Event) {
+		return nil
+	}
+
+	payload, err := json.Marshal(message.Event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", message.ID, message.Event.Type, payload)
+	return err
+}
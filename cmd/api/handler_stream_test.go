@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// streamRecorder is a ResponseRecorder whose body can be read while the
+// handler is still writing.
+type streamRecorder struct {
+	mu sync.Mutex
+	*httptest.ResponseRecorder
+}
+
+func (r *streamRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(b)
+}
+
+func (r *streamRecorder) Flush() {}
+
+func (r *streamRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Body.String()
+}
+
+func testOutboxMessage(t *testing.T, id int, eventType data.EventType, userId int, status data.TransactionStatus) *data.OutboxMessage {
+	t.Helper()
+
+	event, err := data.NewEvent(eventType, data.TransactionEventData{
+		Transaction: &data.Transaction{ID: id, UserId: userId, Amount: 10000, Status: status},
+	})
+	require.NoError(t, err)
+
+	return &data.OutboxMessage{ID: id, Event: event}
+}
+
+// startStream runs the stream handler for path until the returned function
+// disconnects the client, which returns the handler error.
+func startStream(t *testing.T, app *application, path string, lastEventId string) (*streamRecorder, func() error) {
+	t.Helper()
+
+	ctx, _ := createTestContext(http.MethodGet, path, "")
+	if lastEventId != "" {
+		ctx.Request().Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	reqCtx, cancel := context.WithCancel(ctx.Request().Context())
+	ctx.SetRequest(ctx.Request().WithContext(reqCtx))
+
+	rec := &streamRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx.Response().Writer = rec
+
+	done := make(chan error, 1)
+	go func() { done <- app.streamTransactionHandler(ctx) }()
+
+	return rec, func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream handler did not return")
+			return nil
+		}
+	}
+}
+
+func createStreamTestApp(t *testing.T, outbox *data.MockOutboxModel) *application {
+	t.Helper()
+
+	app := createTestApp(t, data.Models{Outbox: outbox})
+	app.config.Stream.BufferSize = 8
+	app.config.Stream.HeartbeatInterval = time.Hour
+	return app
+}
+
+func TestStreamTransactionHandler(t *testing.T) {
+	t.Run("streams live events", func(t *testing.T) {
+		// Setup
+		app := createStreamTestApp(t, new(data.MockOutboxModel))
+
+		// Execute
+		rec, stop := startStream(t, app, "/transactions/stream", "")
+		require.Eventually(t, func() bool { return app.broker.Len() == 1 }, time.Second, time.Millisecond)
+
+		app.broker.Publish(testOutboxMessage(t, 7, data.EventTransactionCreated, 1, data.TransactionStatusPending))
+
+		// Assert
+		require.Eventually(t, func() bool { return strings.Contains(rec.String(), "id: 7\n") }, time.Second, time.Millisecond)
+		assert.NoError(t, stop())
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+		assert.True(t, strings.HasPrefix(rec.String(), "retry: 3000\n\n"))
+		assert.Contains(t, rec.String(), "id: 7\nevent: transaction.created\ndata: {\"id\":")
+		assert.Zero(t, app.broker.Len(), "subscription is released")
+	})
+
+	t.Run("filters by user_id and status", func(t *testing.T) {
+		// Setup
+		app := createStreamTestApp(t, new(data.MockOutboxModel))
+
+		// Execute
+		rec, stop := startStream(t, app, "/transactions/stream?user_id=2&status=success", "")
+		require.Eventually(t, func() bool { return app.broker.Len() == 1 }, time.Second, time.Millisecond)
+
+		app.broker.Publish(testOutboxMessage(t, 1, data.EventTransactionUpdated, 1, data.TransactionStatusSucces))
+		app.broker.Publish(testOutboxMessage(t, 2, data.EventTransactionUpdated, 2, data.TransactionStatusPending))
+		app.broker.Publish(testOutboxMessage(t, 3, data.EventTransactionUpdated, 2, data.TransactionStatusSucces))
+
+		// Assert
+		require.Eventually(t, func() bool { return strings.Contains(rec.String(), "id: 3\n") }, time.Second, time.Millisecond)
+		assert.NoError(t, stop())
+
+		assert.NotContains(t, rec.String(), "id: 1\n")
+		assert.NotContains(t, rec.String(), "id: 2\n")
+	})
+
+	t.Run("replays missed events after Last-Event-ID", func(t *testing.T) {
+		// Setup
+		outbox := new(data.MockOutboxModel)
+		app := createStreamTestApp(t, outbox)
+
+		missed := testOutboxMessage(t, 11, data.EventTransactionDeleted, 1, data.TransactionStatusFailed)
+		since := mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since).Round(time.Minute) == streamReplayMaxAge
+		})
+		outbox.On("GetAfterSince", 10, since, streamReplayPageSize).Return([]*data.OutboxMessage{missed}, nil)
+
+		// Execute
+		rec, stop := startStream(t, app, "/transactions/stream", strconv.Itoa(10+streamResumeLookback))
+		require.Eventually(t, func() bool { return app.broker.Len() == 1 }, time.Second, time.Millisecond)
+
+		// Arrives live as well, it must not be sent twice.
+		app.broker.Publish(missed)
+		app.broker.Publish(testOutboxMessage(t, 12, data.EventTransactionCreated, 1, data.TransactionStatusPending))
+
+		// Assert
+		require.Eventually(t, func() bool { return strings.Contains(rec.String(), "id: 12\n") }, time.Second, time.Millisecond)
+		assert.NoError(t, stop())
+
+		assert.Equal(t, 1, strings.Count(rec.String(), "id: 11\n"))
+		assert.Less(t, strings.Index(rec.String(), "id: 11\n"), strings.Index(rec.String(), "id: 12\n"))
+		outbox.AssertExpectations(t)
+	})
+
+	t.Run("replays at most streamReplayMaxRows events per stream", func(t *testing.T) {
+		// Setup
+		outbox := new(data.MockOutboxModel)
+		app := createStreamTestApp(t, outbox)
+
+		page := make([]*data.OutboxMessage, streamReplayPageSize)
+		for i := range page {
+			page[i] = testOutboxMessage(t, i+1, data.EventTransactionCreated, 1, data.TransactionStatusPending)
+		}
+		outbox.On("GetAfterSince", mock.Anything, mock.Anything, streamReplayPageSize).Return(page, nil)
+
+		// Execute
+		rec, stop := startStream(t, app, "/transactions/stream?user_id=2", "0")
+
+		// Assert
+		require.Eventually(t, func() bool { return app.broker.Len() == 0 && rec.String() != "" }, time.Second, time.Millisecond)
+		assert.NoError(t, stop())
+
+		assert.True(t, strings.HasSuffix(rec.String(), fmt.Sprintf("\n\nid: %d\n\n", streamReplayPageSize)))
+		outbox.AssertNumberOfCalls(t, "GetAfterSince", streamReplayMaxRows/streamReplayPageSize)
+	})
+
+	t.Run("ends lagging stream", func(t *testing.T) {
+		// Setup
+		app := createStreamTestApp(t, new(data.MockOutboxModel))
+
+		// Execute
+		_, stop := startStream(t, app, "/transactions/stream", "")
+		require.Eventually(t, func() bool { return app.broker.Len() == 1 }, time.Second, time.Millisecond)
+
+		app.broker.Reset()
+
+		// Assert
+		require.Eventually(t, func() bool { return app.broker.Len() == 0 }, time.Second, time.Millisecond)
+		assert.NoError(t, stop())
+	})
+
+	t.Run("rejects malformed Last-Event-ID", func(t *testing.T) {
+		// Setup
+		app := createStreamTestApp(t, new(data.MockOutboxModel))
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/stream", "")
+		ctx.Request().Header.Set("Last-Event-ID", "abc")
+
+		// Execute
+		err := app.streamTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		assert.Zero(t, app.broker.Len())
+	})
+
+	t.Run("refuses new streams while shutting down", func(t *testing.T) {
+		// Setup
+		app := createStreamTestApp(t, new(data.MockOutboxModel))
+		app.broker.Close()
+
+		ctx, _ := createTestContext(http.MethodGet, "/transactions/stream", "")
+
+		// Execute
+		err := app.streamTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, err.(*echo.HTTPError).Code)
+	})
+}
+
+func TestReplayedIds(t *testing.T) {
+	t.Run("forgets the IDs up to the watermark", func(t *testing.T) {
+		// Setup
+		replayed := newReplayedIds()
+		for _, id := range []int{3, 5, 8} {
+			replayed.add(id)
+		}
+
+		// Execute
+		replayed.forget(5)
+
+		// Assert
+		assert.False(t, replayed.has(3))
+		assert.False(t, replayed.has(5))
+		assert.True(t, replayed.has(8))
+		assert.Equal(t, []int{8}, replayed.order)
+	})
+}
@@ -1,54 +0,0 @@
-package main
-
-import (
-	"embed"
-	"net/http"
-
-	"github.com/labstack/echo/v4"
-)
-
-//go:embed docs/swagger.yaml
-var swaggerFile embed.FS
-
-const swaggerUIHTML = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>API Documentation - Transaction Management</title>
-    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.10.5/swagger-ui.css">
-    <style>
-        body { margin: 0; padding: 0; }
-        .swagger-ui .topbar { display: none; }
-    </style>
-</head>
-<body>
-    <div id="swagger-ui"></div>
-    <script src="https://unpkg.com/swagger-ui-dist@5.10.5/swagger-ui-bundle.js"></script>
-    <script src="https://unpkg.com/swagger-ui-dist@5.10.5/swagger-ui-standalone-preset.js"></script>
-    <script>
-        window.onload = function() {
-            SwaggerUIBundle({
-                url: '/swagger.yaml',
-                dom_id: '#swagger-ui',
-                deepLinking: true,
-                presets: [
-                    SwaggerUIBundle.presets.apis,
-                    SwaggerUIStandalonePreset
-                ],
-                plugins: [SwaggerUIBundle.plugins.DownloadUrl],
-                layout: "StandaloneLayout",
-                defaultModelsExpandDepth: 1,
-                defaultModelExpandDepth: 1,
-                docExpansion: "list",
-                filter: true,
-                tryItOutEnabled: false
-            });
-        };
-    </script>
-</body>
-</html>`
-
-func (app *application) serveSwaggerUI(c echo.Context) error {
-	return c.HTML(http.StatusOK, swaggerUIHTML)
-}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/ucok-man/tcsa/cmd/api/dto"
 	"github.com/ucok-man/tcsa/internal/data"
 	"github.com/ucok-man/tcsa/internal/utility"
+	"github.com/ucok-man/tcsa/internal/validator"
 )
 
 func (app *application) createTransactionHandler(ctx echo.Context) error {
@@ -41,6 +44,232 @@ func (app *application) createTransactionHandler(ctx echo.Context) error {
 	})
 }
 
+// batchItemResult is the outcome of one item of a partial batch.
+type batchItemResult struct {
+	Index       int                             `json:"index"`
+	Status      string                          `json:"status"`
+	Transaction *data.Transaction               `json:"transaction,omitempty"`
+	Errors      validator.ValidationErrorMap    `json:"errors,omitempty"`
+	Fields      map[string]validator.FieldError `json:"fields,omitempty"`
+}
+
+// createBatchTransactionHandler creates up to BATCH_MAX_ITEMS transactions. In
+// atomic mode, the default, an invalid item fails the whole request and
+// nothing is created. In partial mode the valid items are created, and the
+// result of every item is reported by index.
+func (app *application) createBatchTransactionHandler(ctx echo.Context) error {
+	var dto dto.TransactionBatchCreateDTO
+
+	// Set Default Value
+	dto.Mode = utility.SetPtrValue("atomic")
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if len(dto.Items) > app.config.Batch.MaxItems {
+		return app.ErrFailedValidation(map[string]string{
+			"items": fmt.Sprintf("items must contain at most %d items", app.config.Batch.MaxItems),
+		})
+	}
+
+	itemErrors := make(map[int]validator.ValidationErrors)
+	for i := range dto.Items {
+		if err := ctx.Validate(&dto.Items[i]); err != nil {
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				return app.ErrInternalServer(err, "failed validating batch item", ctx.Request())
+			}
+			itemErrors[i] = verrs
+		}
+	}
+
+	if *dto.Mode == "atomic" && len(itemErrors) > 0 {
+		// Keyed like nested fields, e.g. items[3].amount.
+		merged := validator.ValidationErrors{
+			ValidationErrorMap: validator.ValidationErrorMap{},
+			Codes:              map[string]validator.FieldCode{},
+			Rules:              map[string]validator.Translatable{},
+		}
+		for i, verrs := range itemErrors {
+			for key, message := range verrs.ValidationErrorMap {
+				_, field, _ := strings.Cut(key, ".")
+				nested := fmt.Sprintf("TransactionBatchCreateDTO.items[%d].%s", i, field)
+				merged.ValidationErrorMap[nested] = message
+				merged.Codes[nested] = verrs.Codes[key]
+				merged.Rules[nested] = verrs.Rules[key]
+			}
+		}
+		return app.ErrFailedValidation(merged)
+	}
+
+	transactions := make([]*data.Transaction, 0, len(dto.Items))
+	for i, item := range dto.Items {
+		if _, invalid := itemErrors[i]; invalid {
+			continue
+		}
+		transactions = append(transactions, &data.Transaction{
+			UserId: item.UserId,
+			Amount: item.Amount,
+			Status: data.TransactionStatusPending,
+		})
+	}
+
+	if len(transactions) > 0 {
+		err := app.models.Transactions.InsertMany(transactions)
+		if err != nil {
+			return app.ErrInternalServer(err, "failed insert transactions", ctx.Request())
+		}
+	}
+
+	if *dto.Mode == "atomic" {
+		return ctx.JSON(http.StatusCreated, envelope{
+			"data": transactions,
+		})
+	}
+
+	results := make([]batchItemResult, len(dto.Items))
+	created := 0
+	for i := range dto.Items {
+		results[i].Index = i
+		if verrs, invalid := itemErrors[i]; invalid {
+			verrs = app.localize(ctx, verrs)
+			results[i].Status = "failed"
+			results[i].Errors = verrs.ValidationErrorMap
+			results[i].Fields = verrs.Fields()
+			continue
+		}
+		results[i].Status = "created"
+		results[i].Transaction = transactions[created]
+		created++
+	}
+
+	return ctx.JSON(http.StatusMultiStatus, envelope{
+		"data": results,
+		"metadata": envelope{
+			"created": created,
+			"failed":  len(itemErrors),
+		},
+	})
+}
+
+// bulkUpdateTransactionHandler changes the status of many transactions at
+// once, selected by id or by filter. Transactions whose status can't change
+// are left untouched, those selected by id are reported as conflicts.
+func (app *application) bulkUpdateTransactionHandler(ctx echo.Context) error {
+	var dto dto.TransactionBulkUpdateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if len(dto.Items) > app.config.Batch.MaxItems {
+		return app.ErrFailedValidation(map[string]string{
+			"items": fmt.Sprintf("items must contain at most %d items", app.config.Batch.MaxItems),
+		})
+	}
+
+	param := app.bulkParam(dto.Items, dto.Filter, dto.DryRun)
+	result, err := app.models.Transactions.BulkUpdateStatus(param, data.TransactionStatus(dto.Status))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrBulkLimitExceeded):
+			return app.errBulkLimitExceeded()
+		default:
+			return app.ErrInternalServer(err, "failed bulk update transactions", ctx.Request())
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, app.bulkResponse(result, dto.DryRun))
+}
+
+// bulkDeleteTransactionHandler deletes many transactions at once, selected by
+// id or by filter.
+func (app *application) bulkDeleteTransactionHandler(ctx echo.Context) error {
+	var dto dto.TransactionBulkDeleteDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if len(dto.Items) > app.config.Batch.MaxItems {
+		return app.ErrFailedValidation(map[string]string{
+			"items": fmt.Sprintf("items must contain at most %d items", app.config.Batch.MaxItems),
+		})
+	}
+
+	result, err := app.models.Transactions.BulkDelete(app.bulkParam(dto.Items, dto.Filter, dto.DryRun))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrBulkLimitExceeded):
+			return app.errBulkLimitExceeded()
+		default:
+			return app.ErrInternalServer(err, "failed bulk delete transactions", ctx.Request())
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, app.bulkResponse(result, dto.DryRun))
+}
+
+// bulkParam selects the transactions of a bulk operation. A filter may select
+// at most BATCH_MAX_ITEMS transactions, like items.
+func (app *application) bulkParam(items []dto.TransactionBulkItemDTO, filter *dto.TransactionBulkFilterDTO, dryRun bool) data.TransactionBulkParam {
+	param := data.TransactionBulkParam{DryRun: dryRun, Limit: app.config.Batch.MaxItems}
+
+	for _, item := range items {
+		param.Targets = append(param.Targets, data.TransactionBulkTarget{
+			ID:      item.ID,
+			Version: utility.DerefOrDefault(item.Version, 0),
+		})
+	}
+
+	if filter != nil {
+		param.FilterStatus = utility.DerefOrDefault(filter.Status, "")
+		param.FilterUserId = utility.DerefOrDefault(filter.UserId, 0)
+	}
+	return param
+}
+
+// errBulkLimitExceeded reports a filter selecting more transactions than a
+// bulk operation may change at once.
+func (app *application) errBulkLimitExceeded() error {
+	return app.ErrFailedValidation(map[string]string{
+		"filter": fmt.Sprintf("filter must select at most %d transactions, narrow it or select by items", app.config.Batch.MaxItems),
+	})
+}
+
+func (app *application) bulkResponse(result *data.TransactionBulkResult, dryRun bool) envelope {
+	body := envelope{"conflicts": result.Conflicts}
+	if !dryRun {
+		transactions := result.Transactions
+		if transactions == nil {
+			transactions = []*data.Transaction{}
+		}
+		body["transactions"] = transactions
+	}
+
+	return envelope{
+		"data": body,
+		"metadata": envelope{
+			"affected":  result.Affected,
+			"conflicts": len(result.Conflicts),
+			"dry_run":   dryRun,
+		},
+	}
+}
+
 func (app *application) getByIdTransactionHandler(ctx echo.Context) error {
 	var dto dto.TransactionParamIdDTO
 
@@ -56,7 +285,7 @@ func (app *application) getByIdTransactionHandler(ctx echo.Context) error {
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			return app.ErrNotFound()
+			return app.ErrNotFound(codeTransactionNotFound)
 		default:
 			return app.ErrInternalServer(err, "failed to get transaction by id", ctx.Request())
 		}
@@ -82,7 +311,7 @@ func (app *application) removeByIdTransactionHandler(ctx echo.Context) error {
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			return app.ErrNotFound()
+			return app.ErrNotFound(codeTransactionNotFound)
 		default:
 			return app.ErrInternalServer(err, "failed to get transaction by id", ctx.Request())
 		}
@@ -113,7 +342,7 @@ func (app *application) updateByIdTransactionHandler(ctx echo.Context) error {
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			return app.ErrNotFound()
+			return app.ErrNotFound(codeTransactionNotFound)
 		default:
 			return app.ErrInternalServer(err, "failed to get transaction by id", ctx.Request())
 		}
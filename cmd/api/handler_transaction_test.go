@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -166,6 +167,196 @@ func TestCreateTransactionHandler(t *testing.T) {
 	})
 }
 
+func TestCreateBatchTransactionHandler(t *testing.T) {
+	insertMany := func(args mock.Arguments) {
+		for i, tx := range args.Get(0).([]*data.Transaction) {
+			tx.ID = i + 1
+			tx.Version = 1
+			tx.CreatedAt = time.Now()
+			tx.UpdatedAt = time.Now()
+		}
+	}
+
+	createBatchTestApp := func(t *testing.T, mockModel *data.MockTransactionModel) *application {
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Batch.MaxItems = 3
+		return app
+	}
+
+	t.Run("successfully creates all items in atomic mode", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.MatchedBy(func(txs []*data.Transaction) bool {
+			return len(txs) == 2 && txs[0].Status == data.TransactionStatusPending
+		})).Run(insertMany).Return(nil)
+
+		body := `{"items": [{"user_id": 1, "amount": 10000}, {"user_id": 2, "amount": 20000}]}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		txs := response["data"].([]interface{})
+		require.Len(t, txs, 2)
+		assert.Equal(t, float64(2), txs[1].(map[string]interface{})["user_id"])
+		assert.Equal(t, float64(2), txs[1].(map[string]interface{})["id"])
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns per item validation errors in atomic mode", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		body := `{"items": [{"user_id": 1, "amount": 10000}, {"user_id": 2, "amount": -5}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		httpErr := err.(*echo.HTTPError)
+		assert.Equal(t, http.StatusUnprocessableEntity, httpErr.Code)
+
+		payload, err := json.Marshal(httpErr.Message)
+		require.NoError(t, err)
+		assert.Contains(t, string(payload), `"items[1].amount"`)
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("creates valid items and reports failures in partial mode", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.MatchedBy(func(txs []*data.Transaction) bool {
+			return len(txs) == 2 && txs[0].UserId == 1 && txs[1].UserId == 3
+		})).Run(insertMany).Return(nil)
+
+		body := `{"mode": "partial", "items": [{"user_id": 1, "amount": 10000}, {"amount": 5}, {"user_id": 3, "amount": 30000}]}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		results := response["data"].([]interface{})
+		require.Len(t, results, 3)
+		assert.Equal(t, "created", results[0].(map[string]interface{})["status"])
+		assert.Equal(t, "failed", results[1].(map[string]interface{})["status"])
+		assert.Contains(t, results[1].(map[string]interface{})["errors"], "user_id")
+		assert.Equal(t, float64(3), results[2].(map[string]interface{})["transaction"].(map[string]interface{})["user_id"])
+
+		metadata := response["metadata"].(map[string]interface{})
+		assert.Equal(t, float64(2), metadata["created"])
+		assert.Equal(t, float64(1), metadata["failed"])
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("skips insert when every item fails in partial mode", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		body := `{"mode": "partial", "items": [{"amount": 5}]}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns validation error for too many items", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		body := `{"items": [{"user_id": 1, "amount": 1}, {"user_id": 1, "amount": 1}, {"user_id": 1, "amount": 1}, {"user_id": 1, "amount": 1}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		mockModel.AssertNotCalled(t, "InsertMany", mock.Anything)
+	})
+
+	t.Run("returns validation error for empty items", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/batch", `{"items": []}`)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for invalid mode", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		body := `{"mode": "eventually", "items": [{"user_id": 1, "amount": 1}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns error when database insert fails", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBatchTestApp(t, mockModel)
+
+		mockModel.On("InsertMany", mock.Anything).Return(assert.AnError)
+
+		body := `{"items": [{"user_id": 1, "amount": 10000}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/batch", body)
+
+		// Execute
+		err := app.createBatchTransactionHandler(ctx)
+
+		// Assert
+		assert.Error(t, err)
+		mockModel.AssertExpectations(t)
+	})
+}
+
 func TestGetByIdTransactionHandler(t *testing.T) {
 	t.Run("successfully gets transaction by id", func(t *testing.T) {
 		// Setup
@@ -497,6 +688,36 @@ func TestUpdateByIdTransactionHandler(t *testing.T) {
 		mockModel.AssertNotCalled(t, "Update")
 	})
 
+	t.Run("successfully updates status of a settled transaction", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+
+		mockModel.On("GetById", 1).Return(&data.Transaction{
+			ID:      1,
+			UserId:  1,
+			Amount:  10000,
+			Status:  data.TransactionStatusSucces,
+			Version: 1,
+		}, nil)
+		mockModel.On("Update", mock.MatchedBy(func(tx *data.Transaction) bool {
+			return tx.Status == data.TransactionStatusFailed
+		})).Return(nil)
+
+		body := `{"status": "failed"}`
+		ctx, rec := createTestContext(http.MethodPut, "/transactions/1", body)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("1")
+
+		// Execute
+		err := app.updateByIdTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockModel.AssertExpectations(t)
+	})
+
 	t.Run("returns error for malformed JSON", func(t *testing.T) {
 		// Setup
 		mockModel := new(data.MockTransactionModel)
@@ -675,6 +896,299 @@ func TestDeleteTransactionHandler(t *testing.T) {
 	})
 }
 
+func TestBulkUpdateTransactionHandler(t *testing.T) {
+	createBulkTestApp := func(t *testing.T, mockModel *data.MockTransactionModel) *application {
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Batch.MaxItems = 3
+		return app
+	}
+
+	t.Run("successfully updates by id and reports conflicts", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		param := data.TransactionBulkParam{
+			Targets: []data.TransactionBulkTarget{{ID: 1, Version: 2}, {ID: 2}},
+			Limit:   3,
+		}
+		mockModel.On("BulkUpdateStatus", param, data.TransactionStatusSucces).Return(&data.TransactionBulkResult{
+			Affected: 1,
+			Transactions: []*data.Transaction{
+				{ID: 1, UserId: 1, Amount: 10000, Status: data.TransactionStatusSucces, Version: 3},
+			},
+			Conflicts: []data.TransactionBulkConflict{
+				{ID: 2, Reason: data.BulkConflictInvalidTransition, Version: 1, Status: data.TransactionStatusFailed},
+			},
+		}, nil)
+
+		body := `{"status": "success", "items": [{"id": 1, "version": 2}, {"id": 2}]}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		result := response["data"].(map[string]interface{})
+		txs := result["transactions"].([]interface{})
+		require.Len(t, txs, 1)
+		assert.Equal(t, float64(3), txs[0].(map[string]interface{})["version"])
+
+		conflicts := result["conflicts"].([]interface{})
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "invalid_transition", conflicts[0].(map[string]interface{})["reason"])
+		assert.Equal(t, "failed", conflicts[0].(map[string]interface{})["current_status"])
+
+		metadata := response["metadata"].(map[string]interface{})
+		assert.Equal(t, float64(1), metadata["affected"])
+		assert.Equal(t, float64(1), metadata["conflicts"])
+		assert.Equal(t, false, metadata["dry_run"])
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns count only on filter dry run", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		param := data.TransactionBulkParam{FilterStatus: "pending", FilterUserId: 5, DryRun: true, Limit: 3}
+		mockModel.On("BulkUpdateStatus", param, data.TransactionStatusFailed).Return(&data.TransactionBulkResult{
+			Affected:  42,
+			Conflicts: []data.TransactionBulkConflict{},
+		}, nil)
+
+		body := `{"status": "failed", "filter": {"status": "pending", "user_id": 5}, "dry_run": true}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		assert.NotContains(t, response["data"], "transactions")
+		metadata := response["metadata"].(map[string]interface{})
+		assert.Equal(t, float64(42), metadata["affected"])
+		assert.Equal(t, true, metadata["dry_run"])
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns validation error without items or filter", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", `{"status": "success"}`)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		mockModel.AssertNotCalled(t, "BulkUpdateStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns validation error with both items and filter", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		body := `{"status": "success", "items": [{"id": 1}], "filter": {"user_id": 1}}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for empty filter", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", `{"status": "success", "filter": {}}`)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for duplicate ids", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		body := `{"status": "success", "items": [{"id": 1}, {"id": 1}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for too many items", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		body := `{"status": "success", "items": [{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	})
+
+	t.Run("returns validation error for a filter selecting too many", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		param := data.TransactionBulkParam{FilterStatus: "pending", Limit: 3}
+		mockModel.On("BulkUpdateStatus", param, data.TransactionStatusSucces).Return(nil, data.ErrBulkLimitExceeded)
+
+		body := `{"status": "success", "filter": {"status": "pending"}}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		httpErr := err.(*echo.HTTPError)
+		assert.Equal(t, http.StatusUnprocessableEntity, httpErr.Code)
+		assert.Contains(t, httpErr.Message, "filter")
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns error when database update fails", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createBulkTestApp(t, mockModel)
+
+		mockModel.On("BulkUpdateStatus", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		body := `{"status": "success", "filter": {"user_id": 1}}`
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-update", body)
+
+		// Execute
+		err := app.bulkUpdateTransactionHandler(ctx)
+
+		// Assert
+		assert.Error(t, err)
+		mockModel.AssertExpectations(t)
+	})
+}
+
+func TestBulkDeleteTransactionHandler(t *testing.T) {
+	t.Run("successfully deletes by id and reports conflicts", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Batch.MaxItems = 10
+
+		param := data.TransactionBulkParam{
+			Targets: []data.TransactionBulkTarget{{ID: 1}, {ID: 9}},
+			Limit:   10,
+		}
+		mockModel.On("BulkDelete", param).Return(&data.TransactionBulkResult{
+			Affected: 1,
+			Transactions: []*data.Transaction{
+				{ID: 1, UserId: 1, Amount: 10000, Status: data.TransactionStatusPending, Version: 1},
+			},
+			Conflicts: []data.TransactionBulkConflict{{ID: 9, Reason: data.BulkConflictNotFound}},
+		}, nil)
+
+		body := `{"items": [{"id": 1}, {"id": 9}]}`
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/bulk-delete", body)
+
+		// Execute
+		err := app.bulkDeleteTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		conflicts := response["data"].(map[string]interface{})["conflicts"].([]interface{})
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "not_found", conflicts[0].(map[string]interface{})["reason"])
+		assert.NotContains(t, conflicts[0], "current_status")
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("successfully deletes by filter", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Batch.MaxItems = 10
+
+		param := data.TransactionBulkParam{FilterStatus: "failed", Limit: 10}
+		mockModel.On("BulkDelete", param).Return(&data.TransactionBulkResult{
+			Conflicts: []data.TransactionBulkConflict{},
+		}, nil)
+
+		ctx, rec := createTestContext(http.MethodPost, "/transactions/bulk-delete", `{"filter": {"status": "failed"}}`)
+
+		// Execute
+		err := app.bulkDeleteTransactionHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"transactions":[]`)
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns validation error without items or filter", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockTransactionModel)
+		app := createTestApp(t, data.Models{Transactions: mockModel})
+		app.config.Batch.MaxItems = 10
+
+		ctx, _ := createTestContext(http.MethodPost, "/transactions/bulk-delete", `{"dry_run": true}`)
+
+		// Execute
+		err := app.bulkDeleteTransactionHandler(ctx)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		mockModel.AssertNotCalled(t, "BulkDelete", mock.Anything)
+	})
+}
+
 func TestGetAllTransactionHandler(t *testing.T) {
 	t.Run("successfully gets all transactions with default pagination", func(t *testing.T) {
 		// Setup
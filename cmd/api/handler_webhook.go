@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/utility"
+)
+
+func (app *application) createWebhookHandler(ctx echo.Context) error {
+	var dto dto.WebhookCreateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	webhook := data.Webhook{
+		URL:        dto.URL,
+		Secret:     utility.DerefOrDefault(dto.Secret, rand.Text()),
+		EventTypes: dto.EventTypes,
+		Version:    1,
+	}
+
+	err := app.models.Webhooks.Insert(&webhook)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed insert webhook", ctx.Request())
+	}
+
+	// The secret is only ever returned here.
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": webhook,
+	})
+}
+
+func (app *application) getAllWebhookHandler(ctx echo.Context) error {
+	var dto dto.WebhookGetAllDTO
+
+	// Set Default Value
+	dto.Pagination.Page = utility.SetPtrValue(1)
+	dto.Pagination.PageSize = utility.SetPtrValue(10)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	webhooks, metadata, err := app.models.Webhooks.GetAll(data.WebhookGetAllParam{
+		Page:       *dto.Pagination.Page,
+		PageSize:   *dto.Pagination.PageSize,
+		PageOffset: app.PageOffset(*dto.Pagination.Page, *dto.Pagination.PageSize),
+	})
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get all webhooks", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data":     webhooks,
+		"metadata": metadata,
+	})
+}
+
+func (app *application) getByIdWebhookHandler(ctx echo.Context) error {
+	var dto dto.WebhookParamIdDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	webhook, err := app.models.Webhooks.GetById(dto.WebhookId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return app.ErrNotFound(codeWebhookNotFound)
+		default:
+			return app.ErrInternalServer(err, "failed to get webhook by id", ctx.Request())
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": webhook,
+	})
+}
+
+func (app *application) removeByIdWebhookHandler(ctx echo.Context) error {
+	var dto dto.WebhookParamIdDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	webhook, err := app.models.Webhooks.GetById(dto.WebhookId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return app.ErrNotFound(codeWebhookNotFound)
+		default:
+			return app.ErrInternalServer(err, "failed to get webhook by id", ctx.Request())
+		}
+	}
+
+	err = app.models.Webhooks.DeleteOne(webhook.ID)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed to delete webhook", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": webhook,
+	})
+}
+
+func (app *application) getAllWebhookDeliveryHandler(ctx echo.Context) error {
+	var dto dto.WebhookDeliveryGetAllDTO
+
+	// Set Default Value
+	dto.Pagination.Page = utility.SetPtrValue(1)
+	dto.Pagination.PageSize = utility.SetPtrValue(10)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	_, err := app.models.Webhooks.GetById(dto.WebhookId)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return app.ErrNotFound(codeWebhookNotFound)
+		default:
+			return app.ErrInternalServer(err, "failed to get webhook by id", ctx.Request())
+		}
+	}
+
+	deliveries, metadata, err := app.models.WebhookDeliveries.GetAll(data.WebhookDeliveryGetAllParam{
+		Page:         *dto.Pagination.Page,
+		PageSize:     *dto.Pagination.PageSize,
+		PageOffset:   app.PageOffset(*dto.Pagination.Page, *dto.Pagination.PageSize),
+		WebhookId:    dto.WebhookId,
+		FilterStatus: utility.DerefOrDefault(dto.Filter.Status, ""),
+	})
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get all webhook deliveries", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data":     deliveries,
+		"metadata": metadata,
+	})
+}
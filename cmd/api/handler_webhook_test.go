@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func TestCreateWebhookHandler(t *testing.T) {
+	t.Run("successfully creates webhook with generated secret", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockWebhookModel)
+		app := createTestApp(t, data.Models{Webhooks: mockModel})
+
+		mockModel.On("Insert", mock.AnythingOfType("*data.Webhook")).
+			Run(func(args mock.Arguments) {
+				webhook := args.Get(0).(*data.Webhook)
+				webhook.ID = 1
+			}).
+			Return(nil)
+
+		body := `{"url": "https://example.com/hooks", "event_types": ["transaction.created"]}`
+		ctx, rec := createTestContext(http.MethodPost, "/webhooks", body)
+
+		// Execute
+		err := app.createWebhookHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		webhookData := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(1), webhookData["id"])
+		assert.Equal(t, "https://example.com/hooks", webhookData["url"])
+		assert.Equal(t, []any{"transaction.created"}, webhookData["event_types"])
+		assert.NotEmpty(t, webhookData["secret"])
+
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("keeps given secret", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockWebhookModel)
+		app := createTestApp(t, data.Models{Webhooks: mockModel})
+
+		mockModel.On("Insert", mock.MatchedBy(func(webhook *data.Webhook) bool {
+			return webhook.Secret == "0123456789abcdef"
+		})).Return(nil)
+
+		body := `{"url": "https://example.com/hooks", "event_types": ["transaction.deleted"], "secret": "0123456789abcdef"}`
+		ctx, _ := createTestContext(http.MethodPost, "/webhooks", body)
+
+		// Execute
+		err := app.createWebhookHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		mockModel.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "returns validation error for invalid url", body: `{"url": "ftp://example.com", "event_types": ["transaction.created"]}`},
+		{name: "returns validation error for missing event types", body: `{"url": "https://example.com/hooks", "event_types": []}`},
+		{name: "returns validation error for unknown event type", body: `{"url": "https://example.com/hooks", "event_types": ["transaction.archived"]}`},
+		{name: "returns validation error for short secret", body: `{"url": "https://example.com/hooks", "event_types": ["transaction.created"], "secret": "short"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockModel := new(data.MockWebhookModel)
+			app := createTestApp(t, data.Models{Webhooks: mockModel})
+
+			ctx, _ := createTestContext(http.MethodPost, "/webhooks", tt.body)
+
+			// Execute
+			err := app.createWebhookHandler(ctx)
+
+			// Assert
+			var he *echo.HTTPError
+			require.ErrorAs(t, err, &he)
+			assert.Equal(t, http.StatusUnprocessableEntity, he.Code)
+			mockModel.AssertNotCalled(t, "Insert")
+		})
+	}
+}
+
+func TestRemoveByIdWebhookHandler(t *testing.T) {
+	t.Run("successfully deletes webhook", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockWebhookModel)
+		app := createTestApp(t, data.Models{Webhooks: mockModel})
+
+		mockModel.On("GetById", 1).Return(&data.Webhook{ID: 1, URL: "https://example.com/hooks"}, nil)
+		mockModel.On("DeleteOne", 1).Return(nil)
+
+		ctx, rec := createTestContext(http.MethodDelete, "/webhooks/1", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("1")
+
+		// Execute
+		err := app.removeByIdWebhookHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockModel.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for missing webhook", func(t *testing.T) {
+		// Setup
+		mockModel := new(data.MockWebhookModel)
+		app := createTestApp(t, data.Models{Webhooks: mockModel})
+
+		mockModel.On("GetById", 99).Return(nil, data.ErrRecordNotFound)
+
+		ctx, _ := createTestContext(http.MethodDelete, "/webhooks/99", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("99")
+
+		// Execute
+		err := app.removeByIdWebhookHandler(ctx)
+
+		// Assert
+		var he *echo.HTTPError
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+		mockModel.AssertNotCalled(t, "DeleteOne", mock.Anything)
+	})
+}
+
+func TestGetAllWebhookDeliveryHandler(t *testing.T) {
+	t.Run("successfully lists deliveries with attempts", func(t *testing.T) {
+		// Setup
+		mockWebhooks := new(data.MockWebhookModel)
+		mockDeliveries := new(data.MockWebhookDeliveryModel)
+		app := createTestApp(t, data.Models{Webhooks: mockWebhooks, WebhookDeliveries: mockDeliveries})
+
+		mockWebhooks.On("GetById", 1).Return(&data.Webhook{ID: 1}, nil)
+		mockDeliveries.On("GetAll", data.WebhookDeliveryGetAllParam{
+			Page:         1,
+			PageSize:     10,
+			PageOffset:   0,
+			WebhookId:    1,
+			FilterStatus: "dead",
+		}).Return([]*data.WebhookDelivery{
+			{
+				ID:        5,
+				WebhookId: 1,
+				EventType: data.EventTransactionCreated,
+				Payload:   json.RawMessage(`{}`),
+				Status:    data.WebhookDeliveryDead,
+				Attempts:  1,
+				Log: []*data.WebhookAttempt{
+					{Attempt: 1, StatusCode: 500, Error: "unexpected response status 500 Internal Server Error", AttemptedAt: time.Now()},
+				},
+			},
+		}, &data.Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 1, TotalRecords: 1}, nil)
+
+		ctx, rec := createTestContext(http.MethodGet, "/webhooks/1/deliveries?status=dead", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("1")
+
+		// Execute
+		err := app.getAllWebhookDeliveryHandler(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response envelope
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err, "Failed to parse JSON response")
+
+		deliveries := response["data"].([]interface{})
+		require.Len(t, deliveries, 1)
+		delivery := deliveries[0].(map[string]interface{})
+		assert.Equal(t, "dead", delivery["status"])
+
+		log := delivery["log"].([]interface{})
+		require.Len(t, log, 1)
+		assert.Equal(t, float64(500), log[0].(map[string]interface{})["status_code"])
+
+		mockWebhooks.AssertExpectations(t)
+		mockDeliveries.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for missing webhook", func(t *testing.T) {
+		// Setup
+		mockWebhooks := new(data.MockWebhookModel)
+		mockDeliveries := new(data.MockWebhookDeliveryModel)
+		app := createTestApp(t, data.Models{Webhooks: mockWebhooks, WebhookDeliveries: mockDeliveries})
+
+		mockWebhooks.On("GetById", 99).Return(nil, data.ErrRecordNotFound)
+
+		ctx, _ := createTestContext(http.MethodGet, "/webhooks/99/deliveries", "")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("99")
+
+		// Execute
+		err := app.getAllWebhookDeliveryHandler(ctx)
+
+		// Assert
+		var he *echo.HTTPError
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusNotFound, he.Code)
+		mockDeliveries.AssertNotCalled(t, "GetAll", mock.Anything)
+	})
+}
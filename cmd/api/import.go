@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+const (
+	// importChunkRows is how many valid rows are inserted at once. Every
+	// chunk is committed on its own.
+	importChunkRows = 1000
+	// importMaxRejectedRows bounds the rejected rows listed in a report, the
+	// count covers all of them.
+	importMaxRejectedRows = 1000
+)
+
+// importField maps a CSV column to a TransactionCreateDTO field. key is the
+// validator's name for the field, so parse and validation errors of a row
+// are reported alike.
+type importField struct {
+	column string
+	key    string
+	set    func(dto *dto.TransactionCreateDTO, value int)
+}
+
+var importFields = []importField{
+	{"user_id", "TransactionCreateDTO.user_id", func(dto *dto.TransactionCreateDTO, v int) { dto.UserId = v }},
+	{"amount", "TransactionCreateDTO.amount", func(dto *dto.TransactionCreateDTO, v int) { dto.Amount = v }},
+}
+
+// errImportHeader is returned for a header row the import can't use.
+type errImportHeader struct {
+	message string
+}
+
+func (e errImportHeader) Error() string {
+	return e.message
+}
+
+type importRejectedRow struct {
+	Line   int                          `json:"line"`
+	Errors validator.ValidationErrorMap `json:"errors"`
+	// verrs are Errors with their rules, so an import answered right away
+	// words them in the locale of the request. Checkpoints don't keep them.
+	verrs validator.ValidationErrors
+}
+
+type importReport struct {
+	TotalRows    int                 `json:"total_rows"`
+	Imported     int                 `json:"imported"`
+	Rejected     int                 `json:"rejected"`
+	RejectedRows []importRejectedRow `json:"rejected_rows"`
+	// Truncated tells that more rows were rejected than are listed.
+	Truncated bool `json:"truncated"`
+}
+
+// importCheckpoint is where an import resumes. The rows before Offset, a
+// byte offset into the file, are imported or rejected already, as counted by
+// Report.
+type importCheckpoint struct {
+	Offset int64        `json:"offset"`
+	Report importReport `json:"report"`
+}
+
+// transactionImporter creates transactions from CSV. Columns are found by
+// their header, in any order, and columns it doesn't know are ignored.
+type transactionImporter struct {
+	store    data.TransactionModeler
+	validate func(i any) error
+	// job is the id of the import job running the import, zero when it
+	// runs right away. The lines of a job are inserted once, however often
+	// it runs.
+	job int
+	// progress, when set, is called after every committed chunk with the
+	// checkpoint to resume from. An error stops the import.
+	progress func(checkpoint importCheckpoint) error
+}
+
+// readImportHeader reads the header row of r, and returns the position of
+// every import field.
+func readImportHeader(r *csv.Reader) ([]int, error) {
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errImportHeader{"file is empty, a header row is required"}
+		}
+		return nil, errImportHeader{fmt.Sprintf("invalid header row: %v", err)}
+	}
+
+	found := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, dup := found[column]; dup {
+			return nil, errImportHeader{fmt.Sprintf("column %q appears twice in the header", column)}
+		}
+		found[column] = i
+	}
+
+	positions := make([]int, len(importFields))
+	for i, field := range importFields {
+		position, ok := found[field.column]
+		if !ok {
+			return nil, errImportHeader{fmt.Sprintf("header is missing column %q", field.column)}
+		}
+		positions[i] = position
+	}
+	return positions, nil
+}
+
+// newImportReader creates the CSV reader of the import file.
+func newImportReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader
+}
+
+// run imports every row of r, or the rows after from when it is not nil.
+// Rows failing validation are reported and skipped. An error means the
+// import stopped, the report then tells how far it got.
+func (im transactionImporter) run(ctx context.Context, r io.ReadSeeker, from *importCheckpoint) (*importReport, error) {
+	reader := newImportReader(r)
+
+	positions, err := readImportHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &importReport{RejectedRows: []importRejectedRow{}}
+
+	// base and lines place the rows read by reader in the file, reader
+	// starts at the checkpoint when resuming.
+	var base int64
+	var lines int
+
+	if from != nil && from.Offset > 0 {
+		*report = from.Report
+		if report.RejectedRows == nil {
+			report.RejectedRows = []importRejectedRow{}
+		}
+
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		lines, err = countLines(io.LimitReader(r, from.Offset))
+		if err != nil {
+			return nil, err
+		}
+
+		base = from.Offset
+		reader = newImportReader(r)
+	}
+
+	pending := make([]data.TransactionImportRow, 0, importChunkRows)
+
+	flush := func() error {
+		if len(pending) > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := im.insert(pending); err != nil {
+				return err
+			}
+			report.Imported += len(pending)
+			pending = pending[:0]
+		}
+		if im.progress != nil {
+			return im.progress(importCheckpoint{Offset: base + reader.InputOffset(), Report: *report})
+		}
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			report.TotalRows++
+			im.reject(report, lines+parseErr.StartLine, validator.ValidationErrors{
+				ValidationErrorMap: validator.ValidationErrorMap{
+					"TransactionCreateDTO.row": parseErr.Err.Error(),
+				},
+			})
+			continue
+		case err != nil:
+			return report, err
+		}
+
+		report.TotalRows++
+		line, _ := reader.FieldPos(0)
+		line += lines
+
+		transaction, verrs := im.parse(record, positions)
+		if transaction == nil {
+			im.reject(report, line, verrs)
+		} else {
+			pending = append(pending, data.TransactionImportRow{Line: line, Transaction: transaction})
+		}
+
+		if len(pending) == importChunkRows {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, flush()
+}
+
+// insert creates the transactions of rows in one database transaction.
+func (im transactionImporter) insert(rows []data.TransactionImportRow) error {
+	if im.job != 0 {
+		return im.store.InsertImported(im.job, rows)
+	}
+
+	transactions := make([]*data.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = row.Transaction
+	}
+	return im.store.InsertMany(transactions)
+}
+
+// parse returns the transaction of record, or nil and why the row is
+// invalid.
+func (im transactionImporter) parse(record []string, positions []int) (*data.Transaction, validator.ValidationErrors) {
+	var dto dto.TransactionCreateDTO
+	verrs := validator.ValidationErrors{
+		ValidationErrorMap: validator.ValidationErrorMap{},
+		Codes:              map[string]validator.FieldCode{},
+		Rules:              map[string]validator.Translatable{},
+	}
+
+	for i, field := range importFields {
+		if positions[i] >= len(record) {
+			verrs.ValidationErrorMap["TransactionCreateDTO.row"] = fmt.Sprintf("row has %d columns, %s is missing", len(record), field.column)
+			return nil, verrs
+		}
+
+		value := strings.TrimSpace(record[positions[i]])
+		if value == "" {
+			// Left to the validator, which reports it as required.
+			continue
+		}
+
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			verrs.ValidationErrorMap[field.key] = fmt.Sprintf("%s must be an integer", field.column)
+			verrs.Codes[field.key] = validator.CodeOf("type-integer")
+			verrs.Rules[field.key] = validator.Rule{Field: field.column, Tag: "type-integer"}
+			continue
+		}
+		field.set(&dto, number)
+	}
+
+	if err := im.validate(&dto); err != nil {
+		var validated validator.ValidationErrors
+		if !errors.As(err, &validated) {
+			verrs.ValidationErrorMap["TransactionCreateDTO.row"] = err.Error()
+		}
+		for key, message := range validated.ValidationErrorMap {
+			// A parse error says more than "is required".
+			if _, exists := verrs.ValidationErrorMap[key]; exists {
+				continue
+			}
+			verrs.ValidationErrorMap[key] = message
+			if code, ok := validated.Codes[key]; ok {
+				verrs.Codes[key] = code
+			}
+			if rule, ok := validated.Rules[key]; ok {
+				verrs.Rules[key] = rule
+			}
+		}
+	}
+
+	if len(verrs.ValidationErrorMap) > 0 {
+		return nil, verrs
+	}
+
+	return &data.Transaction{
+		UserId: dto.UserId,
+		Amount: dto.Amount,
+		Status: data.TransactionStatusPending,
+	}, validator.ValidationErrors{}
+}
+
+func (im transactionImporter) reject(report *importReport, line int, verrs validator.ValidationErrors) {
+	report.Rejected++
+	if len(report.RejectedRows) == importMaxRejectedRows {
+		report.Truncated = true
+		return
+	}
+	report.RejectedRows = append(report.RejectedRows, importRejectedRow{Line: line, Errors: verrs.ValidationErrorMap, verrs: verrs})
+}
+
+// countLines counts the line breaks in r.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 32<<10)
+	lines := 0
+
+	for {
+		n, err := r.Read(buf)
+		lines += bytes.Count(buf[:n], []byte{'\n'})
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return lines, nil
+		case err != nil:
+			return lines, err
+		}
+	}
+}
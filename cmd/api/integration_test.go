@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/pgtest"
+)
+
+// TestIntegration runs the routes against Postgres, every subtest in a schema
+// of its own. It is skipped unless TCSA_TEST_DB_DSN is set, see pgtest.
+func TestIntegration(t *testing.T) {
+	newApp := func(t *testing.T) func(method, path, body string) *http.Response {
+		app := createTestApp(t, data.NewModels(pgtest.New(t), 5*time.Second))
+		app.config.Batch.MaxItems = 100
+		app.config.Batch.MaxBodyBytes = 1 << 20
+		return serveTestApp(t, app)
+	}
+
+	decode := func(t *testing.T, res *http.Response, v any) {
+		t.Helper()
+		require.NoError(t, json.NewDecoder(res.Body).Decode(v))
+	}
+
+	t.Run("creates, updates and lists transactions", func(t *testing.T) {
+		// Setup
+		do := newApp(t)
+
+		// Execute
+		first := do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+		second := do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+		do(http.MethodPost, "/transactions", `{"user_id":2,"amount":300}`)
+
+		var created struct {
+			Data data.Transaction `json:"data"`
+		}
+		decode(t, second, &created)
+
+		updated := do(http.MethodPut, "/transactions/"+strconv.Itoa(created.Data.ID), `{"status":"failed"}`)
+		listed := do(http.MethodGet, "/transactions?user_id=1&sort_by=-amount&page_size=1&page=2", "")
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, first.StatusCode)
+		assert.Equal(t, http.StatusOK, updated.StatusCode)
+		require.Equal(t, http.StatusOK, listed.StatusCode)
+
+		var response struct {
+			Data     []data.Transaction `json:"data"`
+			Metadata data.Metadata      `json:"metadata"`
+		}
+		decode(t, listed, &response)
+
+		// Equal amounts are ordered by id, the second page has the later
+		// one.
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, created.Data.ID, response.Data[0].ID)
+		assert.Equal(t, data.TransactionStatusFailed, response.Data[0].Status)
+		assert.Equal(t, 2, response.Data[0].Version)
+		assert.Equal(t, data.Metadata{CurrentPage: 2, PageSize: 1, FirstPage: 1, LastPage: 2, TotalRecords: 2}, response.Metadata)
+	})
+
+	t.Run("returns 404 for removed transaction", func(t *testing.T) {
+		// Setup
+		do := newApp(t)
+		do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+
+		// Execute
+		removed := do(http.MethodDelete, "/transactions/1", "")
+		fetched := do(http.MethodGet, "/transactions/1", "")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, removed.StatusCode)
+		assert.Equal(t, http.StatusNotFound, fetched.StatusCode)
+	})
+
+	t.Run("bulk updates by filter and summarizes", func(t *testing.T) {
+		// Setup
+		do := newApp(t)
+		do(http.MethodPost, "/transactions/batch", `{"items":[
+			{"user_id":1,"amount":100},
+			{"user_id":1,"amount":200},
+			{"user_id":1,"amount":300},
+			{"user_id":2,"amount":400}
+		]}`)
+
+		// Execute
+		bulk := do(http.MethodPost, "/transactions/bulk-update", `{"filter":{"user_id":1},"status":"success"}`)
+		summary := do(http.MethodGet, "/dashboard/summary?date_range=1", "")
+
+		// Assert
+		require.Equal(t, http.StatusOK, bulk.StatusCode)
+		require.Equal(t, http.StatusOK, summary.StatusCode)
+
+		var response struct {
+			Data data.TransactionSummary `json:"data"`
+		}
+		decode(t, summary, &response)
+
+		assert.Equal(t, 4, response.Data.Summary.CountTotal)
+		assert.Equal(t, 3, response.Data.Summary.Success.Count)
+		assert.Equal(t, 75.0, response.Data.Summary.Success.RatePercentage)
+		assert.Equal(t, 25.0, response.Data.Summary.Pending.RatePercentage)
+	})
+
+	t.Run("exports as csv", func(t *testing.T) {
+		// Setup
+		do := newApp(t)
+		do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+		do(http.MethodPost, "/transactions", `{"user_id":2,"amount":200}`)
+
+		// Execute
+		res := do(http.MethodGet, "/transactions/export?format=csv&columns=id,amount&sort_by=-amount", "")
+
+		// Assert
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "id,amount\n2,200\n1,100\n", string(body))
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/jobs"
+)
+
+// Job kinds run by the pool.
+const (
+	jobKindTransactionImport = "transactions.import"
+)
+
+// newJobPool creates the pool running the background jobs, with a handler
+// for every job kind.
+func (app *application) newJobPool() *jobs.Pool {
+	opts := jobs.DefaultOptions()
+	opts.Workers = app.config.Jobs.Workers
+	opts.PollInterval = app.config.Jobs.PollInterval
+	opts.Lease = app.config.Jobs.Lease
+	opts.BackoffBase = app.config.Jobs.BackoffBase
+	opts.BackoffMax = app.config.Jobs.BackoffMax
+	opts.Retention = app.config.Jobs.Retention
+
+	pool := jobs.NewPool(app.models.Jobs, app.logger, opts)
+	pool.Register(jobKindTransactionImport, jobs.HandlerFunc(app.runImportJob))
+	return pool
+}
+
+// jobResponse is a job as shown by GET /jobs/:id. Its result has a link of
+// its own, it can be much larger than the status.
+type jobResponse struct {
+	ID          int               `json:"id"`
+	Kind        string            `json:"kind"`
+	Status      data.JobStatus    `json:"status"`
+	Payload     json.RawMessage   `json:"payload,omitempty"`
+	Progress    json.RawMessage   `json:"progress,omitempty"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	Error       string            `json:"error,omitempty"`
+	RunAt       time.Time         `json:"run_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Links       map[string]string `json:"links"`
+}
+
+func newJobResponse(job *data.Job) jobResponse {
+	links := map[string]string{
+		"self": jobLocation(job.ID),
+	}
+	if job.Status == data.JobCompleted {
+		links["result"] = jobLocation(job.ID) + "/result"
+	}
+
+	return jobResponse{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Status:      job.Status,
+		Payload:     job.Payload,
+		Progress:    job.Progress,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.LastError,
+		RunAt:       job.RunAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		Links:       links,
+	}
+}
+
+func jobLocation(id int) string {
+	return fmt.Sprintf("/jobs/%d", id)
+}
@@ -3,52 +3,103 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	_ "github.com/jackc/pgx/stdlib"
+	"github.com/spf13/pflag"
 	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/outbox"
+	"github.com/ucok-man/tcsa/internal/stream"
 	"github.com/ucok-man/tcsa/internal/tlog"
 )
 
 const VERSION = "1.0.0"
 
 type application struct {
-	config Config
-	logger *tlog.Logger
-	models data.Models
-	wg     sync.WaitGroup
+	config  Config
+	live    atomic.Pointer[Config]
+	loader  *configLoader
+	limiter *rateLimiter
+	logger  *tlog.Logger
+	db      *sql.DB
+	models  data.Models
+	events  *outbox.Bus
+	broker  *stream.Broker
+	wg      sync.WaitGroup
 }
 
 func main() {
-	cfg, err := NewConfig()
-	if err != nil {
-		log.Fatal(err)
+	err := run(os.Args[1:])
+	if err != nil && !errors.Is(err, pflag.ErrHelp) {
+		log.Print(err)
 	}
+	os.Exit(exitCode(err))
+}
+
+// exitCode is the process exit code of a command that returned err. Asking
+// for help is not a failure.
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, pflag.ErrHelp) {
+		return 0
+	}
+	return 1
+}
 
+// newApplication creates the logger and database pool shared by every command
+// that talks to the database. With in-memory storage there is no database,
+// only the transactions are kept, in memory.
+func newApplication(cfg Config) (*application, error) {
 	logger := tlog.Must(tlog.NewProduction())
 	if cfg.Env != "production" {
 		logger = tlog.Must(tlog.NewDevelopment())
 	}
-	defer logger.Sync()
 
-	db, err := openDB(cfg)
-	if err != nil {
-		logger.Fatalj(tlog.JSON{"message": "failed connecting to database", "err": err})
+	if lvl, err := tlog.ParseLevel(cfg.Log.Level); err == nil {
+		logger.SetLevel(lvl)
 	}
-	defer db.Close()
 
 	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(db),
+		config:  cfg,
+		limiter: newRateLimiter(),
+		logger:  logger,
+		events:  outbox.NewBus(),
+		broker:  stream.NewBroker(),
+	}
+
+	if cfg.inMemory() {
+		app.models = data.NewMemoryModels()
+		return app, nil
 	}
 
-	err = app.serve()
+	db, err := openDB(cfg)
 	if err != nil {
-		logger.Fatalj(tlog.JSON{"message": "server has error occured", "error": err})
+		logger.Sync()
+		return nil, fmt.Errorf("failed connecting to database: %w", err)
+	}
+
+	app.db = db
+	app.models = data.NewModels(db, cfg.Database.QueryTimeout)
+	return app, nil
+}
+
+func (app *application) close() {
+	if app.db != nil {
+		app.db.Close()
+	}
+	app.logger.Sync()
+}
+
+// requireDatabase fails commands that only work against Postgres.
+func (app *application) requireDatabase(command string) error {
+	if app.db == nil {
+		return fmt.Errorf("%s needs postgres storage, not %s", command, app.config.Database.Storage)
 	}
+	return nil
 }
 
 func openDB(cfg Config) (*sql.DB, error) {
@@ -61,7 +112,7 @@ func openDB(cfg Config) (*sql.DB, error) {
 	db.SetMaxIdleConns(cfg.Database.MaxIdleConn)
 	db.SetConnMaxIdleTime(cfg.Database.MaxIdleTime)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
 	defer cancel()
 
 	err = db.PingContext(ctx)
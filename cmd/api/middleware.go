@@ -1,10 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/ucok-man/tcsa/internal/openapi"
+	"github.com/ucok-man/tcsa/internal/serializer"
 	"github.com/ucok-man/tcsa/internal/tlog"
+	"github.com/ucok-man/tcsa/internal/validator"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 func (app *application) withRecover() echo.MiddlewareFunc {
@@ -52,6 +65,9 @@ func (app *application) withRequestLogger() echo.MiddlewareFunc {
 				"response_time": v.Latency,
 				"response_size": v.ResponseSize,
 			}
+			if identity := clientIdentityFrom(ctx); identity != nil {
+				data["client_cn"] = identity.CommonName
+			}
 
 			ctx.Logger().Infoj(data)
 
@@ -60,8 +76,274 @@ func (app *application) withRequestLogger() echo.MiddlewareFunc {
 	})
 }
 
+// withCORS checks origins against the live configuration, so trusted origins
+// can be changed by a config reload. No trusted origins allows any origin.
 func (app *application) withCORS() echo.MiddlewareFunc {
 	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: app.config.Cors.TrustedOrigins,
+		AllowOriginFunc: func(origin string) (bool, error) {
+			origins := app.liveConfig().Cors.TrustedOrigins
+			return len(origins) == 0 || slices.Contains(origins, origin), nil
+		},
 	})
 }
+
+// withBodyLimits raises the largest request body the JSON serializer accepts
+// from its default, for the routes of limits by path. It must come before
+// withOpenAPIValidation, which reads bodies up to the same limit.
+func (app *application) withBodyLimits(limits map[string]int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if n, ok := limits[ctx.Path()]; ok {
+				serializer.SetMaxBytes(ctx, n)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// withDatabase answers 503 for routes that need Postgres while the
+// transactions are kept in memory.
+func (app *application) withDatabase() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if app.config.inMemory() {
+				return app.ErrServiceUnavailable("not available with in-memory storage")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func (app *application) withRateLimit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			cfg := app.liveConfig().RateLimit
+			if !cfg.Enabled {
+				return next(ctx)
+			}
+
+			if !app.limiter.allow(ctx.RealIP(), rate.Limit(cfg.Rps), cfg.Burst) {
+				ctx.Response().Header().Set("Retry-After", "1")
+				return app.ErrRateLimitExceeded()
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// withOpenAPIValidation checks requests, and responses in development,
+// against the embedded OpenAPI document. Requests that don't match are
+// answered 422 before reaching the handler, responses that don't match are
+// replaced by a 500 naming the mismatch. Routes without an operation in the
+// document pass through.
+func (app *application) withOpenAPIValidation() echo.MiddlewareFunc {
+	validateRequests := app.config.OpenAPI.ValidateRequests
+	validateResponses := app.config.OpenAPI.ValidateResponses || app.config.Env == "development"
+	if !validateRequests && !validateResponses {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	doc := embeddedOpenAPI()
+	spec := openapi.NewValidator(doc)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			op := doc.Operation(ctx.Request().Method, openAPIPath(ctx.Path()))
+			if op == nil {
+				return next(ctx)
+			}
+
+			if validateRequests {
+				input, err := openAPIRequestInput(ctx)
+				if err != nil {
+					return app.ErrBadRequest(err.Error())
+				}
+				if errs := spec.Request(op, input); len(errs) > 0 {
+					return app.ErrFailedValidation(openAPIErrors("request", errs))
+				}
+			}
+
+			if !validateResponses || !bufferable(op) {
+				return next(ctx)
+			}
+
+			res := ctx.Response()
+			buffer := &responseBuffer{ResponseWriter: res.Writer, status: http.StatusOK}
+			res.Writer = buffer
+
+			// Errors are written here rather than by the router, so they are
+			// validated too.
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+			res.Writer = buffer.ResponseWriter
+
+			errs := spec.Response(op, buffer.status, res.Header().Get(echo.HeaderContentType), buffer.body.Bytes())
+			if len(errs) == 0 {
+				return buffer.flush()
+			}
+
+			details := openAPIErrors("response", errs)
+			app.logger.Errorj(tlog.JSON{
+				"message": "response does not match the openapi document",
+				"method":  ctx.Request().Method,
+				"path":    ctx.Path(),
+				"status":  buffer.status,
+				"error":   details,
+			})
+
+			// The response held back is dropped, the error is answered like
+			// any other, as problem details to the clients asking for them.
+			res.Committed = false
+			res.Header().Del(echo.HeaderContentLength)
+			app.HTTPErrorHandler(app.ErrResponseMismatch(details), ctx)
+			return nil
+		}
+	}
+}
+
+// openAPIRequestInput reads what the operation of ctx is validated against.
+// A JSON body is read up to the limit of the serializer and put back for the
+// handler, a larger one is left to the handler and not validated.
+func openAPIRequestInput(ctx echo.Context) (openapi.RequestInput, error) {
+	req := ctx.Request()
+	input := openapi.RequestInput{
+		Path:        map[string]string{},
+		Query:       ctx.QueryParams(),
+		Header:      req.Header,
+		ContentType: req.Header.Get(echo.HeaderContentType),
+	}
+	for i, name := range ctx.ParamNames() {
+		input.Path[name] = ctx.ParamValues()[i]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(input.ContentType)
+	if mediaType != echo.MIMEApplicationJSON || req.Body == nil {
+		return input, nil
+	}
+
+	limit := serializer.MaxBytes(ctx)
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return input, err
+	}
+
+	if int64(len(body)) > limit {
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return input, nil
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	input.Body = body
+	return input, nil
+}
+
+// openAPIErrors turns errs into the details of a 422, keyed like the errors
+// of the validator package, whose first segment is dropped.
+func openAPIErrors(namespace string, errs openapi.Errors) validator.ValidationErrors {
+	details := validator.ValidationErrors{
+		ValidationErrorMap: validator.ValidationErrorMap{},
+		Codes:              map[string]validator.FieldCode{},
+		Rules:              map[string]validator.Translatable{},
+	}
+	for field, reason := range errs {
+		details.ValidationErrorMap[namespace+"."+field] = reason.Message
+		details.Codes[namespace+"."+field] = reason.Code
+		details.Rules[namespace+"."+field] = reason.Rule
+	}
+	return details
+}
+
+// bufferable reports whether the responses of op can be held back until
+// they are validated, which streams and protocol switches can't.
+func bufferable(op *openapi.Operation) bool {
+	for status, response := range op.Responses {
+		if status == strconv.Itoa(http.StatusSwitchingProtocols) {
+			return false
+		}
+		for contentType := range response.Content {
+			if contentType != echo.MIMEApplicationJSON && contentType != problemJSON {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// responseBuffer holds a response back until it is validated.
+type responseBuffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) flush() error {
+	b.ResponseWriter.WriteHeader(b.status)
+	_, err := b.ResponseWriter.Write(b.body.Bytes())
+	return err
+}
+
+// rateLimiter keeps a token bucket per client. Limits are passed on every
+// call rather than fixed on creation, so a config reload applies to existing
+// clients too.
+type rateLimiter struct {
+	mu          sync.Mutex
+	clients     map[string]*rateLimiterClient
+	lastCleanup time.Time
+}
+
+type rateLimiterClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		clients:     make(map[string]*rateLimiterClient),
+		lastCleanup: time.Now(),
+	}
+}
+
+func (rl *rateLimiter) allow(key string, limit rate.Limit, burst int) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+
+	// Forget clients that have been idle for a while.
+	if now.Sub(rl.lastCleanup) > time.Minute {
+		for key, client := range rl.clients {
+			if now.Sub(client.lastSeen) > 3*time.Minute {
+				delete(rl.clients, key)
+			}
+		}
+		rl.lastCleanup = now
+	}
+
+	client, found := rl.clients[key]
+	if !found {
+		client = &rateLimiterClient{limiter: rate.NewLimiter(limit, burst)}
+		rl.clients[key] = client
+	}
+	client.lastSeen = now
+
+	if client.limiter.Limit() != limit {
+		client.limiter.SetLimitAt(now, limit)
+	}
+	if client.limiter.Burst() != burst {
+		client.limiter.SetBurstAt(now, burst)
+	}
+
+	return client.limiter.AllowN(now, 1)
+}
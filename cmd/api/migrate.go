@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/migration"
+	"github.com/ucok-man/tcsa/internal/tlog"
+	"github.com/ucok-man/tcsa/migrations"
+)
+
+func runMigrate(db *sql.DB, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: migrate up|down|status|version")
+	}
+
+	migrator, err := migration.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(w, "OK   %s\n", m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "no migrations to run")
+		}
+
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if rolledBack == nil {
+			fmt.Fprintln(w, "no migrations to roll back")
+			return nil
+		}
+		fmt.Fprintf(w, "OK   %s\n", rolledBack.Name)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "Applied At\tMigration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC1123)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", appliedAt, status.Name)
+		}
+		return tw.Flush()
+
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "version %d\n", version)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up|down|status|version", args[0])
+	}
+
+	return nil
+}
+
+func autoMigrate(db *sql.DB, logger *tlog.Logger) error {
+	migrator, err := migration.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		logger.Infoj(tlog.JSON{"message": "applied migration", "migration": m.Name})
+	}
+	return err
+}
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/openapi"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+// apiRoute documents a route of routes() for the OpenAPI document served as
+// /swagger.yaml. Parameters and bodies are derived from the DTOs the handler
+// binds, responses from the types it answers with. openapi_test.go fails
+// when a route is missing here or the document is stale, see `make openapi`.
+type apiRoute struct {
+	method      string
+	path        string
+	id          string
+	tag         string
+	summary     string
+	description string
+
+	// params is the DTO bound from the path and the query, body the one
+	// bound from the JSON body. They can be the same.
+	params any
+	body   any
+
+	// headers and requestBody document what the handler reads by hand.
+	headers     []*openapi.Parameter
+	requestBody *openapi.RequestBody
+
+	responses []apiResponse
+}
+
+// apiResponse documents a status of a route. body is a value of the type
+// answered with, as JSON unless content is set.
+type apiResponse struct {
+	status      int
+	description string
+	body        any
+	content     map[string]*openapi.MediaType
+	headers     map[string]*openapi.Header
+}
+
+// embeddedOpenAPI returns the served docs/swagger.yaml. It is generated and
+// checked by the tests, failing to read it is a bug.
+var embeddedOpenAPI = sync.OnceValue(func() *openapi.Document {
+	spec, err := swaggerFile.ReadFile("docs/swagger.yaml")
+	if err != nil {
+		panic(err)
+	}
+	doc, err := openapi.Unmarshal(spec)
+	if err != nil {
+		panic(err)
+	}
+	return doc
+})
+
+// undocumentedRoutes serve the documentation itself.
+var undocumentedRoutes = []string{
+	http.MethodGet + " /docs",
+	http.MethodGet + " /docs/assets/:name",
+	http.MethodGet + " /swagger.yaml",
+}
+
+// batchCreateBody is dto.TransactionBatchCreateDTO with items checked for
+// their types only, as partial batches report invalid items rather than
+// rejecting the request.
+type batchCreateBody struct {
+	Mode  *string `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []struct {
+		UserID int `json:"user_id"`
+		Amount int `json:"amount"`
+	} `json:"items" validate:"required,min=1" doc:"Every item is validated like the body of POST /transactions, see mode"`
+}
+
+// Response bodies. Handlers answer with envelope maps, these have the same
+// shape.
+type (
+	dataBody[T any] struct {
+		Data T `json:"data"`
+	}
+
+	pageBody[T any] struct {
+		Data     []T           `json:"data"`
+		Metadata data.Metadata `json:"metadata"`
+	}
+
+	summaryBody struct {
+		Data     data.TransactionSummary `json:"data"`
+		Metadata data.Metadata           `json:"metadata"`
+	}
+
+	batchBody struct {
+		Data     []batchItemResult `json:"data"`
+		Metadata struct {
+			Created int `json:"created"`
+			Failed  int `json:"failed"`
+		} `json:"metadata"`
+	}
+
+	bulkBody struct {
+		Data struct {
+			Conflicts    []data.TransactionBulkConflict `json:"conflicts"`
+			Transactions []*data.Transaction            `json:"transactions,omitempty" doc:"The changed transactions, omitted on a dry run"`
+		} `json:"data"`
+		Metadata struct {
+			Affected  int  `json:"affected" doc:"Transactions changed, or that would be on a dry run"`
+			Conflicts int  `json:"conflicts"`
+			DryRun    bool `json:"dry_run"`
+		} `json:"metadata"`
+	}
+
+	healthBody struct {
+		Status     string `json:"status"`
+		SystemInfo struct {
+			Environment string `json:"environment"`
+			Version     string `json:"version"`
+		} `json:"system_info"`
+	}
+
+	errorBody struct {
+		Error errorResponse `json:"error"`
+	}
+
+	problemBody struct {
+		Type     string                          `json:"type" doc:"URI of the kind of error, see Problem types, or about:blank"`
+		Title    string                          `json:"title"`
+		Status   int                             `json:"status"`
+		Detail   string                          `json:"detail,omitempty"`
+		Instance string                          `json:"instance,omitempty" doc:"Path of the request"`
+		Code     errorCode                       `json:"code"`
+		Errors   map[string]string               `json:"errors,omitempty" doc:"Error message by field, on validation errors"`
+		Fields   map[string]validator.FieldError `json:"fields,omitempty" doc:"Code and message by field, on validation errors"`
+	}
+
+	validationErrorBody struct {
+		Error struct {
+			Code    errorCode                       `json:"code"`
+			Message string                          `json:"message"`
+			Details map[string]string               `json:"details" doc:"Error message by field"`
+			Fields  map[string]validator.FieldError `json:"fields" doc:"Code and message by field"`
+		} `json:"error"`
+	}
+)
+
+var (
+	transactionIdParams = dto.TransactionParamIdDTO{}
+	webhookIdParams     = dto.WebhookParamIdDTO{}
+	jobIdParams         = dto.JobParamIdDTO{}
+
+	notFound           = apiResponse{status: http.StatusNotFound, description: "Not found"}
+	serviceUnavailable = apiResponse{status: http.StatusServiceUnavailable, description: "Unavailable without a database, or shutting down"}
+)
+
+func apiRoutes() []apiRoute {
+	return []apiRoute{
+		{
+			method: http.MethodGet, path: "/healthcheck", id: "healthCheck", tag: "Health",
+			summary:     "Health check",
+			description: "Returns the current status and version of the API.",
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "API is available", body: healthBody{}},
+			},
+		},
+
+		// Transactions
+
+		{
+			method: http.MethodGet, path: "/transactions", id: "getAllTransactions", tag: "Transactions",
+			summary:     "List transactions",
+			description: "Returns a page of transactions, filtered and sorted.",
+			params:      dto.TransactionGetAllDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "A page of transactions", body: pageBody[data.Transaction]{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/transactions", id: "createTransaction", tag: "Transactions",
+			summary:     "Create a transaction",
+			description: "Creates a pending transaction.",
+			body:        dto.TransactionCreateDTO{},
+			responses: []apiResponse{
+				{status: http.StatusCreated, description: "Transaction created", body: dataBody[data.Transaction]{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/transactions/batch", id: "createTransactionBatch", tag: "Transactions",
+			summary: "Create transactions in bulk",
+			description: "Creates up to `TCSA_BATCH_MAX_ITEMS` pending transactions, every item follows the rules of " +
+				"`POST /transactions`. In `atomic` mode, the default, one invalid item fails the whole request and its " +
+				"errors are keyed by index, e.g. `items[3].amount`. In `partial` mode the valid items are created and " +
+				"the outcome of every item is reported by its index. Bodies larger than `TCSA_BATCH_MAX_BODY_BYTES` are " +
+				"answered 400.",
+			body: batchCreateBody{},
+			responses: []apiResponse{
+				{status: http.StatusCreated, description: "Every transaction created, in atomic mode", body: dataBody[[]data.Transaction]{}},
+				{status: http.StatusMultiStatus, description: "Outcome of every item, in partial mode", body: batchBody{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/transactions/bulk-update", id: "bulkUpdateTransactions", tag: "Transactions",
+			summary: "Change the status of many transactions",
+			description: "Changes the status of the transactions selected by `items` or by `filter`, in one database " +
+				"transaction. Pending transactions may change to success or failed, settled transactions keep their status. " +
+				"Transactions whose status can't change are left untouched, those selected by `items` are " +
+				"reported as conflicts. A `filter` may select at most `TCSA_BATCH_MAX_ITEMS` transactions that can change, more " +
+				"are rejected with 422. With `dry_run` nothing is changed or locked.",
+			body: dto.TransactionBulkUpdateDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Outcome of the bulk operation", body: bulkBody{}},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/transactions/bulk-delete", id: "bulkDeleteTransactions", tag: "Transactions",
+			summary: "Delete many transactions",
+			description: "Deletes the transactions selected by `items` or by `filter`, in one database transaction. " +
+				"Items that are missing or at another version are reported as conflicts. A `filter` may select at most " +
+				"`TCSA_BATCH_MAX_ITEMS` transactions, more are rejected with 422. With `dry_run` nothing is deleted or locked.",
+			body: dto.TransactionBulkDeleteDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Outcome of the bulk operation", body: bulkBody{}},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/transactions/stream", id: "streamTransactions", tag: "Transactions",
+			summary: "Stream transaction events",
+			description: "Server-Sent Events stream of the transaction events of every API instance. Every SSE `id` is " +
+				"the outbox ID of the event, reconnecting with `Last-Event-ID` first replays the events after it that " +
+				"are still in the outbox. Idle streams receive a `: heartbeat` comment.",
+			params: dto.TransactionStreamDTO{},
+			headers: []*openapi.Parameter{
+				{
+					Name: "Last-Event-ID", In: "header",
+					Description: "ID of the last event received, to resume after",
+					Schema:      &openapi.Schema{Type: "integer", Minimum: ptr(0.0)},
+				},
+			},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Event stream, every event has an `id`, an `event` type and the event as JSON `data`", content: textContent("text/event-stream")},
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodGet, path: "/transactions/export", id: "exportTransactions", tag: "Transactions",
+			summary: "Export transactions",
+			description: "Streams every matching transaction as CSV or NDJSON, read over a single snapshot. If the " +
+				"export fails after the first row the connection is aborted, so an incomplete file is never mistaken " +
+				"for a complete one.",
+			params: dto.TransactionExportDTO{},
+			responses: []apiResponse{
+				{
+					status: http.StatusOK, description: "The export, as a file download",
+					content: textContent("text/csv", "application/x-ndjson"),
+					headers: map[string]*openapi.Header{
+						"Content-Disposition": {Schema: &openapi.Schema{Type: "string"}},
+					},
+				},
+			},
+		},
+		{
+			method: http.MethodPost, path: "/transactions/import", id: "importTransactions", tag: "Transactions",
+			summary: "Import transactions from CSV",
+			description: "Creates pending transactions from a CSV file with `user_id` and `amount` columns. Every row " +
+				"is validated like the body of `POST /transactions`, rejected rows are reported by line number. Files " +
+				"up to `TCSA_IMPORT_ASYNC_THRESHOLD_BYTES` are imported during the request, larger ones by a background job.",
+			requestBody: &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					"multipart/form-data": {Schema: &openapi.Schema{
+						Type:     "object",
+						Required: []string{"file"},
+						Properties: openapi.Properties{
+							{Name: "file", Schema: &openapi.Schema{Type: "string", Format: "binary", Description: "CSV file, at most `TCSA_IMPORT_MAX_FILE_BYTES`"}},
+						},
+					}},
+				},
+			},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "File imported", body: dataBody[importReport]{}},
+				{
+					status: http.StatusAccepted, description: "File is imported by a background job", body: dataBody[jobResponse]{},
+					headers: map[string]*openapi.Header{
+						"Location": {Description: "URL of the import job", Schema: &openapi.Schema{Type: "string"}},
+					},
+				},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/transactions/:id", id: "getTransactionById", tag: "Transactions",
+			summary: "Get a transaction",
+			params:  transactionIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The transaction", body: dataBody[data.Transaction]{}},
+				notFound,
+			},
+		},
+		{
+			method: http.MethodPut, path: "/transactions/:id", id: "updateTransaction", tag: "Transactions",
+			summary:     "Update a transaction",
+			description: "Changes the amount, the status or both.",
+			params:      dto.TransactionUpdateDTO{},
+			body:        dto.TransactionUpdateDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "Transaction updated", body: dataBody[data.Transaction]{}},
+				notFound,
+				{status: http.StatusConflict, description: "Changed by another request meanwhile", body: errorBody{}},
+			},
+		},
+		{
+			method: http.MethodDelete, path: "/transactions/:id", id: "deleteTransaction", tag: "Transactions",
+			summary: "Delete a transaction",
+			params:  transactionIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The deleted transaction", body: dataBody[data.Transaction]{}},
+				notFound,
+			},
+		},
+
+		// Webhooks
+
+		{
+			method: http.MethodGet, path: "/webhooks", id: "getAllWebhooks", tag: "Webhooks",
+			summary:     "List webhooks",
+			description: "Returns a page of webhooks, without their secrets.",
+			params:      dto.WebhookGetAllDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "A page of webhooks", body: pageBody[data.Webhook]{}},
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodPost, path: "/webhooks", id: "createWebhook", tag: "Webhooks",
+			summary: "Register a webhook",
+			description: "Subscribes a URL to transaction events. Every delivery is a POST of the event signed with " +
+				"the webhook secret in the `X-TCSA-Signature` header. The secret is only returned in this response.",
+			body: dto.WebhookCreateDTO{},
+			responses: []apiResponse{
+				{status: http.StatusCreated, description: "Webhook registered", body: dataBody[data.Webhook]{}},
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodGet, path: "/webhooks/:id", id: "getWebhookById", tag: "Webhooks",
+			summary: "Get a webhook",
+			params:  webhookIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The webhook", body: dataBody[data.Webhook]{}},
+				notFound,
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodDelete, path: "/webhooks/:id", id: "deleteWebhook", tag: "Webhooks",
+			summary:     "Delete a webhook",
+			description: "Deletes a webhook together with its deliveries.",
+			params:      webhookIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The deleted webhook", body: dataBody[data.Webhook]{}},
+				notFound,
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodGet, path: "/webhooks/:id/deliveries", id: "getAllWebhookDeliveries", tag: "Webhooks",
+			summary:     "List the deliveries of a webhook",
+			description: "Returns a page of the deliveries of a webhook, newest first, with every attempt made.",
+			params:      dto.WebhookDeliveryGetAllDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "A page of deliveries", body: pageBody[data.WebhookDelivery]{}},
+				notFound,
+				serviceUnavailable,
+			},
+		},
+
+		// Jobs
+
+		{
+			method: http.MethodGet, path: "/jobs/:id", id: "getJobById", tag: "Jobs",
+			summary:     "Get a job",
+			description: "Returns the status and progress of a background job. Once the job completed, its links include `result`.",
+			params:      jobIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The job", body: dataBody[jobResponse]{}},
+				notFound,
+				serviceUnavailable,
+			},
+		},
+		{
+			method: http.MethodGet, path: "/jobs/:id/result", id: "getJobResult", tag: "Jobs",
+			summary: "Get the result of a job",
+			description: "Returns the result of a completed job, a `transactions.import` job results in an import " +
+				"report. Jobs that are not completed have no result.",
+			params: jobIdParams,
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The result", body: dataBody[importReport]{}},
+				notFound,
+				serviceUnavailable,
+			},
+		},
+
+		// Dashboard
+
+		{
+			method: http.MethodGet, path: "/dashboard/summary", id: "getTransactionSummary", tag: "Dashboard",
+			summary:     "Summarize transactions",
+			description: "Returns the count and rate of every status, with a page of the summarized transactions.",
+			params:      dto.TransactionSummaryDTO{},
+			responses: []apiResponse{
+				{status: http.StatusOK, description: "The summary", body: summaryBody{}},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/dashboard/summary/ws", id: "streamTransactionSummary", tag: "Dashboard",
+			summary: "Live transaction summary (WebSocket)",
+			description: "Upgrades to a WebSocket sending the summary of `GET /dashboard/summary` on connect, then " +
+				"again after changes to matching transactions, at most once per `TCSA_STREAM_SUMMARY_DEBOUNCE`. " +
+				"Every message has a `type` of `summary`, with `data` and `metadata`, or `error`.",
+			params: dto.TransactionSummaryDTO{},
+			responses: []apiResponse{
+				{status: http.StatusSwitchingProtocols, description: "Switching to the WebSocket protocol"},
+				serviceUnavailable,
+			},
+		},
+	}
+}
+
+// newOpenAPIDocument builds the OpenAPI document of apiRoutes.
+func newOpenAPIDocument() *openapi.Document {
+	gen := openapi.NewGenerator()
+	gen.Name(reflect.TypeFor[jobResponse](), "Job")
+	gen.Name(reflect.TypeFor[errorBody](), "Error")
+	gen.Name(reflect.TypeFor[validationErrorBody](), "ValidationError")
+	gen.Name(reflect.TypeFor[problemBody](), "Problem")
+	gen.Name(reflect.TypeFor[batchCreateBody](), "TransactionBatchCreateRequest")
+	gen.Name(reflect.TypeFor[healthBody](), "Health")
+	gen.Name(reflect.TypeFor[summaryBody](), "TransactionSummaryPage")
+	gen.Name(reflect.TypeFor[batchBody](), "TransactionBatchResult")
+	gen.Name(reflect.TypeFor[bulkBody](), "TransactionBulkResult")
+	gen.Enum(reflect.TypeFor[data.TransactionStatus](),
+		string(data.TransactionStatusPending), string(data.TransactionStatusSucces), string(data.TransactionStatusFailed))
+	gen.Enum(reflect.TypeFor[data.JobStatus](),
+		string(data.JobQueued), string(data.JobRunning), string(data.JobCompleted), string(data.JobFailed))
+	gen.Enum(reflect.TypeFor[data.WebhookDeliveryStatus](),
+		string(data.WebhookDeliveryPending), string(data.WebhookDeliverySucceeded), string(data.WebhookDeliveryDead))
+	codes := make([]any, len(errorCodes))
+	for i, entry := range errorCodes {
+		codes[i] = string(entry.code)
+	}
+	gen.Enum(reflect.TypeFor[errorCode](), codes...)
+	fieldCodes := make([]any, len(validator.FieldCodes))
+	for i, code := range validator.FieldCodes {
+		fieldCodes[i] = string(code)
+	}
+	gen.Enum(reflect.TypeFor[validator.FieldCode](), fieldCodes...)
+	eventTypes := make([]any, len(data.EventTypes))
+	for i, eventType := range data.EventTypes {
+		eventTypes[i] = string(eventType)
+	}
+	gen.Enum(reflect.TypeFor[data.EventType](), eventTypes...)
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title: "Transaction Management API",
+			Description: "Manages financial transactions, with filtering, sorting, bulk operations, imports and " +
+				"exports, live events and a summary dashboard.\n\nGenerated from the routes and DTOs by " +
+				"`make openapi`, do not edit by hand.",
+			Version: VERSION,
+		},
+		Servers: []openapi.Server{
+			{URL: "http://localhost:4000", Description: "Development server"},
+		},
+		Tags: []openapi.Tag{
+			{Name: "Health", Description: "API health check"},
+			{Name: "Transactions", Description: "Transaction management"},
+			{Name: "Dashboard", Description: "Analytics and summary"},
+			{Name: "Webhooks", Description: "Subscriptions to transaction events"},
+			{Name: "Jobs", Description: "Background jobs"},
+		},
+		Paths: map[string]*openapi.PathItem{},
+		Components: openapi.Components{
+			Responses: map[string]*openapi.Response{
+				"BadRequest":          errorComponent(gen, "Malformed request, like invalid JSON or a parameter of the wrong type", errorBody{}),
+				"ValidationError":     errorComponent(gen, "Request failed validation", validationErrorBody{}),
+				"RateLimited":         errorComponent(gen, "Too many requests from the client, retry after `Retry-After` seconds", errorBody{}),
+				"InternalServerError": errorComponent(gen, "The server encountered a problem", errorBody{}),
+			},
+		},
+	}
+
+	for _, route := range apiRoutes() {
+		path := openAPIPath(route.path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		if item.Operation(route.method) != nil {
+			panic(fmt.Sprintf("openapi: %s %s documented twice", route.method, route.path))
+		}
+		item.SetOperation(route.method, route.operation(gen))
+	}
+
+	doc.Components.Schemas = gen.Components()
+	return doc
+}
+
+func (route apiRoute) operation(gen *openapi.Generator) *openapi.Operation {
+	op := &openapi.Operation{
+		Tags:        []string{route.tag},
+		Summary:     route.summary,
+		Description: route.description,
+		OperationID: route.id,
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	if route.params != nil {
+		op.Parameters = gen.Parameters(reflect.TypeOf(route.params))
+	}
+	op.Parameters = append(op.Parameters, route.headers...)
+
+	switch {
+	case route.requestBody != nil:
+		op.RequestBody = route.requestBody
+	case route.body != nil:
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/json": {Schema: gen.Schema(reflect.TypeOf(route.body), openapi.ForRequest)},
+			},
+		}
+	}
+
+	for _, res := range route.responses {
+		body := res.body
+		// Errors documented without a body have the error envelope.
+		if body == nil && res.content == nil && res.status >= http.StatusBadRequest {
+			body = errorBody{}
+		}
+
+		response := &openapi.Response{Description: res.description, Headers: res.headers, Content: res.content}
+		switch {
+		case body != nil && res.status >= http.StatusBadRequest:
+			response.Content = errorContent(gen, body)
+		case body != nil:
+			response.Content = map[string]*openapi.MediaType{
+				"application/json": {Schema: gen.Schema(reflect.TypeOf(body), openapi.ForResponse)},
+			}
+		}
+		op.Responses[strconv.Itoa(res.status)] = response
+	}
+
+	// Every route may fail these ways, those reading input these too.
+	refs := map[int]string{
+		http.StatusTooManyRequests:     "RateLimited",
+		http.StatusInternalServerError: "InternalServerError",
+	}
+	if route.params != nil || route.body != nil || route.requestBody != nil {
+		refs[http.StatusBadRequest] = "BadRequest"
+		refs[http.StatusUnprocessableEntity] = "ValidationError"
+	}
+	for status, name := range refs {
+		if _, ok := op.Responses[strconv.Itoa(status)]; !ok {
+			op.Responses[strconv.Itoa(status)] = &openapi.Response{Ref: "#/components/responses/" + name}
+		}
+	}
+
+	return op
+}
+
+func errorComponent(gen *openapi.Generator, description string, body any) *openapi.Response {
+	return &openapi.Response{Description: description, Content: errorContent(gen, body)}
+}
+
+// errorContent is the envelope of an error, or its problem details for
+// clients asking for them.
+func errorContent(gen *openapi.Generator, body any) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{
+		"application/json": {Schema: gen.Schema(reflect.TypeOf(body), openapi.ForResponse)},
+		problemJSON:        {Schema: gen.Schema(reflect.TypeFor[problemBody](), openapi.ForResponse)},
+	}
+}
+
+func textContent(contentTypes ...string) map[string]*openapi.MediaType {
+	content := map[string]*openapi.MediaType{}
+	for _, contentType := range contentTypes {
+		content[contentType] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+	}
+	return content
+}
+
+var echoParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath turns the echo path /transactions/:id into /transactions/{id}.
+func openAPIPath(path string) string {
+	return echoParam.ReplaceAllString(path, "{$1}")
+}
+
+// isUndocumentedRoute reports whether the route serves the documentation.
+func isUndocumentedRoute(method, path string) bool {
+	return slices.Contains(undocumentedRoutes, method+" "+path)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
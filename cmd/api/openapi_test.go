@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/cmd/api/dto"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/openapi"
+	"github.com/ucok-man/tcsa/internal/serializer"
+)
+
+func TestOpenAPI(t *testing.T) {
+	// Setup
+	embedded, err := swaggerFile.ReadFile("docs/swagger.yaml")
+	require.NoError(t, err)
+
+	spec, err := openapi.Unmarshal(embedded)
+	require.NoError(t, err)
+
+	app := createTestApp(t, data.NewMemoryModels())
+	router := app.routes().(*echo.Echo)
+
+	t.Run("embeds the generated document", func(t *testing.T) {
+		// Execute
+		generated, err := openapi.Marshal(newOpenAPIDocument())
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, string(generated), string(embedded), "docs/swagger.yaml is stale, run `make openapi`")
+	})
+
+	t.Run("documents every route of the router", func(t *testing.T) {
+		// Execute
+		var routed, documented []string
+		for _, route := range router.Routes() {
+			if !slices.Contains(standardMethods, route.Method) || isUndocumentedRoute(route.Method, route.Path) {
+				continue
+			}
+			routed = append(routed, route.Method+" "+openAPIPath(route.Path))
+		}
+		for path, item := range spec.Paths {
+			for method := range item.Operations() {
+				documented = append(documented, method+" "+path)
+			}
+		}
+
+		// Assert
+		assert.ElementsMatch(t, routed, documented)
+	})
+
+	t.Run("documents the parameters the handlers bind", func(t *testing.T) {
+		gen := openapi.NewGenerator()
+
+		for _, route := range apiRoutes() {
+			path := openAPIPath(route.path)
+			item, ok := spec.Paths[path]
+			require.True(t, ok, path)
+			op := item.Operation(route.method)
+			require.NotNil(t, op, route.method+" "+path)
+
+			// Execute
+			var bound []*openapi.Parameter
+			if route.params != nil {
+				bound = gen.Parameters(reflect.TypeOf(route.params))
+			}
+			bound = append(bound, route.headers...)
+
+			// Assert
+			assert.Equal(t, parameterNames(bound), parameterNames(op.Parameters), route.method+" "+path)
+			assert.ElementsMatch(t, pathParams(path), parameterNamesIn(op.Parameters, "path"), route.method+" "+path)
+		}
+	})
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	// Setup
+	app := createTestApp(t, data.NewMemoryModels())
+	app.config.Database.Storage = "memory"
+	app.config.Batch.MaxItems = 3
+	app.config.Batch.MaxBodyBytes = 2 << 20
+
+	router := app.routes().(*echo.Echo)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, body string) (int, map[string]string) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var response struct {
+			Error struct {
+				Details map[string]string `json:"details"`
+			} `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&response)
+		return res.StatusCode, response.Error.Details
+	}
+
+	t.Run("rejects parameters against the document", func(t *testing.T) {
+		// Execute
+		status, details := do(http.MethodGet, "/transactions?page=0&sort_by=-version&user_id=one", "")
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, map[string]string{
+			"page":    "page must be 1 or greater",
+			"sort_by": "sort_by must be one of [id user_id amount status created_at -id -user_id -amount -status -created_at]",
+			"user_id": "user_id must be an integer",
+		}, details)
+	})
+
+	t.Run("rejects bodies against the document", func(t *testing.T) {
+		// Execute
+		status, details := do(http.MethodPost, "/transactions/bulk-update", `{"items":[{"id":"1"},{"version":2}],"status":"settled"}`)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, map[string]string{
+			"items[0].id": "id must be an integer",
+			"items[1].id": "id is a required field",
+			"status":      "status must be one of [pending failed success]",
+		}, details)
+	})
+
+	t.Run("rejects bodies up to the limit of their route", func(t *testing.T) {
+		// Setup
+		body := `{"items":[{"user_id":"one","amount":100}]` + strings.Repeat(" ", int(serializer.DefaultMaxBytes)) + `}`
+
+		// Execute
+		status, details := do(http.MethodPost, "/transactions/batch", body)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, map[string]string{
+			"items[0].user_id": "user_id must be an integer",
+		}, details)
+	})
+
+	t.Run("replaces responses that don't match the document", func(t *testing.T) {
+		// Setup
+		router.GET("/healthcheck", func(ctx echo.Context) error {
+			return ctx.JSON(http.StatusOK, envelope{"status": true})
+		})
+
+		// Execute
+		status, details := do(http.MethodGet, "/healthcheck", "")
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, map[string]string{
+			"status":      "status must be a string",
+			"system_info": "system_info is a required field",
+		}, details)
+	})
+
+	t.Run("replaces responses as problem details when asked", func(t *testing.T) {
+		// Setup
+		router.GET("/healthcheck", func(ctx echo.Context) error {
+			return ctx.JSON(http.StatusOK, envelope{"status": true})
+		})
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/healthcheck", nil)
+		require.NoError(t, err)
+		req.Header.Set(echo.HeaderAccept, problemJSON)
+
+		// Execute
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, problemJSON, res.Header.Get(echo.HeaderContentType))
+
+		var body problem
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, codeInternalError, body.Code)
+		assert.Equal(t, "the response does not match the openapi document", body.Detail)
+		assert.Contains(t, body.Errors, "system_info")
+	})
+
+	t.Run("documents batch items as the DTO", func(t *testing.T) {
+		// Setup
+		gen := openapi.NewGenerator()
+
+		// Execute
+		documented := gen.Schema(reflect.TypeFor[batchCreateBody](), openapi.ForRequest)
+		bound := gen.Schema(reflect.TypeFor[dto.TransactionBatchCreateDTO](), openapi.ForRequest)
+
+		// Assert
+		components := gen.Components()
+		documentedItem := components[strings.TrimPrefix(documented.Ref, "#/components/schemas/")].Properties.Get("items").Items
+		boundItem := components["TransactionCreateRequest"]
+		require.NotNil(t, bound)
+		assert.Equal(t, propertyNames(boundItem), propertyNames(documentedItem))
+	})
+}
+
+func propertyNames(schema *openapi.Schema) []string {
+	var names []string
+	for _, prop := range schema.Properties {
+		names = append(names, prop.Name)
+	}
+	return names
+}
+
+var standardMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch,
+}
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+func pathParams(path string) []string {
+	var names []string
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func parameterNames(params []*openapi.Parameter) []string {
+	var names []string
+	for _, param := range params {
+		names = append(names, param.In+":"+param.Name)
+	}
+	return names
+}
+
+func parameterNamesIn(params []*openapi.Parameter, in string) []string {
+	var names []string
+	for _, name := range parameterNames(params) {
+		if after, ok := strings.CutPrefix(name, in+":"); ok {
+			names = append(names, after)
+		}
+	}
+	return names
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+// liveConfig returns the latest configuration. Fields tagged `reload:"true"`
+// may differ from app.config after a reload, every other field never changes
+// while the process runs.
+func (app *application) liveConfig() *Config {
+	if cfg := app.live.Load(); cfg != nil {
+		return cfg
+	}
+	return &app.config
+}
+
+func (app *application) featureEnabled(name string) bool {
+	return slices.Contains(app.liveConfig().Features.Flags, name)
+}
+
+// applyConfig publishes cfg to every reader of liveConfig at once.
+func (app *application) applyConfig(cfg *Config) {
+	if lvl, err := tlog.ParseLevel(cfg.Log.Level); err == nil {
+		app.logger.SetLevel(lvl)
+	}
+	app.live.Store(cfg)
+}
+
+// reloadConfig loads the configuration again and applies its reloadable
+// fields. An invalid configuration is rejected as a whole.
+func (app *application) reloadConfig() error {
+	next, err := app.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	merged, rejected := mergeReloadable(*app.liveConfig(), next)
+	if len(rejected) > 0 {
+		app.logger.Warnj(tlog.JSON{
+			"message": "ignoring configuration changes that require a restart",
+			"keys":    rejected,
+		})
+	}
+
+	app.applyConfig(&merged)
+	return nil
+}
+
+// mergeReloadable copies the fields tagged `reload:"true"` from next into a
+// copy of current. It returns the keys of the other fields that differ.
+func mergeReloadable(current, next Config) (Config, []string) {
+	var rejected []string
+
+	var walk func(dst, src reflect.Value)
+	walk = func(dst, src reflect.Value) {
+		t := dst.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			key := field.Tag.Get("mapstructure")
+
+			if field.Type.Kind() == reflect.Struct && key == ",squash" {
+				walk(dst.Field(i), src.Field(i))
+				continue
+			}
+
+			if field.Tag.Get("reload") == "true" {
+				dst.Field(i).Set(src.Field(i))
+				continue
+			}
+
+			if !reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()) {
+				rejected = append(rejected, key)
+			}
+		}
+	}
+
+	walk(reflect.ValueOf(&current).Elem(), reflect.ValueOf(next))
+	return current, rejected
+}
+
+// watchConfig reloads the configuration on SIGHUP and whenever the config
+// file changes, until ctx is done.
+func (app *application) watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		fileEvents <-chan fsnotify.Event
+		fileErrors <-chan error
+	)
+	if app.loader.file != "" {
+		watcher, err := app.newConfigWatcher()
+		if err != nil {
+			app.logger.Errorj(tlog.JSON{"message": "unable to watch config file", "error": err})
+		} else {
+			defer watcher.Close()
+			fileEvents = watcher.Events
+			fileErrors = watcher.Errors
+		}
+	}
+
+	// Coalesce the burst of events a single save produces.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+
+	reload := func(trigger string) {
+		err := app.reloadConfig()
+		if err != nil {
+			app.logger.Errorj(tlog.JSON{"message": "rejected configuration reload", "trigger": trigger, "error": err})
+			return
+		}
+		app.logger.Infoj(tlog.JSON{"message": "configuration reloaded", "trigger": trigger})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+
+		case <-hup:
+			reload("SIGHUP")
+
+		case event := <-fileEvents:
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(app.loader.file) &&
+				filepath.Base(event.Name) != "..data" {
+				continue
+			}
+			debounce.Reset(200 * time.Millisecond)
+
+		case err := <-fileErrors:
+			app.logger.Errorj(tlog.JSON{"message": "config file watcher failed", "error": err})
+
+		case <-debounce.C:
+			reload("file change")
+		}
+	}
+}
+
+func (app *application) newConfigWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Watch the directory rather than the file, editors and Kubernetes
+	// config maps replace the file instead of writing to it.
+	err = watcher.Add(filepath.Dir(app.loader.file))
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
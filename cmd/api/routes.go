@@ -16,13 +16,22 @@ func (app *application) routes() http.Handler {
 	ec.HTTPErrorHandler = app.HTTPErrorHandler
 
 	ec.Use(app.withRecover())
+	ec.Use(app.withClientIdentity())
 	ec.Use(app.withCORS())
 	ec.Use(app.withRequestLogger())
+	ec.Use(app.withRateLimit())
+	ec.Use(app.withBodyLimits(map[string]int64{
+		"/transactions/batch": app.config.Batch.MaxBodyBytes,
+	}))
+	ec.Use(app.withOpenAPIValidation())
 
 	// Documentation routes
 
-	ec.FileFS("/swagger.yaml", "docs/swagger.yaml", swaggerFile)
-	ec.GET("/docs", app.serveSwaggerUI)
+	if app.config.Docs.Enabled {
+		ec.GET("/swagger.yaml", app.serveSwaggerHandler)
+		ec.GET("/docs", app.serveDocsHandler)
+		ec.GET("/docs/assets/:name", app.serveDocsAssetHandler)
+	}
 
 	// Health check
 	ec.GET("/healthcheck", app.healthcheckHandler)
@@ -32,15 +41,39 @@ func (app *application) routes() http.Handler {
 	{
 		transactions.GET("", app.getAllTransactionHandler)
 		transactions.POST("", app.createTransactionHandler)
+		transactions.POST("/batch", app.createBatchTransactionHandler)
+		transactions.POST("/bulk-update", app.bulkUpdateTransactionHandler)
+		transactions.POST("/bulk-delete", app.bulkDeleteTransactionHandler)
+		transactions.GET("/stream", app.streamTransactionHandler, app.withDatabase())
+		transactions.GET("/export", app.exportTransactionHandler)
+		transactions.POST("/import", app.importTransactionHandler)
 		transactions.GET("/:id", app.getByIdTransactionHandler)
 		transactions.PUT("/:id", app.updateByIdTransactionHandler)
 		transactions.DELETE("/:id", app.removeByIdTransactionHandler)
 	}
 
+	// Webhook routes
+	webhooks := ec.Group("/webhooks", app.withDatabase())
+	{
+		webhooks.GET("", app.getAllWebhookHandler)
+		webhooks.POST("", app.createWebhookHandler)
+		webhooks.GET("/:id", app.getByIdWebhookHandler)
+		webhooks.DELETE("/:id", app.removeByIdWebhookHandler)
+		webhooks.GET("/:id/deliveries", app.getAllWebhookDeliveryHandler)
+	}
+
+	// Job routes
+	jobs := ec.Group("/jobs", app.withDatabase())
+	{
+		jobs.GET("/:id", app.getByIdJobHandler)
+		jobs.GET("/:id/result", app.getResultJobHandler)
+	}
+
 	// Dashboard routes
 	dashboard := ec.Group("/dashboard")
 	{
 		dashboard.GET("/summary", app.summaryTransactionHandler)
+		dashboard.GET("/summary/ws", app.summaryWebSocketHandler)
 	}
 
 	return ec
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func TestRoutesInMemory(t *testing.T) {
+	// Setup
+	app := createTestApp(t, data.NewMemoryModels())
+	app.config.Database.Storage = "memory"
+
+	do := serveTestApp(t, app)
+
+	t.Run("serves transactions without a database", func(t *testing.T) {
+		// Execute
+		created := do(http.MethodPost, "/transactions", `{"user_id":1,"amount":100}`)
+		do(http.MethodPost, "/transactions", `{"user_id":2,"amount":300}`)
+		updated := do(http.MethodPut, "/transactions/1", `{"status":"success"}`)
+		listed := do(http.MethodGet, "/transactions?sort_by=-amount", "")
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, created.StatusCode)
+		assert.Equal(t, http.StatusOK, updated.StatusCode)
+		require.Equal(t, http.StatusOK, listed.StatusCode)
+
+		var response struct {
+			Data []struct {
+				ID      int    `json:"id"`
+				Status  string `json:"status"`
+				Version int    `json:"version"`
+			} `json:"data"`
+			Metadata struct {
+				TotalRecords int `json:"total_records"`
+			} `json:"metadata"`
+		}
+		require.NoError(t, json.NewDecoder(listed.Body).Decode(&response))
+
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, 2, response.Data[0].ID)
+		assert.Equal(t, 1, response.Data[1].ID)
+		assert.Equal(t, "success", response.Data[1].Status)
+		assert.Equal(t, 2, response.Data[1].Version)
+		assert.Equal(t, 2, response.Metadata.TotalRecords)
+	})
+
+	t.Run("answers 503 for routes needing a database", func(t *testing.T) {
+		for _, path := range []string{"/webhooks", "/jobs/1", "/transactions/stream"} {
+			// Execute
+			res := do(http.MethodGet, path, "")
+
+			// Assert
+			assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode, path)
+		}
+	})
+}
@@ -9,8 +9,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/ucok-man/tcsa/internal/stream"
 	"github.com/ucok-man/tcsa/internal/tlog"
 )
 
@@ -18,14 +18,49 @@ func (app *application) serve() error {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.config.Port),
 		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  app.config.Server.IdleTimeout,
+		ReadTimeout:  app.config.Server.ReadTimeout,
+		WriteTimeout: app.config.Server.WriteTimeout,
 		ErrorLog:     stdlog.New(app.logger, "", 0),
+		Protocols:    new(http.Protocols),
 	}
 
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetUnencryptedHTTP2(app.config.TLS.H2C)
+
+	if app.config.tlsEnabled() {
+		reloader, err := newCertReloader(app.config.TLS.CertFile, app.config.TLS.KeyFile, app.logger)
+		if err != nil {
+			return err
+		}
+
+		srv.TLSConfig, err = newTLSConfig(app.config, reloader)
+		if err != nil {
+			return err
+		}
+		srv.Protocols.SetHTTP2(true)
+	}
+
+	// Shutdown waits for open requests, end the event streams first.
+	srv.RegisterOnShutdown(app.broker.Close)
+
 	shutdownError := make(chan error)
 
+	// ctx stops the background workers once the server stopped accepting
+	// requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if app.loader != nil {
+		go app.watchConfig(ctx)
+	}
+
+	// Without a database there are no events to relay or stream, and no
+	// webhooks or jobs to run.
+	if !app.config.inMemory() {
+		app.startWorkers(ctx)
+	}
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -35,19 +70,37 @@ func (app *application) serve() error {
 
 		app.logger.Infoj(tlog.JSON{"message": "shutting down server", "signal": s.String()})
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), app.config.Server.ShutdownTimeout)
+		defer shutdownCancel()
+
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			shutdownError <- err
+			return
+		}
 
-		shutdownError <- srv.Shutdown(ctx)
 		app.logger.Infoj(tlog.JSON{"message": "completing background tasks", "addr": srv.Addr})
 
+		cancel()
 		app.wg.Wait()
 		shutdownError <- nil
 	}()
 
-	app.logger.Infoj(tlog.JSON{"message": "starting server", "addr": srv.Addr, "env": app.config.Env})
+	app.logger.Infoj(tlog.JSON{
+		"message": "starting server",
+		"addr":    srv.Addr,
+		"env":     app.config.Env,
+		"tls":     app.config.tlsEnabled(),
+		"h2c":     app.config.TLS.H2C,
+	})
 
-	err := srv.ListenAndServe()
+	var err error
+	if app.config.tlsEnabled() {
+		// The certificate comes from TLSConfig.GetCertificate.
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -60,3 +113,41 @@ func (app *application) serve() error {
 	app.logger.Info(tlog.JSON{"message": "server stopped", "addr": srv.Addr})
 	return nil
 }
+
+// startWorkers starts the background tasks working off the database, they
+// stop once ctx is done and are waited for through app.wg.
+func (app *application) startWorkers(ctx context.Context) {
+	// The relay drains the outbox once ctx is done, so it is waited for
+	// like any other background task.
+	if app.config.Outbox.Enabled {
+		app.wg.Add(1)
+		go func() {
+			defer app.wg.Done()
+			app.newOutboxRelay().Run(ctx)
+		}()
+	}
+
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		stream.NewListener(app.config.Database.Dsn, app.models.Outbox, app.broker, app.logger).Run(ctx)
+	}()
+
+	if app.config.Webhook.Enabled {
+		app.wg.Add(1)
+		go func() {
+			defer app.wg.Done()
+			app.newWebhookDispatcher().Run(ctx)
+		}()
+	}
+
+	// Running jobs see ctx done and checkpoint, or finish, before the pool
+	// returns.
+	if app.config.Jobs.Enabled {
+		app.wg.Add(1)
+		go func() {
+			defer app.wg.Done()
+			app.newJobPool().Run(ctx)
+		}()
+	}
+}
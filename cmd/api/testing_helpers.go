@@ -10,6 +10,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/ucok-man/tcsa/internal/data"
 	"github.com/ucok-man/tcsa/internal/serializer"
+	"github.com/ucok-man/tcsa/internal/stream"
 	"github.com/ucok-man/tcsa/internal/tlog"
 	"github.com/ucok-man/tcsa/internal/validator"
 )
@@ -21,14 +22,20 @@ func createTestApp(t *testing.T, mock data.Models) *application {
 	logger := tlog.Must(tlog.NewDevelopment())
 	logger.SetOutput(&bytes.Buffer{})
 
-	return &application{
+	app := &application{
 		config: Config{
 			Port: 3000,
 			Env:  "test",
 		},
 		logger: logger,
 		models: mock,
+		broker: stream.NewBroker(),
 	}
+
+	// Requests and responses of app.routes() must match the OpenAPI document.
+	app.config.OpenAPI.ValidateResponses = true
+	app.config.OpenAPI.ValidateRequests = true
+	return app
 }
 
 // createTestContext creates a new Echo context for testing
@@ -50,3 +57,31 @@ func createTestContext(method, path string, body string) (echo.Context, *httptes
 
 	return c, rec
 }
+
+// serveTestApp serves the routes of app and returns a function sending
+// requests to them. Response bodies are closed when the test ends.
+func serveTestApp(t *testing.T, app *application) func(method, path, body string) *http.Response {
+	t.Helper()
+
+	srv := httptest.NewServer(app.routes())
+	t.Cleanup(srv.Close)
+
+	return func(method, path, body string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+}
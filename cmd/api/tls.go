@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+// tlsCipherSuites only allows forward secret AEAD suites for TLS 1.2. TLS 1.3
+// suites are not configurable and are all acceptable.
+var tlsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+func (cfg Config) tlsEnabled() bool {
+	return cfg.TLS.CertFile != "" && cfg.TLS.KeyFile != ""
+}
+
+// newTLSConfig builds the server TLS configuration. The certificate is served
+// through reloader, so it is picked up again whenever its files change.
+func newTLSConfig(cfg Config, reloader *certReloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     tlsCipherSuites,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		GetCertificate:   reloader.GetCertificate,
+		ClientAuth:       tls.NoClientCert,
+	}
+
+	if cfg.TLS.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.TLS.ClientAuth == "none" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLS.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA bundle contains no certificates")
+	}
+	tlsConfig.ClientCAs = pool
+
+	switch cfg.TLS.ClientAuth {
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// certReloader serves a certificate key pair from disk, and loads it again
+// when either file's modification time changes. Files are checked at most
+// once per checkInterval, and a pair that fails to load keeps the previous
+// certificate in use.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *tlog.Logger
+
+	checkInterval time.Duration
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+func newCertReloader(certFile, keyFile string, logger *tlog.Logger) (*certReloader, error) {
+	reloader := &certReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		logger:        logger,
+		checkInterval: 5 * time.Second,
+	}
+
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	due := time.Since(r.lastCheck) >= r.checkInterval
+	r.mu.RUnlock()
+
+	if due {
+		r.maybeReload()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) maybeReload() {
+	certInfo, certErr := os.Stat(r.certFile)
+	keyInfo, keyErr := os.Stat(r.keyFile)
+
+	r.mu.Lock()
+	r.lastCheck = time.Now()
+	changed := certErr == nil && keyErr == nil &&
+		(!certInfo.ModTime().Equal(r.certModTime) || !keyInfo.ModTime().Equal(r.keyModTime))
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		r.logger.Errorj(tlog.JSON{"message": "failed reloading tls certificate", "error": err})
+		return
+	}
+	r.logger.Infoj(tlog.JSON{"message": "tls certificate reloaded", "cert_file": r.certFile})
+}
+
+func (r *certReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// clientIdentity describes the verified certificate a client presented
+// during the TLS handshake.
+type clientIdentity struct {
+	CommonName   string   `json:"common_name"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+	Issuer       string   `json:"issuer"`
+}
+
+const clientIdentityKey = "client_identity"
+
+// withClientIdentity stores the identity of a verified client certificate in
+// the request context, see clientIdentityFrom.
+func (app *application) withClientIdentity() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			state := ctx.Request().TLS
+			if state == nil || len(state.VerifiedChains) == 0 {
+				return next(ctx)
+			}
+
+			cert := state.VerifiedChains[0][0]
+			identity := &clientIdentity{
+				CommonName:   cert.Subject.CommonName,
+				DNSNames:     cert.DNSNames,
+				SerialNumber: cert.SerialNumber.String(),
+				Issuer:       cert.Issuer.String(),
+			}
+			for _, uri := range cert.URIs {
+				identity.URIs = append(identity.URIs, uri.String())
+			}
+
+			ctx.Set(clientIdentityKey, identity)
+			return next(ctx)
+		}
+	}
+}
+
+// clientIdentityFrom returns the identity of the client certificate, or nil
+// when the client did not present a verified one.
+func clientIdentityFrom(ctx echo.Context) *clientIdentity {
+	identity, _ := ctx.Get(clientIdentityKey).(*clientIdentity)
+	return identity
+}
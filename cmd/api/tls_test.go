@@ -0,0 +1,245 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// createTestCert writes a certificate signed by parent, or a self-signed CA
+// when parent is nil, to dir.
+func createTestCert(t *testing.T, dir, name string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, os.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return tc
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	app := createTestApp(t, data.Models{})
+
+	first := createTestCert(t, dir, "server", nil)
+
+	reloader, err := newCertReloader(first.certFile, first.keyFile, app.logger)
+	require.NoError(t, err)
+	reloader.checkInterval = 0
+
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	t.Run("reloads changed files", func(t *testing.T) {
+		second := createTestCert(t, dir, "server", nil)
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(second.certFile, later, later))
+		require.NoError(t, os.Chtimes(second.keyFile, later, later))
+
+		cert, err := reloader.GetCertificate(nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+	})
+
+	t.Run("keeps certificate when reload fails", func(t *testing.T) {
+		current, err := reloader.GetCertificate(nil)
+		require.NoError(t, err)
+
+		later := time.Now().Add(2 * time.Minute)
+		require.NoError(t, os.WriteFile(first.keyFile, []byte("not a key"), 0o600))
+		require.NoError(t, os.Chtimes(first.keyFile, later, later))
+
+		cert, err := reloader.GetCertificate(nil)
+
+		require.NoError(t, err)
+		assert.Same(t, current, cert)
+	})
+
+	t.Run("returns error for missing files", func(t *testing.T) {
+		_, err := newCertReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), app.logger)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	app := createTestApp(t, data.Models{})
+	ca := createTestCert(t, dir, "ca", nil)
+	server := createTestCert(t, dir, "server", ca)
+
+	reloader, err := newCertReloader(server.certFile, server.keyFile, app.logger)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		minVersion string
+		clientAuth string
+		expected   tls.ClientAuthType
+		minTLS     uint16
+	}{
+		{name: "without client auth", minVersion: "1.2", clientAuth: "none", expected: tls.NoClientCert, minTLS: tls.VersionTLS12},
+		{name: "optional client auth", minVersion: "1.2", clientAuth: "optional", expected: tls.VerifyClientCertIfGiven, minTLS: tls.VersionTLS12},
+		{name: "required client auth", minVersion: "1.3", clientAuth: "require", expected: tls.RequireAndVerifyClientCert, minTLS: tls.VersionTLS13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cfg.TLS.MinVersion = tt.minVersion
+			cfg.TLS.ClientAuth = tt.clientAuth
+			cfg.TLS.ClientCAFile = ca.certFile
+
+			tlsConfig, err := newTLSConfig(cfg, reloader)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tlsConfig.ClientAuth)
+			assert.Equal(t, tt.minTLS, tlsConfig.MinVersion)
+			assert.Equal(t, tt.clientAuth != "none", tlsConfig.ClientCAs != nil)
+		})
+	}
+
+	t.Run("returns error for invalid client CA bundle", func(t *testing.T) {
+		var cfg Config
+		cfg.TLS.MinVersion = "1.2"
+		cfg.TLS.ClientAuth = "require"
+		cfg.TLS.ClientCAFile = server.keyFile
+
+		_, err := newTLSConfig(cfg, reloader)
+
+		assert.ErrorContains(t, err, "no certificates")
+	})
+}
+
+func TestWithClientIdentity(t *testing.T) {
+	dir := t.TempDir()
+	app := createTestApp(t, data.Models{})
+	ca := createTestCert(t, dir, "ca", nil)
+	server := createTestCert(t, dir, "server", ca)
+	client := createTestCert(t, dir, "billing-service", ca)
+
+	reloader, err := newCertReloader(server.certFile, server.keyFile, app.logger)
+	require.NoError(t, err)
+
+	var cfg Config
+	cfg.TLS.MinVersion = "1.2"
+	cfg.TLS.ClientAuth = "optional"
+	cfg.TLS.ClientCAFile = ca.certFile
+	tlsConfig, err := newTLSConfig(cfg, reloader)
+	require.NoError(t, err)
+
+	ec := echo.New()
+	ec.Use(app.withClientIdentity())
+	ec.GET("/", func(ctx echo.Context) error {
+		identity := clientIdentityFrom(ctx)
+		if identity == nil {
+			return ctx.String(http.StatusOK, "anonymous")
+		}
+		return ctx.String(http.StatusOK, identity.CommonName)
+	})
+
+	// httptest.Server.StartTLS would add its own certificate, which takes
+	// precedence over GetCertificate.
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: ec, ErrorLog: log.New(io.Discard, "", 0)}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	url := "https://" + ln.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(t *testing.T, certs []tls.Certificate) string {
+		t.Helper()
+		httpClient := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+
+		res, err := httpClient.Get(url)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("exposes client certificate identity", func(t *testing.T) {
+		pair, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, "billing-service", get(t, []tls.Certificate{pair}))
+	})
+
+	t.Run("allows clients without certificate", func(t *testing.T) {
+		assert.Equal(t, "anonymous", get(t, nil))
+	})
+}
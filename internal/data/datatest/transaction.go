@@ -0,0 +1,558 @@
+// Package datatest holds the suites every implementation of the data
+// interfaces has to pass. It is only imported by tests.
+package datatest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// RunTransactionModelerSuite checks that a data.TransactionModeler behaves
+// like data.TransactionModel, which is what the handlers and their mocks
+// assume. newModel is called by every subtest and must return a model holding
+// no transactions.
+func RunTransactionModelerSuite(t *testing.T, newModel func(t *testing.T) data.TransactionModeler) {
+	t.Run("inserts and gets transaction", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		transaction := &data.Transaction{UserId: 1, Amount: 100, Status: data.TransactionStatusPending}
+
+		// Execute
+		err := m.Insert(transaction)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Positive(t, transaction.ID)
+		assert.Equal(t, 1, transaction.Version)
+		assert.False(t, transaction.CreatedAt.IsZero())
+		assert.True(t, transaction.CreatedAt.Equal(transaction.UpdatedAt))
+
+		stored, err := m.GetById(transaction.ID)
+		require.NoError(t, err)
+		assertSameTransaction(t, transaction, stored)
+	})
+
+	t.Run("inserts many with distinct ids", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		transactions := []*data.Transaction{
+			{UserId: 1, Amount: 100, Status: data.TransactionStatusPending},
+			{UserId: 2, Amount: 200, Status: data.TransactionStatusSucces},
+		}
+
+		// Execute
+		err := m.InsertMany(transactions)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotEqual(t, transactions[0].ID, transactions[1].ID)
+		for _, transaction := range transactions {
+			stored, err := m.GetById(transaction.ID)
+			require.NoError(t, err)
+			assertSameTransaction(t, transaction, stored)
+		}
+	})
+
+	t.Run("inserts nothing when one transaction is invalid", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+
+		// Execute
+		err := m.InsertMany([]*data.Transaction{
+			{UserId: 1, Amount: 100, Status: data.TransactionStatusPending},
+			{UserId: 1, Amount: 100, Status: "refunded"},
+		})
+
+		// Assert
+		require.Error(t, err)
+		transactions, _, err := m.GetAll(conformanceGetAllParam(1, 10))
+		require.NoError(t, err)
+		assert.Empty(t, transactions)
+	})
+
+	t.Run("returns not found for unknown id", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusPending))
+
+		for _, id := range []int{0, -1, ids[0] + 1000} {
+			// Execute
+			_, err := m.GetById(id)
+
+			// Assert
+			assert.ErrorIs(t, err, data.ErrRecordNotFound, "id %d", id)
+		}
+	})
+
+	t.Run("updates at current version", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusPending))
+
+		transaction, err := m.GetById(ids[0])
+		require.NoError(t, err)
+
+		transaction.Amount = 250
+		transaction.Status = data.TransactionStatusSucces
+		transaction.UpdatedAt = transaction.CreatedAt.Add(time.Minute)
+
+		// Execute
+		err = m.Update(transaction)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, transaction.Version)
+
+		stored, err := m.GetById(ids[0])
+		require.NoError(t, err)
+		assertSameTransaction(t, transaction, stored)
+	})
+
+	t.Run("returns edit conflict for stale version", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusPending))
+
+		first, err := m.GetById(ids[0])
+		require.NoError(t, err)
+		second, err := m.GetById(ids[0])
+		require.NoError(t, err)
+
+		first.Amount = 200
+		require.NoError(t, m.Update(first))
+
+		// Execute
+		second.Amount = 300
+		err = m.Update(second)
+
+		// Assert
+		assert.ErrorIs(t, err, data.ErrEditConflict)
+
+		stored, err := m.GetById(ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, 200, stored.Amount)
+		assert.Equal(t, 2, stored.Version)
+	})
+
+	t.Run("returns edit conflict for unknown id", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+
+		// Execute
+		err := m.Update(&data.Transaction{ID: 1, Version: 1, Amount: 100, Status: data.TransactionStatusPending, UpdatedAt: time.Now()})
+
+		// Assert
+		assert.ErrorIs(t, err, data.ErrEditConflict)
+	})
+
+	t.Run("lets one of concurrent updates win", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusPending))
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+
+		// Execute
+		for i := range 8 {
+			wg.Go(func() {
+				errs <- m.Update(&data.Transaction{
+					ID:        ids[0],
+					Version:   1,
+					Amount:    100 + i,
+					Status:    data.TransactionStatusPending,
+					UpdatedAt: time.Now().Truncate(time.Microsecond),
+				})
+			})
+		}
+		wg.Wait()
+		close(errs)
+
+		// Assert
+		var succeeded int
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, data.ErrEditConflict)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+
+	t.Run("deletes one transaction", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusPending))
+
+		// Execute
+		err := m.DeleteOne(ids[0])
+
+		// Assert
+		require.NoError(t, err)
+		_, err = m.GetById(ids[0])
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+		assert.ErrorIs(t, m.DeleteOne(ids[0]), data.ErrRecordNotFound)
+		assert.ErrorIs(t, m.DeleteOne(0), data.ErrRecordNotFound)
+	})
+
+	t.Run("deletes many by filter", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusFailed),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(2, 100, data.TransactionStatusFailed),
+		)
+
+		// Execute
+		deleted, err := m.DeleteMany(data.TransactionDeleteManyParam{
+			FilterStatus: string(data.TransactionStatusFailed),
+			FilterUserId: 1,
+		})
+		require.NoError(t, err)
+		none, err := m.DeleteMany(data.TransactionDeleteManyParam{
+			FilterCreatedBefore: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 1, deleted)
+		assert.Equal(t, 0, none)
+		assert.Equal(t, []int{ids[1], ids[2]}, conformanceIds(t, m, conformanceGetAllParam(1, 10)))
+	})
+
+	t.Run("sorts ties by id ascending", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 200, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+			conformanceTransaction(1, 200, data.TransactionStatusFailed),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 200, data.TransactionStatusSucces),
+		)
+
+		ascending := []int{ids[1], ids[3], ids[0], ids[2], ids[4]}
+		descending := []int{ids[0], ids[2], ids[4], ids[1], ids[3]}
+
+		for _, tc := range []struct {
+			direction string
+			want      []int
+		}{
+			{"ASC", ascending},
+			{"DESC", descending},
+		} {
+			param := conformanceGetAllParam(1, 10)
+			param.SortColumn = "amount"
+			param.SortDirection = tc.direction
+
+			// Execute
+			got := conformanceIds(t, m, param)
+
+			var exported []int
+			err := m.Export(context.Background(), data.TransactionExportParam{
+				SortColumn:    "amount",
+				SortDirection: tc.direction,
+			}, func(transaction *data.Transaction) error {
+				exported = append(exported, transaction.ID)
+				return nil
+			})
+			require.NoError(t, err)
+
+			summary, _, err := m.Summary(data.TransactionSummaryParam{
+				Page: 1, PageSize: 10, SortColumn: "amount", SortDirection: tc.direction,
+			})
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, tc.want, got, tc.direction)
+			assert.Equal(t, tc.want, exported, tc.direction)
+			assert.Equal(t, tc.want, transactionIds(summary.Transactions), tc.direction)
+		}
+	})
+
+	t.Run("filters by status and user", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(2, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+		)
+
+		param := conformanceGetAllParam(1, 10)
+		param.FilterStatus = string(data.TransactionStatusPending)
+		param.FilterUserId = 1
+
+		// Execute
+		got := conformanceIds(t, m, param)
+
+		// Assert
+		assert.Equal(t, []int{ids[0]}, got)
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		var transactions []*data.Transaction
+		for range 5 {
+			transactions = append(transactions, conformanceTransaction(1, 100, data.TransactionStatusPending))
+		}
+		ids := insertConformanceTransactions(t, m, transactions...)
+
+		tests := []struct {
+			name     string
+			page     int
+			pageSize int
+			want     []int
+			metadata data.Metadata
+		}{
+			{
+				name: "first page", page: 1, pageSize: 2,
+				want:     ids[0:2],
+				metadata: data.Metadata{CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+			},
+			{
+				name: "partial last page", page: 3, pageSize: 2,
+				want:     ids[4:5],
+				metadata: data.Metadata{CurrentPage: 3, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+			},
+			{
+				name: "full last page", page: 1, pageSize: 5,
+				want:     ids,
+				metadata: data.Metadata{CurrentPage: 1, PageSize: 5, FirstPage: 1, LastPage: 1, TotalRecords: 5},
+			},
+			{
+				name: "page larger than total", page: 1, pageSize: 100,
+				want:     ids,
+				metadata: data.Metadata{CurrentPage: 1, PageSize: 100, FirstPage: 1, LastPage: 1, TotalRecords: 5},
+			},
+			{
+				// The total comes with the rows, past the last page there
+				// is none.
+				name: "page past the last one", page: 4, pageSize: 2,
+				want:     nil,
+				metadata: data.Metadata{},
+			},
+		}
+
+		for _, tc := range tests {
+			// Execute
+			transactions, metadata, err := m.GetAll(conformanceGetAllParam(tc.page, tc.pageSize))
+
+			// Assert
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.want, transactionIds(transactions), tc.name)
+			assert.Equal(t, tc.metadata, *metadata, tc.name)
+		}
+	})
+
+	t.Run("summarizes status percentages", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusFailed),
+			conformanceTransaction(2, 100, data.TransactionStatusFailed),
+		)
+
+		// Execute
+		summary, metadata, err := m.Summary(data.TransactionSummaryParam{
+			Page: 1, PageSize: 2, SortColumn: "id", SortDirection: "ASC",
+			FilterDateRange: 1, FilterUserId: 1,
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, summary.Transactions, 2)
+		assert.Equal(t, 6, summary.Summary.CountTotal)
+		assert.Equal(t, 3, summary.Summary.Success.Count)
+		assert.Equal(t, 50.0, summary.Summary.Success.RatePercentage)
+		assert.Equal(t, 2, summary.Summary.Pending.Count)
+		assert.Equal(t, 33.33, summary.Summary.Pending.RatePercentage)
+		assert.Equal(t, 1, summary.Summary.Failed.Count)
+		assert.Equal(t, 16.67, summary.Summary.Failed.RatePercentage)
+		assert.Equal(t, data.Metadata{CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 6}, *metadata)
+	})
+
+	t.Run("summarizes nothing past the last page", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		insertConformanceTransactions(t, m, conformanceTransaction(1, 100, data.TransactionStatusSucces))
+
+		// Execute
+		summary, metadata, err := m.Summary(data.TransactionSummaryParam{
+			Page: 2, PageSize: 10, PageOffset: 10, SortColumn: "id", SortDirection: "ASC",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, summary.Transactions)
+		assert.Equal(t, data.Summary{}, summary.Summary)
+		assert.Equal(t, data.Metadata{}, *metadata)
+	})
+
+	t.Run("bulk updates status of targets", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusFailed),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+		)
+
+		// Execute
+		result, err := m.BulkUpdateStatus(data.TransactionBulkParam{
+			Targets: []data.TransactionBulkTarget{
+				{ID: ids[2]},
+				{ID: ids[1]},
+				{ID: ids[0], Version: 2},
+			},
+		}, data.TransactionStatusSucces)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Affected)
+		require.Len(t, result.Transactions, 1)
+		assert.Equal(t, ids[2], result.Transactions[0].ID)
+		assert.Equal(t, 2, result.Transactions[0].Version)
+		assert.Equal(t, data.TransactionStatusSucces, result.Transactions[0].Status)
+		assert.Equal(t, []data.TransactionBulkConflict{
+			{ID: ids[1], Reason: data.BulkConflictInvalidTransition, Version: 1, Status: data.TransactionStatusFailed},
+			{ID: ids[0], Reason: data.BulkConflictVersionMismatch, Version: 1, Status: data.TransactionStatusPending},
+		}, result.Conflicts)
+	})
+
+	t.Run("bulk updates by filter up to its limit", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+		)
+		param := data.TransactionBulkParam{FilterUserId: 1, Limit: 1}
+
+		// Execute
+		_, dryRunErr := m.BulkUpdateStatus(data.TransactionBulkParam{FilterUserId: 1, Limit: 1, DryRun: true}, data.TransactionStatusSucces)
+		_, err := m.BulkUpdateStatus(param, data.TransactionStatusSucces)
+		param.Limit = 2
+		result, limitErr := m.BulkUpdateStatus(param, data.TransactionStatusSucces)
+
+		// Assert
+		assert.ErrorIs(t, dryRunErr, data.ErrBulkLimitExceeded)
+		assert.ErrorIs(t, err, data.ErrBulkLimitExceeded)
+		require.NoError(t, limitErr, "transactions that can't change don't count")
+		assert.Equal(t, 2, result.Affected)
+	})
+
+	t.Run("bulk updates by filter to a status none can change to", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusFailed),
+			conformanceTransaction(1, 100, data.TransactionStatusSucces),
+		)
+
+		// Execute
+		dryRun, err := m.BulkUpdateStatus(data.TransactionBulkParam{FilterUserId: 1, Limit: 1, DryRun: true}, data.TransactionStatusPending)
+		require.NoError(t, err)
+		result, err := m.BulkUpdateStatus(data.TransactionBulkParam{FilterUserId: 1, Limit: 1}, data.TransactionStatusPending)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 0, dryRun.Affected)
+		assert.Equal(t, 0, result.Affected)
+		assert.Empty(t, result.Transactions)
+	})
+
+	t.Run("bulk deletes by filter", func(t *testing.T) {
+		// Setup
+		m := newModel(t)
+		ids := insertConformanceTransactions(t, m,
+			conformanceTransaction(1, 100, data.TransactionStatusPending),
+			conformanceTransaction(2, 100, data.TransactionStatusPending),
+		)
+
+		// Execute
+		dryRun, err := m.BulkDelete(data.TransactionBulkParam{FilterUserId: 1, DryRun: true})
+		require.NoError(t, err)
+		result, err := m.BulkDelete(data.TransactionBulkParam{FilterUserId: 1})
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 1, dryRun.Affected)
+		assert.Nil(t, dryRun.Transactions)
+		assert.Equal(t, 1, result.Affected)
+		assert.Equal(t, []int{ids[0]}, transactionIds(result.Transactions))
+		assert.Equal(t, []int{ids[1]}, conformanceIds(t, m, conformanceGetAllParam(1, 10)))
+	})
+}
+
+func conformanceTransaction(userId, amount int, status data.TransactionStatus) *data.Transaction {
+	return &data.Transaction{UserId: userId, Amount: amount, Status: status}
+}
+
+// insertConformanceTransactions inserts transactions one by one, so their
+// ids are in order, and returns the ids.
+func insertConformanceTransactions(t *testing.T, m data.TransactionModeler, transactions ...*data.Transaction) []int {
+	t.Helper()
+
+	ids := make([]int, len(transactions))
+	for i, transaction := range transactions {
+		require.NoError(t, m.Insert(transaction))
+		ids[i] = transaction.ID
+	}
+	return ids
+}
+
+func conformanceGetAllParam(page, pageSize int) data.TransactionGetAllParam {
+	return data.TransactionGetAllParam{
+		Page:          page,
+		PageSize:      pageSize,
+		PageOffset:    (page - 1) * pageSize,
+		SortColumn:    "id",
+		SortDirection: "ASC",
+	}
+}
+
+func conformanceIds(t *testing.T, m data.TransactionModeler, param data.TransactionGetAllParam) []int {
+	t.Helper()
+
+	transactions, _, err := m.GetAll(param)
+	require.NoError(t, err)
+	return transactionIds(transactions)
+}
+
+func transactionIds(transactions []*data.Transaction) []int {
+	var ids []int
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+	}
+	return ids
+}
+
+// assertSameTransaction compares timestamps by instant, the location depends
+// on where they were read from.
+func assertSameTransaction(t *testing.T, want, got *data.Transaction) {
+	t.Helper()
+
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.UserId, got.UserId)
+	assert.Equal(t, want.Amount, got.Amount)
+	assert.Equal(t, want.Status, got.Status)
+	assert.Equal(t, want.Version, got.Version)
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "created_at %s, got %s", want.CreatedAt, got.CreatedAt)
+	assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "updated_at %s, got %s", want.UpdatedAt, got.UpdatedAt)
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventTransactionCreated       EventType = "transaction.created"
+	EventTransactionUpdated       EventType = "transaction.updated"
+	EventTransactionStatusChanged EventType = "transaction.status_changed"
+	EventTransactionDeleted       EventType = "transaction.deleted"
+)
+
+// EventTypes lists every event a subscriber can receive.
+var EventTypes = []EventType{
+	EventTransactionCreated,
+	EventTransactionUpdated,
+	EventTransactionStatusChanged,
+	EventTransactionDeleted,
+}
+
+// Event is a transaction lifecycle change. ID is unique per event, so
+// receivers can drop an event they already processed.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       EventType       `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// TransactionEventData is the data of every transaction event. PreviousStatus
+// is only set for transaction.updated and transaction.status_changed.
+type TransactionEventData struct {
+	Transaction    *Transaction      `json:"transaction"`
+	PreviousStatus TransactionStatus `json:"previous_status,omitempty"`
+}
+
+func NewEvent(eventType EventType, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		ID:         newEventId(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       raw,
+	}, nil
+}
+
+// newEventId returns a random version 4 UUID.
+func newEventId() string {
+	var b [16]byte
+	rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,360 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work, see JobModeler. Payload, Progress,
+// Checkpoint and Result are JSON documents owned by the job's kind.
+type Job struct {
+	ID     int
+	Kind   string
+	Status JobStatus
+	// Payload describes the work and is set once, when the job is queued.
+	Payload json.RawMessage
+	// Progress is what a running job reports about itself.
+	Progress json.RawMessage
+	// Checkpoint is where a job resumes when it is run again.
+	Checkpoint  json.RawMessage
+	Result      json.RawMessage
+	LastError   string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// JobModeler stores the job queue. A running job is leased to the worker that
+// claimed it. Every method changing a running job is fenced by its attempt,
+// so a worker whose lease ran out and whose job was claimed again gets
+// ErrEditConflict instead of overwriting the new attempt.
+type JobModeler interface {
+	Insert(job *Job, input []byte) error
+	GetById(id int) (*Job, error)
+	GetInput(id int) ([]byte, error)
+	Claim(limit int, lease time.Duration) ([]*Job, error)
+	Heartbeat(job *Job, lease time.Duration) error
+	Complete(job *Job) error
+	Retry(job *Job, runAt time.Time, reason string) error
+	Fail(job *Job, reason string) error
+	Release(job *Job) error
+	DeleteFinished(before time.Time) (int, error)
+}
+
+type JobModel struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+const jobColumns = `
+	id, kind, status, payload, progress, checkpoint, result, last_error,
+	attempts, max_attempts, run_at, started_at, finished_at, created_at, updated_at`
+
+// Insert queues job. input is an optional blob for the job to read, it is
+// kept apart from the payload and only loaded by GetInput.
+func (m JobModel) Insert(job *Job, input []byte) error {
+	query := `
+		INSERT INTO jobs (kind, payload, input, max_attempts)
+		VALUES ($1, $2::jsonb, $3, $4)
+		RETURNING id, status, run_at, created_at, updated_at`
+
+	payload := job.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage(`{}`)
+	}
+
+	args := []any{job.Kind, string(payload), input, job.MaxAttempts}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.db.QueryRowContext(ctx, query, args...).Scan(
+		&job.ID,
+		&job.Status,
+		&job.RunAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+}
+
+func (m JobModel) GetById(id int) (*Job, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	job, err := m.scan(m.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+func (m JobModel) GetInput(id int) ([]byte, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `SELECT input FROM jobs WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	var input []byte
+	err := m.db.QueryRowContext(ctx, query, id).Scan(&input)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+// Claim starts up to limit due jobs, oldest first, and leases them for lease.
+// A running job whose lease ran out, because its worker died, is claimed
+// again as a new attempt.
+func (m JobModel) Claim(limit int, lease time.Duration) ([]*Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running',
+			attempts = attempts + 1,
+			locked_until = NOW() + $2::bigint * INTERVAL '1 millisecond',
+			started_at = COALESCE(started_at, NOW()),
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_at <= NOW())
+				OR (status = 'running' AND locked_until < NOW())
+			ORDER BY run_at ASC, id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+
+	for rows.Next() {
+		job, err := m.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(jobs, func(a, b *Job) int { return a.ID - b.ID })
+	return jobs, nil
+}
+
+// Heartbeat extends the lease of a running job and stores its progress and
+// checkpoint.
+func (m JobModel) Heartbeat(job *Job, lease time.Duration) error {
+	query := `
+		UPDATE jobs
+		SET locked_until = NOW() + $3::bigint * INTERVAL '1 millisecond',
+			progress = $4::jsonb,
+			checkpoint = $5::jsonb,
+			updated_at = NOW()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	args := []any{job.ID, job.Attempts, lease.Milliseconds(), nullJSON(job.Progress), nullJSON(job.Checkpoint)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOwned(m.db.ExecContext(ctx, query, args...))
+}
+
+// Complete finishes a running job with its result. Its input is dropped.
+func (m JobModel) Complete(job *Job) error {
+	query := `
+		UPDATE jobs
+		SET status = 'completed',
+			progress = $3::jsonb,
+			result = $4::jsonb,
+			last_error = '',
+			input = NULL,
+			locked_until = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	args := []any{job.ID, job.Attempts, nullJSON(job.Progress), nullJSON(job.Result)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOwned(m.db.ExecContext(ctx, query, args...))
+}
+
+// Retry queues a running job whose attempt failed, to run again at runAt.
+// Its checkpoint is kept.
+func (m JobModel) Retry(job *Job, runAt time.Time, reason string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'queued',
+			run_at = $3,
+			last_error = $4,
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOwned(m.db.ExecContext(ctx, query, job.ID, job.Attempts, runAt, reason))
+}
+
+// Fail gives up on a running job. Its input is dropped.
+func (m JobModel) Fail(job *Job, reason string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'failed',
+			last_error = $3,
+			input = NULL,
+			locked_until = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOwned(m.db.ExecContext(ctx, query, job.ID, job.Attempts, reason))
+}
+
+// Release queues a running job again right away without counting its
+// attempt, for a worker that stops before the job is done. The job resumes
+// from its last checkpoint.
+func (m JobModel) Release(job *Job) error {
+	query := `
+		UPDATE jobs
+		SET status = 'queued',
+			attempts = attempts - 1,
+			run_at = NOW(),
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOwned(m.db.ExecContext(ctx, query, job.ID, job.Attempts))
+}
+
+// DeleteFinished removes the jobs finished before the given time.
+func (m JobModel) DeleteFinished(before time.Time) (int, error) {
+	query := `DELETE FROM jobs WHERE finished_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+func (m JobModel) scan(row interface{ Scan(dest ...any) error }) (*Job, error) {
+	var job Job
+	var payload, progress, checkpoint, result []byte
+
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Status,
+		&payload,
+		&progress,
+		&checkpoint,
+		&result,
+		&job.LastError,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+	job.Progress = progress
+	job.Checkpoint = checkpoint
+	job.Result = result
+	return &job, nil
+}
+
+// expectOwned turns an update that matched no running attempt into
+// ErrEditConflict.
+func (m JobModel) expectOwned(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+	return nil
+}
+
+// nullJSON passes an empty document as SQL NULL.
+func nullJSON(document json.RawMessage) any {
+	if len(document) == 0 {
+		return nil
+	}
+	return string(document)
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockJobModel struct {
+	mock.Mock
+}
+
+func (m *MockJobModel) Insert(job *Job, input []byte) error {
+	args := m.Called(job, input)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) GetById(id int) (*Job, error) {
+	args := m.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Job), args.Error(1)
+}
+
+func (m *MockJobModel) GetInput(id int) ([]byte, error) {
+	args := m.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockJobModel) Claim(limit int, lease time.Duration) ([]*Job, error) {
+	args := m.Called(limit, lease)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*Job), args.Error(1)
+}
+
+func (m *MockJobModel) Heartbeat(job *Job, lease time.Duration) error {
+	args := m.Called(job, lease)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) Complete(job *Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) Retry(job *Job, runAt time.Time, reason string) error {
+	args := m.Called(job, runAt, reason)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) Fail(job *Job, reason string) error {
+	args := m.Called(job, reason)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) Release(job *Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockJobModel) DeleteFinished(before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
@@ -1,30 +1,63 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 )
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
+
+	// ErrBulkLimitExceeded is returned when the filter of a bulk operation
+	// selects more transactions than its limit.
+	ErrBulkLimitExceeded = errors.New("bulk limit exceeded")
 )
 
 type TransactionModeler interface {
 	Insert(transaction *Transaction) error
+	InsertMany(transactions []*Transaction) error
+	InsertImported(jobId int, rows []TransactionImportRow) error
 	GetAll(param TransactionGetAllParam) ([]*Transaction, *Metadata, error)
 	GetById(id int) (*Transaction, error)
+	Export(ctx context.Context, param TransactionExportParam, fn func(*Transaction) error) error
 	Update(transaction *Transaction) error
 	DeleteOne(id int) error
+	DeleteMany(param TransactionDeleteManyParam) (int, error)
+	BulkUpdateStatus(param TransactionBulkParam, status TransactionStatus) (*TransactionBulkResult, error)
+	BulkDelete(param TransactionBulkParam) (*TransactionBulkResult, error)
 	Summary(param TransactionSummaryParam) (*TransactionSummary, *Metadata, error)
 }
 
 type Models struct {
-	Transactions TransactionModeler
+	Transactions      TransactionModeler
+	Seeds             SeedModeler
+	Webhooks          WebhookModeler
+	WebhookDeliveries WebhookDeliveryModeler
+	Outbox            OutboxModeler
+	Jobs              JobModeler
+}
+
+// NewModels creates the Postgres backed models. Every query is bounded by
+// queryTimeout.
+func NewModels(db *sql.DB, queryTimeout time.Duration) Models {
+	return Models{
+		Transactions:      TransactionModel{db: db, queryTimeout: queryTimeout},
+		Seeds:             SeedModel{db: db},
+		Webhooks:          WebhookModel{db: db, queryTimeout: queryTimeout},
+		WebhookDeliveries: WebhookDeliveryModel{db: db, queryTimeout: queryTimeout},
+		Outbox:            OutboxModel{db: db, queryTimeout: queryTimeout},
+		Jobs:              JobModel{db: db, queryTimeout: queryTimeout},
+	}
 }
 
-func NewModels(db *sql.DB) Models {
+// NewMemoryModels creates models keeping the transactions in memory, for
+// running without a database. The other models are left unset, what needs
+// them is unavailable.
+func NewMemoryModels() Models {
 	return Models{
-		Transactions: TransactionModel{db: db},
+		Transactions: NewMemoryTransactionModel(),
 	}
 }
@@ -0,0 +1,240 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/pgtest"
+)
+
+func TestTransactionModelIntegration(t *testing.T) {
+	t.Run("records events with every change", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		transaction := &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}
+		require.NoError(t, models.Transactions.Insert(transaction))
+
+		transaction.Status = TransactionStatusSucces
+		transaction.UpdatedAt = time.Now().Truncate(time.Microsecond)
+		require.NoError(t, models.Transactions.Update(transaction))
+
+		// Execute
+		err := models.Transactions.DeleteOne(transaction.ID)
+
+		// Assert
+		require.NoError(t, err)
+
+		messages, err := models.Outbox.GetAfter(0, 10)
+		require.NoError(t, err)
+
+		var types []EventType
+		for _, message := range messages {
+			types = append(types, message.Event.Type)
+		}
+		assert.Equal(t, []EventType{
+			EventTransactionCreated,
+			EventTransactionUpdated,
+			EventTransactionStatusChanged,
+			EventTransactionDeleted,
+		}, types)
+
+		var data TransactionEventData
+		require.NoError(t, json.Unmarshal(messages[2].Event.Data, &data))
+		assert.Equal(t, transaction.ID, data.Transaction.ID)
+		assert.Equal(t, TransactionStatusPending, data.PreviousStatus)
+	})
+
+	t.Run("purges in batches with an event for every row", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		transactions := make([]*Transaction, deleteBatchSize+1)
+		for i := range transactions {
+			transactions[i] = &Transaction{UserId: 1, Amount: i + 1, Status: TransactionStatusPending}
+		}
+		require.NoError(t, models.Transactions.InsertMany(transactions))
+		require.NoError(t, models.Transactions.Insert(&Transaction{UserId: 2, Amount: 100, Status: TransactionStatusPending}))
+
+		// Execute
+		deleted, err := models.Transactions.DeleteMany(TransactionDeleteManyParam{FilterUserId: 1})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, deleteBatchSize+1, deleted)
+
+		messages, err := models.Outbox.GetAfter(0, 3*deleteBatchSize)
+		require.NoError(t, err)
+
+		ids := map[int]bool{}
+		for _, message := range messages {
+			if message.Event.Type != EventTransactionDeleted {
+				continue
+			}
+			var data TransactionEventData
+			require.NoError(t, json.Unmarshal(message.Event.Data, &data))
+			assert.Equal(t, 1, data.Transaction.UserId)
+			ids[data.Transaction.ID] = true
+		}
+		assert.Len(t, ids, deleteBatchSize+1)
+
+		_, err = models.Transactions.GetById(transactions[0].ID)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("imports every line of a job once", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		job := &Job{Kind: "test", MaxAttempts: 3}
+		require.NoError(t, models.Jobs.Insert(job, nil))
+		require.NoError(t, models.Transactions.InsertImported(job.ID, []TransactionImportRow{
+			{Line: 2, Transaction: &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}},
+		}))
+
+		// Execute
+		err := models.Transactions.InsertImported(job.ID, []TransactionImportRow{
+			{Line: 2, Transaction: &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}},
+			{Line: 3, Transaction: &Transaction{UserId: 1, Amount: 200, Status: TransactionStatusPending}},
+		})
+
+		// Assert
+		require.NoError(t, err)
+
+		messages, err := models.Outbox.GetAfter(0, 10)
+		require.NoError(t, err)
+		assert.Len(t, messages, 2)
+	})
+
+	t.Run("records nothing for a failed change", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		// Execute
+		err := models.Transactions.InsertMany([]*Transaction{
+			{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+			{UserId: 1, Amount: 100, Status: "refunded"},
+		})
+
+		// Assert
+		require.Error(t, err)
+
+		messages, err := models.Outbox.GetAfter(0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("exports past one cursor fetch", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		transactions := make([]*Transaction, exportFetchSize+1)
+		for i := range transactions {
+			transactions[i] = &Transaction{UserId: 1, Amount: i + 1, Status: TransactionStatusPending}
+		}
+		require.NoError(t, models.Transactions.InsertMany(transactions))
+
+		var exported []int
+
+		// Execute
+		err := models.Transactions.Export(context.Background(), TransactionExportParam{
+			SortColumn:    "amount",
+			SortDirection: "DESC",
+		}, func(transaction *Transaction) error {
+			exported = append(exported, transaction.Amount)
+			return nil
+		})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, exported, exportFetchSize+1)
+		assert.Equal(t, exportFetchSize+1, exported[0])
+		assert.Equal(t, 1, exported[exportFetchSize])
+	})
+}
+
+func TestOutboxModelIntegration(t *testing.T) {
+	t.Run("gets the messages after an ID since a time", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		transactions := []*Transaction{
+			{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+			{UserId: 1, Amount: 200, Status: TransactionStatusPending},
+		}
+		require.NoError(t, models.Transactions.InsertMany(transactions[:1]))
+		time.Sleep(10 * time.Millisecond)
+		since := time.Now()
+		require.NoError(t, models.Transactions.InsertMany(transactions[1:]))
+
+		// Execute
+		all, err := models.Outbox.GetAfterSince(0, time.Time{}, 10)
+		require.NoError(t, err)
+		recent, err := models.Outbox.GetAfterSince(0, since, 10)
+		require.NoError(t, err)
+
+		// Assert
+		require.Len(t, all, 2)
+		require.Len(t, recent, 1)
+		assert.Equal(t, all[1].ID, recent[0].ID)
+	})
+}
+
+func TestJobModelIntegration(t *testing.T) {
+	t.Run("claims a due job once", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		job := &Job{Kind: "test", Payload: json.RawMessage(`{"n":1}`), MaxAttempts: 3}
+		require.NoError(t, models.Jobs.Insert(job, []byte("input")))
+
+		// Execute
+		claimed, err := models.Jobs.Claim(5, time.Minute)
+		require.NoError(t, err)
+		again, err := models.Jobs.Claim(5, time.Minute)
+		require.NoError(t, err)
+
+		// Assert
+		require.Len(t, claimed, 1)
+		assert.Equal(t, job.ID, claimed[0].ID)
+		assert.Equal(t, JobRunning, claimed[0].Status)
+		assert.Equal(t, 1, claimed[0].Attempts)
+		assert.Empty(t, again)
+
+		input, err := models.Jobs.GetInput(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("input"), input)
+	})
+
+	t.Run("completes only the current attempt", func(t *testing.T) {
+		// Setup
+		models := NewModels(pgtest.New(t), 5*time.Second)
+
+		require.NoError(t, models.Jobs.Insert(&Job{Kind: "test", MaxAttempts: 3}, nil))
+		claimed, err := models.Jobs.Claim(1, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		job := claimed[0]
+		stale := *job
+		stale.Attempts--
+		job.Result = json.RawMessage(`{"ok":true}`)
+
+		// Execute
+		staleErr := models.Jobs.Complete(&stale)
+		err = models.Jobs.Complete(job)
+
+		// Assert
+		assert.ErrorIs(t, staleErr, ErrEditConflict)
+		require.NoError(t, err)
+
+		stored, err := models.Jobs.GetById(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobCompleted, stored.Status)
+		assert.JSONEq(t, `{"ok":true}`, string(stored.Result))
+	})
+}
@@ -0,0 +1,267 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OutboxNotifyChannel is the Postgres channel notified with the ID of every
+// new outbox message, once its transaction commits.
+const OutboxNotifyChannel = "outbox_events"
+
+// OutboxMessage is an event waiting to be published, see OutboxModeler.
+type OutboxMessage struct {
+	ID          int
+	Event       Event
+	Attempts    int
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+}
+
+// OutboxModeler reads the events that transaction mutations write to the
+// outbox table, in the same SQL transaction as the mutation itself. An event
+// is therefore stored if and only if its change is.
+type OutboxModeler interface {
+	GetById(id int) (*OutboxMessage, error)
+	GetAfter(id int, limit int) ([]*OutboxMessage, error)
+	GetAfterSince(id int, since time.Time, limit int) ([]*OutboxMessage, error)
+	Claim(limit int, lease time.Duration) ([]*OutboxMessage, error)
+	MarkPublished(id int) error
+	MarkFailed(id int, nextAttemptAt time.Time, reason string) error
+	DeletePublished(before time.Time) (int, error)
+}
+
+type OutboxModel struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func (m OutboxModel) GetById(id int) (*OutboxMessage, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, payload, attempts, created_at, published_at
+		FROM outbox
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	message, err := m.scan(m.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return message, nil
+}
+
+// GetAfter returns up to limit messages with an ID above id, published or
+// not, oldest first.
+func (m OutboxModel) GetAfter(id int, limit int) ([]*OutboxMessage, error) {
+	return m.GetAfterSince(id, time.Time{}, limit)
+}
+
+// GetAfterSince is GetAfter without the messages created before since.
+func (m OutboxModel) GetAfterSince(id int, since time.Time, limit int) ([]*OutboxMessage, error) {
+	query := `
+		SELECT id, payload, attempts, created_at, published_at
+		FROM outbox
+		WHERE id > $1 AND created_at >= $2
+		ORDER BY id ASC
+		LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, id, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*OutboxMessage{}
+
+	for rows.Next() {
+		message, err := m.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (m OutboxModel) scan(row interface{ Scan(dest ...any) error }) (*OutboxMessage, error) {
+	var message OutboxMessage
+	var payload []byte
+
+	err := row.Scan(&message.ID, &payload, &message.Attempts, &message.CreatedAt, &message.PublishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(payload, &message.Event); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Claim returns up to limit unpublished messages that are due, oldest first,
+// and pushes their next attempt back by lease so other relays skip them. A
+// message whose relay dies before marking it is claimed again once the lease
+// runs out.
+func (m OutboxModel) Claim(limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = NOW() + $2::bigint * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, payload, attempts, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*OutboxMessage
+
+	for rows.Next() {
+		var message OutboxMessage
+		var payload []byte
+
+		err := rows.Scan(&message.ID, &payload, &message.Attempts, &message.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(payload, &message.Event); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(messages, func(a, b *OutboxMessage) int { return a.ID - b.ID })
+	return messages, nil
+}
+
+func (m OutboxModel) MarkPublished(id int) error {
+	query := `
+		UPDATE outbox
+		SET published_at = NOW(), attempts = attempts + 1, last_error = ''
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOne(m.db.ExecContext(ctx, query, id))
+}
+
+func (m OutboxModel) MarkFailed(id int, nextAttemptAt time.Time, reason string) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.expectOne(m.db.ExecContext(ctx, query, id, nextAttemptAt, reason))
+}
+
+// DeletePublished removes the messages published before the given time.
+func (m OutboxModel) DeletePublished(before time.Time) (int, error) {
+	query := `DELETE FROM outbox WHERE published_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+func (m OutboxModel) expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// insertOutbox stores one new event per data in the outbox as part of tx,
+// in order.
+func insertOutbox(ctx context.Context, tx *sql.Tx, eventType EventType, data ...any) error {
+	for chunk := range slices.Chunk(data, insertChunkSize) {
+		var values strings.Builder
+		args := make([]any, 0, 3*len(chunk))
+
+		for i, data := range chunk {
+			event, err := NewEvent(eventType, data)
+			if err != nil {
+				return err
+			}
+
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			fmt.Fprintf(&values, "($%d, $%d, $%d::jsonb)", 3*i+1, 3*i+2, 3*i+3)
+			args = append(args, event.ID, string(event.Type), string(payload))
+		}
+
+		query := `INSERT INTO outbox (event_id, event_type, payload) VALUES ` + values.String()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
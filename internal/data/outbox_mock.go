@@ -0,0 +1,66 @@
+package data
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxModel struct {
+	mock.Mock
+}
+
+func (m *MockOutboxModel) GetById(id int) (*OutboxMessage, error) {
+	args := m.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxModel) GetAfter(id int, limit int) ([]*OutboxMessage, error) {
+	args := m.Called(id, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxModel) GetAfterSince(id int, since time.Time, limit int) ([]*OutboxMessage, error) {
+	args := m.Called(id, since, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxModel) Claim(limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	args := m.Called(limit, lease)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxModel) MarkPublished(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOutboxModel) MarkFailed(id int, nextAttemptAt time.Time, reason string) error {
+	args := m.Called(id, nextAttemptAt, reason)
+	return args.Error(0)
+}
+
+func (m *MockOutboxModel) DeletePublished(before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
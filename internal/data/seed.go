@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SeedModeler stores generated transactions. Rows it inserts are flagged as
+// seeded, so DeleteSeeded never touches real data.
+type SeedModeler interface {
+	InsertSeeded(transactions []*Transaction) error
+	DeleteSeeded() (int, error)
+}
+
+type SeedModel struct {
+	db *sql.DB
+}
+
+func (m SeedModel) InsertSeeded(transactions []*Transaction) error {
+	query := `
+        INSERT INTO transactions (user_id, amount, status, created_at, updated_at, seeded)
+        VALUES ($1, $2, $3, $4, $5, TRUE)
+        RETURNING id, version`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, transaction := range transactions {
+		args := []any{
+			transaction.UserId,
+			transaction.Amount,
+			transaction.Status,
+			transaction.CreatedAt,
+			transaction.UpdatedAt,
+		}
+
+		err := stmt.QueryRowContext(ctx, args...).Scan(&transaction.ID, &transaction.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m SeedModel) DeleteSeeded() (int, error) {
+	query := `DELETE FROM transactions WHERE seeded`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
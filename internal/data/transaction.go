@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/pgtype"
 	"github.com/ucok-man/tcsa/internal/utility"
 )
 
@@ -18,12 +21,30 @@ const (
 	TransactionStatusSucces  TransactionStatus = "success"
 )
 
+// transactionStatuses lists every TransactionStatus.
+var transactionStatuses = []TransactionStatus{
+	TransactionStatusPending, TransactionStatusFailed, TransactionStatusSucces,
+}
+
+// statusTransitions lists the statuses a status may change to by a bulk
+// update. Pending transactions settle as success or failed, settled ones are
+// final.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusPending: {TransactionStatusSucces, TransactionStatusFailed},
+}
+
+// CanTransitionTo reports whether a transaction with status s may be changed
+// to next. Keeping the same status is always allowed.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	return s == next || slices.Contains(statusTransitions[s], next)
+}
+
 type Transaction struct {
 	ID        int               `json:"id"`
 	UserId    int               `json:"user_id"`
 	Amount    int               `json:"amount"`
 	Status    TransactionStatus `json:"status"`
-	Version   int               `json:"-"`
+	Version   int               `json:"version"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
 }
@@ -50,9 +71,11 @@ type TransactionSummary struct {
 }
 
 type TransactionModel struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
+// Insert creates the transaction and its transaction.created event.
 func (m TransactionModel) Insert(transaction *Transaction) error {
 	query := `
         INSERT INTO transactions (user_id, amount, status)
@@ -60,15 +83,194 @@ func (m TransactionModel) Insert(transaction *Transaction) error {
         RETURNING id, version, created_at, updated_at`
 	args := []any{transaction.UserId, transaction.Amount, transaction.Status}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
-	return m.db.QueryRowContext(ctx, query, args...).Scan(
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
 		&transaction.ID,
 		&transaction.Version,
 		&transaction.CreatedAt,
 		&transaction.UpdatedAt,
 	)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutbox(ctx, tx, EventTransactionCreated, TransactionEventData{Transaction: transaction})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertChunkSize is the number of rows per multi-row INSERT, it keeps the
+// statements well below the limit of 65535 parameters.
+const insertChunkSize = 1000
+
+// InsertMany creates all transactions and their transaction.created events in
+// one SQL transaction, so either all of them are stored or none.
+func (m TransactionModel) InsertMany(transactions []*Transaction) error {
+	chunks := (len(transactions) + insertChunkSize - 1) / insertChunkSize
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(max(chunks, 1))*m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTransactions(ctx, tx, transactions); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransactionImportRow is a transaction created from a line of an import
+// file.
+type TransactionImportRow struct {
+	Line        int
+	Transaction *Transaction
+}
+
+// InsertImported creates the transactions of rows, with their events, like
+// InsertMany. Lines the import job jobId created before are skipped, so a job
+// run again after a failure doesn't create their transactions twice. Skipped
+// transactions are left as they are.
+func (m TransactionModel) InsertImported(jobId int, rows []TransactionImportRow) error {
+	chunks := (len(rows) + insertChunkSize - 1) / insertChunkSize
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(max(chunks, 1))*m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lines := make([]int64, len(rows))
+	for i, row := range rows {
+		lines[i] = int64(row.Line)
+	}
+	var array pgtype.Int8Array
+	// Set only fails for unsupported types.
+	_ = array.Set(lines)
+
+	// The primary key makes a concurrent run of the job wait for this one,
+	// the lines it returns are those no run has created yet.
+	query := `
+		INSERT INTO transaction_imports (job_id, line)
+		SELECT $1, unnest($2::bigint[])
+		ON CONFLICT DO NOTHING
+		RETURNING line`
+
+	dbrows, err := tx.QueryContext(ctx, query, jobId, &array)
+	if err != nil {
+		return err
+	}
+
+	created := map[int]bool{}
+	for dbrows.Next() {
+		var line int
+		if err := dbrows.Scan(&line); err != nil {
+			dbrows.Close()
+			return err
+		}
+		created[line] = true
+	}
+	dbrows.Close()
+
+	if err := dbrows.Err(); err != nil {
+		return err
+	}
+
+	transactions := make([]*Transaction, 0, len(created))
+	for _, row := range rows {
+		if created[row.Line] {
+			transactions = append(transactions, row.Transaction)
+		}
+	}
+
+	if err := insertTransactions(ctx, tx, transactions); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTransactions creates transactions and their transaction.created
+// events in tx.
+func insertTransactions(ctx context.Context, tx *sql.Tx, transactions []*Transaction) error {
+	for chunk := range slices.Chunk(transactions, insertChunkSize) {
+		var values strings.Builder
+		args := make([]any, 0, 3*len(chunk))
+
+		for i, transaction := range chunk {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			fmt.Fprintf(&values, "($%d, $%d, $%d)", 3*i+1, 3*i+2, 3*i+3)
+			args = append(args, transaction.UserId, transaction.Amount, transaction.Status)
+		}
+
+		// Identities are assigned in VALUES order, so sorting the returned
+		// rows by id matches them up with chunk.
+		query := `
+			INSERT INTO transactions (user_id, amount, status)
+			VALUES ` + values.String() + `
+			RETURNING id, version, created_at, updated_at`
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		var inserted []Transaction
+		for rows.Next() {
+			var transaction Transaction
+			err := rows.Scan(&transaction.ID, &transaction.Version, &transaction.CreatedAt, &transaction.UpdatedAt)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			inserted = append(inserted, transaction)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(inserted) != len(chunk) {
+			return fmt.Errorf("inserted %d of %d transactions", len(inserted), len(chunk))
+		}
+
+		slices.SortFunc(inserted, func(a, b Transaction) int { return a.ID - b.ID })
+
+		events := make([]any, len(chunk))
+		for i, transaction := range chunk {
+			transaction.ID = inserted[i].ID
+			transaction.Version = inserted[i].Version
+			transaction.CreatedAt = inserted[i].CreatedAt
+			transaction.UpdatedAt = inserted[i].UpdatedAt
+			events[i] = TransactionEventData{Transaction: transaction}
+		}
+
+		if err := insertOutbox(ctx, tx, EventTransactionCreated, events...); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 type TransactionGetAllParam struct {
@@ -101,7 +303,7 @@ func (m TransactionModel) GetAll(param TransactionGetAllParam) ([]*Transaction,
 	    LIMIT $3 OFFSET $4`, param.SortColumn, param.SortDirection,
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
 	args := []any{param.FilterStatus, param.FilterUserId, param.PageSize, param.PageOffset}
@@ -142,6 +344,104 @@ func (m TransactionModel) GetAll(param TransactionGetAllParam) ([]*Transaction,
 	return transactions, &metadata, nil
 }
 
+type TransactionExportParam struct {
+	SortColumn    string
+	SortDirection string
+	FilterStatus  string
+	FilterUserId  int
+}
+
+// exportFetchSize is the number of rows Export reads from its cursor at once.
+const exportFetchSize = 1000
+
+// Export calls fn for every transaction matching param, in order, until fn
+// returns an error. Rows are read in batches through a cursor, from a single
+// snapshot, so memory use doesn't grow with the number of rows. The export
+// runs as long as ctx allows, only each fetch is bounded by the query timeout.
+func (m TransactionModel) Export(ctx context.Context, param TransactionExportParam, fn func(*Transaction) error) error {
+	query := fmt.Sprintf(`
+		DECLARE transactions_export NO SCROLL CURSOR FOR
+		SELECT id, user_id, amount, status, version, created_at, updated_at
+		FROM transactions
+		WHERE
+			(CASE
+				WHEN $1 = '' THEN TRUE
+				ELSE status = $1
+			END)
+			AND
+			(CASE
+				WHEN $2 = 0 THEN TRUE
+				ELSE user_id = $2
+			END)
+		ORDER BY %s %s, id ASC`, param.SortColumn, param.SortDirection,
+	)
+
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query, param.FilterStatus, param.FilterUserId)
+	if err != nil {
+		return err
+	}
+
+	for {
+		batch, err := m.fetchExport(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, transaction := range batch {
+			if err := fn(transaction); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// fetchExport reads the next batch of the export cursor. The batch is read in
+// full before it is handed out, so a slow consumer doesn't count against the
+// query timeout.
+func (m TransactionModel) fetchExport(ctx context.Context, tx *sql.Tx) ([]*Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.queryTimeout)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`FETCH %d FROM transactions_export`, exportFetchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batch := make([]*Transaction, 0, exportFetchSize)
+	for rows.Next() {
+		var transaction Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserId,
+			&transaction.Amount,
+			&transaction.Status,
+			&transaction.Version,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, &transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
 func (m TransactionModel) GetById(id int) (*Transaction, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
@@ -154,7 +454,7 @@ func (m TransactionModel) GetById(id int) (*Transaction, error) {
 
 	var transaction Transaction
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
 	err := m.db.QueryRowContext(ctx, query, id).Scan(
@@ -179,6 +479,9 @@ func (m TransactionModel) GetById(id int) (*Transaction, error) {
 	return &transaction, nil
 }
 
+// Update saves transaction if its version is still current and records a
+// transaction.updated event, plus a transaction.status_changed event when the
+// status changed.
 func (m TransactionModel) Update(transaction *Transaction) error {
 	query := `
         UPDATE transactions
@@ -194,10 +497,22 @@ func (m TransactionModel) Update(transaction *Transaction) error {
 		&transaction.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
-	err := m.db.QueryRowContext(ctx, query, args...).Scan(&transaction.Version)
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the row at the expected version, the status it had is what the
+	// event reports as the previous one.
+	var previousStatus TransactionStatus
+	err = tx.QueryRowContext(ctx,
+		`SELECT status FROM transactions WHERE id = $1 AND version = $2 FOR UPDATE`,
+		transaction.ID, transaction.Version,
+	).Scan(&previousStatus)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -206,30 +521,562 @@ func (m TransactionModel) Update(transaction *Transaction) error {
 			return err
 		}
 	}
-	return nil
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&transaction.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	eventData := TransactionEventData{
+		Transaction:    transaction,
+		PreviousStatus: previousStatus,
+	}
+
+	err = insertOutbox(ctx, tx, EventTransactionUpdated, eventData)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Status != previousStatus {
+		err = insertOutbox(ctx, tx, EventTransactionStatusChanged, eventData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// DeleteOne removes the transaction and records a transaction.deleted event.
 func (m TransactionModel) DeleteOne(id int) error {
 	if id < 1 {
 		return ErrRecordNotFound
 	}
-	query := `DELETE FROM transactions WHERE id = $1`
+	query := `
+		DELETE FROM transactions
+		WHERE id = $1
+		RETURNING id, user_id, amount, status, version, created_at, updated_at`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
-	result, err := m.db.ExecContext(ctx, query, id)
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	var transaction Transaction
+	err = tx.QueryRowContext(ctx, query, id).Scan(
+		&transaction.ID,
+		&transaction.UserId,
+		&transaction.Amount,
+		&transaction.Status,
+		&transaction.Version,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	err = insertOutbox(ctx, tx, EventTransactionDeleted, TransactionEventData{Transaction: &transaction})
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrRecordNotFound
+
+	return tx.Commit()
+}
+
+type TransactionDeleteManyParam struct {
+	FilterStatus        string
+	FilterUserId        int
+	FilterCreatedBefore time.Time
+}
+
+// deleteBatchSize is the number of rows DeleteMany removes per SQL
+// transaction.
+const deleteBatchSize = 1000
+
+// DeleteMany removes every transaction matching param, recording a
+// transaction.deleted event for each. Rows are deleted in batches of
+// deleteBatchSize, each committed with its events under its own query
+// timeout, so a purge of any size doesn't hold one long transaction. When a
+// batch fails the earlier ones stay deleted, the count of them is returned
+// with the error.
+func (m TransactionModel) DeleteMany(param TransactionDeleteManyParam) (int, error) {
+	var createdBefore *time.Time
+	if !param.FilterCreatedBefore.IsZero() {
+		createdBefore = &param.FilterCreatedBefore
 	}
-	return nil
+
+	total := 0
+	for {
+		deleted, err := m.deleteBatch(param.FilterStatus, param.FilterUserId, createdBefore)
+		total += deleted
+		if err != nil || deleted < deleteBatchSize {
+			return total, err
+		}
+	}
+}
+
+// deleteBatch deletes up to deleteBatchSize transactions matching the
+// filters of DeleteMany, and records their events.
+func (m TransactionModel) deleteBatch(status string, userId int, createdBefore *time.Time) (int, error) {
+	query := `
+		DELETE FROM transactions
+		WHERE id IN (
+			SELECT id FROM transactions
+			WHERE
+				(CASE
+					WHEN $1 = '' THEN TRUE
+					ELSE status = $1
+				END)
+				AND
+				(CASE
+					WHEN $2 = 0 THEN TRUE
+					ELSE user_id = $2
+				END)
+				AND
+				(CASE
+					WHEN $3::timestamptz IS NULL THEN TRUE
+					ELSE created_at < $3
+				END)
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE
+		)
+		RETURNING id, user_id, amount, status, version, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	args := []any{status, userId, createdBefore, deleteBatchSize}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var deleted []*Transaction
+
+	for rows.Next() {
+		var transaction Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserId,
+			&transaction.Amount,
+			&transaction.Status,
+			&transaction.Version,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		deleted = append(deleted, &transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, transaction := range deleted {
+		err = insertOutbox(ctx, tx, EventTransactionDeleted, TransactionEventData{Transaction: transaction})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(deleted), nil
+}
+
+// TransactionBulkTarget is a transaction a bulk operation must apply to, at
+// Version. A zero Version accepts any version.
+type TransactionBulkTarget struct {
+	ID      int
+	Version int
+}
+
+// TransactionBulkParam selects the transactions of a bulk operation, either by
+// Targets or, when there are none, by the filters.
+type TransactionBulkParam struct {
+	Targets      []TransactionBulkTarget
+	FilterStatus string
+	FilterUserId int
+	DryRun       bool
+
+	// Limit caps the transactions a filter may select, the operation fails
+	// with ErrBulkLimitExceeded when it selects more. Zero means no cap.
+	Limit int
+}
+
+// Reasons a target of a bulk operation is left untouched.
+const (
+	BulkConflictNotFound          = "not_found"
+	BulkConflictVersionMismatch   = "version_mismatch"
+	BulkConflictInvalidTransition = "invalid_transition"
+	BulkConflictUnchanged         = "unchanged"
+)
+
+type TransactionBulkConflict struct {
+	ID      int               `json:"id"`
+	Reason  string            `json:"reason"`
+	Version int               `json:"current_version,omitempty"`
+	Status  TransactionStatus `json:"current_status,omitempty"`
+}
+
+// TransactionBulkResult is the outcome of a bulk operation. Transactions holds
+// the changed rows, and is empty on a dry run. Conflicts are only reported
+// for targets, transactions selected by filter that can't be changed are
+// skipped.
+type TransactionBulkResult struct {
+	Affected     int
+	Transactions []*Transaction
+	Conflicts    []TransactionBulkConflict
+}
+
+// BulkUpdateStatus changes the status of the selected transactions, bumping
+// their version and recording their events. Transactions that may not change
+// to status are left untouched.
+func (m TransactionModel) BulkUpdateStatus(param TransactionBulkParam, status TransactionStatus) (*TransactionBulkResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	selected, result, err := m.lockBulk(ctx, tx, param, rejectStatus(status))
+	if err != nil || param.DryRun || len(selected) == 0 {
+		return result, err
+	}
+
+	query := `
+		UPDATE transactions
+		SET status = $1, updated_at = NOW(), version = version + 1
+		WHERE id = ANY($2)
+		RETURNING id, user_id, amount, status, version, created_at, updated_at`
+
+	transactions, err := m.scanBulk(tx.QueryContext(ctx, query, status, bulkIds(selected)))
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]any, len(transactions))
+	for i, transaction := range transactions {
+		updated[i] = TransactionEventData{
+			Transaction:    transaction,
+			PreviousStatus: selected[transaction.ID].Status,
+		}
+	}
+
+	err = insertOutbox(ctx, tx, EventTransactionUpdated, updated...)
+	if err != nil {
+		return nil, err
+	}
+	err = insertOutbox(ctx, tx, EventTransactionStatusChanged, updated...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result.Transactions = transactions
+	return result, nil
+}
+
+// BulkDelete removes the selected transactions, recording a
+// transaction.deleted event for each.
+func (m TransactionModel) BulkDelete(param TransactionBulkParam) (*TransactionBulkResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	selected, result, err := m.lockBulk(ctx, tx, param, nil)
+	if err != nil || param.DryRun || len(selected) == 0 {
+		return result, err
+	}
+
+	query := `
+		DELETE FROM transactions
+		WHERE id = ANY($1)
+		RETURNING id, user_id, amount, status, version, created_at, updated_at`
+
+	transactions, err := m.scanBulk(tx.QueryContext(ctx, query, bulkIds(selected)))
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]any, len(transactions))
+	for i, transaction := range transactions {
+		deleted[i] = TransactionEventData{Transaction: transaction}
+	}
+
+	err = insertOutbox(ctx, tx, EventTransactionDeleted, deleted...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result.Transactions = transactions
+	return result, nil
+}
+
+// lockBulk locks the transactions selected by param, and returns those that
+// the operation applies to by id. reject tells why a transaction can't be
+// changed, or "" when it can; nil accepts every transaction.
+//
+// A filter is counted first, without locks, and fails with
+// ErrBulkLimitExceeded when it selects more than param.Limit transactions. A
+// dry run by filter stops at the count, one by targets reads them without
+// locking.
+func (m TransactionModel) lockBulk(ctx context.Context, tx *sql.Tx, param TransactionBulkParam, reject func(*Transaction) string) (map[int]*Transaction, *TransactionBulkResult, error) {
+	filter := `
+		(CASE
+			WHEN $1::bigint[] IS NULL THEN TRUE
+			ELSE id = ANY($1)
+		END)
+		AND
+		(CASE
+			WHEN $2 = '' THEN TRUE
+			ELSE status = $2
+		END)
+		AND
+		(CASE
+			WHEN $3 = 0 THEN TRUE
+			ELSE user_id = $3
+		END)
+		AND
+		(CASE
+			WHEN $4::text[] IS NULL THEN TRUE
+			ELSE status = ANY($4)
+		END)`
+
+	targetIds := pgtype.Int8Array{Status: pgtype.Null}
+	statuses := pgtype.TextArray{Status: pgtype.Null}
+	filterStatus, filterUserId := param.FilterStatus, param.FilterUserId
+	if len(param.Targets) > 0 {
+		ids := make([]int64, len(param.Targets))
+		for i, target := range param.Targets {
+			ids[i] = int64(target.ID)
+		}
+		if err := targetIds.Set(ids); err != nil {
+			return nil, nil, err
+		}
+		filterStatus, filterUserId = "", 0
+	} else if reject != nil {
+		// Transactions a filter selects but the operation can't change are
+		// skipped, they don't count towards the limit.
+		if err := statuses.Set(acceptedStatuses(reject)); err != nil {
+			return nil, nil, err
+		}
+	}
+	args := []any{&targetIds, filterStatus, filterUserId, &statuses}
+
+	if len(param.Targets) == 0 {
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT count(*) FROM transactions WHERE`+filter, args...).Scan(&count)
+		if err != nil {
+			return nil, nil, err
+		}
+		if param.Limit > 0 && count > param.Limit {
+			return nil, nil, ErrBulkLimitExceeded
+		}
+		if param.DryRun {
+			return nil, &TransactionBulkResult{Affected: count, Conflicts: []TransactionBulkConflict{}}, nil
+		}
+	}
+
+	// Rows are locked in id order, so concurrent bulk operations can't
+	// deadlock on each other. Rows added since the count are caught by
+	// reading one past the limit.
+	query := `
+		SELECT id, status, version
+		FROM transactions
+		WHERE` + filter + `
+		ORDER BY id ASC`
+	if len(param.Targets) == 0 && param.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", param.Limit+1)
+	}
+	if !param.DryRun {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]*Transaction)
+	var order []int
+	for rows.Next() {
+		var transaction Transaction
+		if err := rows.Scan(&transaction.ID, &transaction.Status, &transaction.Version); err != nil {
+			return nil, nil, err
+		}
+		found[transaction.ID] = &transaction
+		order = append(order, transaction.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return selectBulk(found, order, param, reject)
+}
+
+// acceptedStatuses lists the statuses of the transactions reject accepts.
+func acceptedStatuses(reject func(*Transaction) string) []string {
+	// Not nil when nothing is accepted, a nil array is NULL and would match
+	// every status.
+	accepted := []string{}
+	for _, status := range transactionStatuses {
+		if reject(&Transaction{Status: status}) == "" {
+			accepted = append(accepted, string(status))
+		}
+	}
+	return accepted
+}
+
+// rejectStatus tells why a transaction can't change to status, for
+// selectBulk.
+func rejectStatus(status TransactionStatus) func(*Transaction) string {
+	return func(current *Transaction) string {
+		switch {
+		case current.Status == status:
+			return BulkConflictUnchanged
+		case !current.Status.CanTransitionTo(status):
+			return BulkConflictInvalidTransition
+		default:
+			return ""
+		}
+	}
+}
+
+// selectBulk picks the transactions a bulk operation applies to from found,
+// the candidates selected by param in id order, and reports the conflicts of
+// its targets.
+func selectBulk(found map[int]*Transaction, order []int, param TransactionBulkParam, reject func(*Transaction) string) (map[int]*Transaction, *TransactionBulkResult, error) {
+	result := &TransactionBulkResult{Conflicts: []TransactionBulkConflict{}}
+	selected := make(map[int]*Transaction)
+
+	if len(param.Targets) == 0 {
+		for _, id := range order {
+			if reject == nil || reject(found[id]) == "" {
+				selected[id] = found[id]
+			}
+		}
+		if param.Limit > 0 && len(selected) > param.Limit {
+			return nil, nil, ErrBulkLimitExceeded
+		}
+		result.Affected = len(selected)
+		return selected, result, nil
+	}
+
+	for _, target := range param.Targets {
+		current, ok := found[target.ID]
+		reason := ""
+		switch {
+		case !ok:
+			reason = BulkConflictNotFound
+		case target.Version != 0 && target.Version != current.Version:
+			reason = BulkConflictVersionMismatch
+		case reject != nil:
+			reason = reject(current)
+		}
+
+		if reason == "" {
+			selected[target.ID] = current
+			continue
+		}
+
+		conflict := TransactionBulkConflict{ID: target.ID, Reason: reason}
+		if ok {
+			conflict.Version = current.Version
+			conflict.Status = current.Status
+		}
+		result.Conflicts = append(result.Conflicts, conflict)
+	}
+
+	result.Affected = len(selected)
+	return selected, result, nil
+}
+
+func (m TransactionModel) scanBulk(rows *sql.Rows, err error) ([]*Transaction, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []*Transaction{}
+	for rows.Next() {
+		var transaction Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserId,
+			&transaction.Amount,
+			&transaction.Status,
+			&transaction.Version,
+			&transaction.CreatedAt,
+			&transaction.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, &transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(transactions, func(a, b *Transaction) int { return a.ID - b.ID })
+	return transactions, nil
+}
+
+func bulkIds(selected map[int]*Transaction) *pgtype.Int8Array {
+	ids := make([]int64, 0, len(selected))
+	for id := range selected {
+		ids = append(ids, int64(id))
+	}
+
+	var array pgtype.Int8Array
+	// Set only fails for unsupported types.
+	_ = array.Set(ids)
+	return &array
 }
 
 type TransactionSummaryParam struct {
@@ -265,7 +1112,7 @@ func (m TransactionModel) Summary(param TransactionSummaryParam) (*TransactionSu
     LIMIT $3 OFFSET $4`, param.SortColumn, param.SortDirection,
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
 
 	args := []any{param.FilterDateRange, param.FilterUserId, param.PageSize, param.PageOffset}
@@ -0,0 +1,24 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/data/datatest"
+	"github.com/ucok-man/tcsa/internal/pgtest"
+)
+
+func TestMemoryTransactionModelConformance(t *testing.T) {
+	datatest.RunTransactionModelerSuite(t, func(t *testing.T) data.TransactionModeler {
+		return data.NewMemoryTransactionModel()
+	})
+}
+
+// TestTransactionModelConformance runs every subtest in a schema of its own,
+// see pgtest.
+func TestTransactionModelConformance(t *testing.T) {
+	datatest.RunTransactionModelerSuite(t, func(t *testing.T) data.TransactionModeler {
+		return data.NewModels(pgtest.New(t), 5*time.Second).Transactions
+	})
+}
@@ -0,0 +1,444 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/utility"
+)
+
+// MemoryTransactionModel is a TransactionModeler keeping the transactions in
+// memory, to run the API and tests without a database. Results match
+// TransactionModel for the same data, including its quirks: a page past the
+// last one comes back with empty metadata, and Summary counts nothing for it.
+// No events are recorded, there is no outbox.
+//
+// It is safe for concurrent use. Every method works on its own copies of the
+// stored transactions.
+type MemoryTransactionModel struct {
+	mu           sync.RWMutex
+	transactions map[int]*Transaction
+	lastId       int
+	// imports holds the lines every import job created, see
+	// InsertImported.
+	imports map[int]map[int]bool
+	now     func() time.Time
+}
+
+func NewMemoryTransactionModel() *MemoryTransactionModel {
+	return &MemoryTransactionModel{
+		transactions: make(map[int]*Transaction),
+		imports:      make(map[int]map[int]bool),
+		now:          time.Now,
+	}
+}
+
+// timestamp returns the current time as Postgres stores it, in microseconds.
+func (m *MemoryTransactionModel) timestamp() time.Time {
+	return m.now().Truncate(time.Microsecond)
+}
+
+func (m *MemoryTransactionModel) Insert(transaction *Transaction) error {
+	return m.InsertMany([]*Transaction{transaction})
+}
+
+// InsertMany stores all transactions or, when one is invalid, none.
+func (m *MemoryTransactionModel) InsertMany(transactions []*Transaction) error {
+	for _, transaction := range transactions {
+		if err := checkMemoryStatus(transaction.Status); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.insert(transactions)
+	return nil
+}
+
+// InsertImported stores the transactions of rows like InsertMany, skipping
+// the lines the import job jobId stored before.
+func (m *MemoryTransactionModel) InsertImported(jobId int, rows []TransactionImportRow) error {
+	for _, row := range rows {
+		if err := checkMemoryStatus(row.Transaction.Status); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lines := m.imports[jobId]
+	if lines == nil {
+		lines = make(map[int]bool)
+		m.imports[jobId] = lines
+	}
+
+	transactions := make([]*Transaction, 0, len(rows))
+	for _, row := range rows {
+		if !lines[row.Line] {
+			lines[row.Line] = true
+			transactions = append(transactions, row.Transaction)
+		}
+	}
+
+	m.insert(transactions)
+	return nil
+}
+
+// insert stores transactions, m.mu must be held.
+func (m *MemoryTransactionModel) insert(transactions []*Transaction) {
+	now := m.timestamp()
+	for _, transaction := range transactions {
+		m.lastId++
+		transaction.ID = m.lastId
+		transaction.Version = 1
+		transaction.CreatedAt = now
+		transaction.UpdatedAt = now
+
+		stored := *transaction
+		m.transactions[stored.ID] = &stored
+	}
+}
+
+func (m *MemoryTransactionModel) GetAll(param TransactionGetAllParam) ([]*Transaction, *Metadata, error) {
+	matches, err := m.query(param.SortColumn, param.SortDirection, func(transaction *Transaction) bool {
+		return matchStatus(transaction, param.FilterStatus) && matchUserId(transaction, param.FilterUserId)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions, totalRecords := paginate(matches, param.PageSize, param.PageOffset)
+
+	metadata := calculateMetadata(totalRecords, param.Page, param.PageSize)
+	return transactions, &metadata, nil
+}
+
+// Export calls fn with a snapshot of the matching transactions taken when it
+// starts, like the repeatable read export of TransactionModel.
+func (m *MemoryTransactionModel) Export(ctx context.Context, param TransactionExportParam, fn func(*Transaction) error) error {
+	matches, err := m.query(param.SortColumn, param.SortDirection, func(transaction *Transaction) bool {
+		return matchStatus(transaction, param.FilterStatus) && matchUserId(transaction, param.FilterUserId)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, transaction := range matches {
+		if i%exportFetchSize == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryTransactionModel) GetById(id int) (*Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.transactions[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	transaction := *stored
+	return &transaction, nil
+}
+
+// Update saves transaction if its version is still current. Like
+// TransactionModel, it takes UpdatedAt from transaction.
+func (m *MemoryTransactionModel) Update(transaction *Transaction) error {
+	if err := checkMemoryStatus(transaction.Status); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.transactions[transaction.ID]
+	if !ok || stored.Version != transaction.Version {
+		return ErrEditConflict
+	}
+
+	stored.Amount = transaction.Amount
+	stored.Status = transaction.Status
+	stored.UpdatedAt = transaction.UpdatedAt.Truncate(time.Microsecond)
+	stored.Version++
+
+	transaction.Version = stored.Version
+	return nil
+}
+
+func (m *MemoryTransactionModel) DeleteOne(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.transactions[id]; !ok {
+		return ErrRecordNotFound
+	}
+
+	delete(m.transactions, id)
+	return nil
+}
+
+func (m *MemoryTransactionModel) DeleteMany(param TransactionDeleteManyParam) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for id, transaction := range m.transactions {
+		if !matchStatus(transaction, param.FilterStatus) || !matchUserId(transaction, param.FilterUserId) {
+			continue
+		}
+		if !param.FilterCreatedBefore.IsZero() && !transaction.CreatedAt.Before(param.FilterCreatedBefore) {
+			continue
+		}
+
+		delete(m.transactions, id)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (m *MemoryTransactionModel) BulkUpdateStatus(param TransactionBulkParam, status TransactionStatus) (*TransactionBulkResult, error) {
+	if err := checkMemoryStatus(status); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	selected, result, err := m.selectBulk(param, rejectStatus(status))
+	if err != nil || param.DryRun || len(selected) == 0 {
+		return result, err
+	}
+
+	now := m.timestamp()
+	result.Transactions = m.changeBulk(selected, func(stored *Transaction) {
+		stored.Status = status
+		stored.UpdatedAt = now
+		stored.Version++
+	})
+	return result, nil
+}
+
+func (m *MemoryTransactionModel) BulkDelete(param TransactionBulkParam) (*TransactionBulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	selected, result, err := m.selectBulk(param, nil)
+	if err != nil || param.DryRun || len(selected) == 0 {
+		return result, err
+	}
+
+	result.Transactions = m.changeBulk(selected, func(stored *Transaction) {
+		delete(m.transactions, stored.ID)
+	})
+	return result, nil
+}
+
+// selectBulk finds the candidates of a bulk operation like
+// TransactionModel.lockBulk does. It must be called with mu held.
+func (m *MemoryTransactionModel) selectBulk(param TransactionBulkParam, reject func(*Transaction) string) (map[int]*Transaction, *TransactionBulkResult, error) {
+	found := make(map[int]*Transaction)
+
+	if len(param.Targets) > 0 {
+		for _, target := range param.Targets {
+			if stored, ok := m.transactions[target.ID]; ok {
+				found[target.ID] = stored
+			}
+		}
+	} else {
+		for id, stored := range m.transactions {
+			if matchStatus(stored, param.FilterStatus) && matchUserId(stored, param.FilterUserId) {
+				found[id] = stored
+			}
+		}
+	}
+
+	order := make([]int, 0, len(found))
+	for id := range found {
+		order = append(order, id)
+	}
+	slices.Sort(order)
+
+	return selectBulk(found, order, param, reject)
+}
+
+// changeBulk applies change to every selected transaction, and returns a copy
+// of each, in id order, as it was after the change. It must be called with
+// mu held.
+func (m *MemoryTransactionModel) changeBulk(selected map[int]*Transaction, change func(stored *Transaction)) []*Transaction {
+	transactions := make([]*Transaction, 0, len(selected))
+	for _, stored := range selected {
+		change(stored)
+
+		transaction := *stored
+		transactions = append(transactions, &transaction)
+	}
+
+	slices.SortFunc(transactions, func(a, b *Transaction) int { return a.ID - b.ID })
+	return transactions
+}
+
+// Summary filters by date range from the start of the current day in UTC,
+// which is what CURRENT_DATE is for a database running in UTC.
+func (m *MemoryTransactionModel) Summary(param TransactionSummaryParam) (*TransactionSummary, *Metadata, error) {
+	var since time.Time
+	if param.FilterDateRange != 0 {
+		today := m.now().UTC().Truncate(24 * time.Hour)
+		since = today.AddDate(0, 0, -(param.FilterDateRange - 1))
+	}
+
+	matches, err := m.query(param.SortColumn, param.SortDirection, func(transaction *Transaction) bool {
+		if param.FilterDateRange != 0 && transaction.CreatedAt.Before(since) {
+			return false
+		}
+		return matchUserId(transaction, param.FilterUserId)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions, totalRecords := paginate(matches, param.PageSize, param.PageOffset)
+
+	// The counts come with the rows of the page, an empty page counts
+	// nothing.
+	var summary Summary
+	if totalRecords > 0 {
+		summary.CountTotal = totalRecords
+		for _, transaction := range matches {
+			switch transaction.Status {
+			case TransactionStatusSucces:
+				summary.Success.Count++
+			case TransactionStatusPending:
+				summary.Pending.Count++
+			case TransactionStatusFailed:
+				summary.Failed.Count++
+			}
+		}
+
+		summary.Success.RatePercentage = utility.Round2(
+			float64(summary.Success.Count) / float64(summary.CountTotal) * 100,
+		)
+
+		summary.Pending.RatePercentage = utility.Round2(
+			float64(summary.Pending.Count) / float64(summary.CountTotal) * 100,
+		)
+
+		summary.Failed.RatePercentage = utility.Round2(
+			float64(summary.Failed.Count) / float64(summary.CountTotal) * 100,
+		)
+	}
+
+	metadata := calculateMetadata(totalRecords, param.Page, param.PageSize)
+
+	return &TransactionSummary{
+		Transactions: transactions,
+		Summary:      summary,
+	}, &metadata, nil
+}
+
+// query returns copies of the transactions matching keep, sorted by column in
+// direction and then by id ascending, the ORDER BY of TransactionModel.
+func (m *MemoryTransactionModel) query(column, direction string, keep func(*Transaction) bool) ([]*Transaction, error) {
+	compare, err := compareColumn(column)
+	if err != nil {
+		return nil, err
+	}
+
+	var descending bool
+	switch strings.ToUpper(direction) {
+	case "ASC":
+	case "DESC":
+		descending = true
+	default:
+		return nil, fmt.Errorf("invalid sort direction %q", direction)
+	}
+
+	m.mu.RLock()
+	matches := []*Transaction{}
+	for _, stored := range m.transactions {
+		if keep(stored) {
+			transaction := *stored
+			matches = append(matches, &transaction)
+		}
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(matches, func(a, b *Transaction) int {
+		order := compare(a, b)
+		if descending {
+			order = -order
+		}
+		if order != 0 {
+			return order
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return matches, nil
+}
+
+// compareColumn orders transactions by a column of the transactions table.
+func compareColumn(column string) (func(a, b *Transaction) int, error) {
+	switch column {
+	case "id":
+		return func(a, b *Transaction) int { return cmp.Compare(a.ID, b.ID) }, nil
+	case "user_id":
+		return func(a, b *Transaction) int { return cmp.Compare(a.UserId, b.UserId) }, nil
+	case "amount":
+		return func(a, b *Transaction) int { return cmp.Compare(a.Amount, b.Amount) }, nil
+	case "status":
+		return func(a, b *Transaction) int { return cmp.Compare(a.Status, b.Status) }, nil
+	case "version":
+		return func(a, b *Transaction) int { return cmp.Compare(a.Version, b.Version) }, nil
+	case "created_at":
+		return func(a, b *Transaction) int { return a.CreatedAt.Compare(b.CreatedAt) }, nil
+	case "updated_at":
+		return func(a, b *Transaction) int { return a.UpdatedAt.Compare(b.UpdatedAt) }, nil
+	default:
+		return nil, fmt.Errorf("invalid sort column %q", column)
+	}
+}
+
+// paginate returns the page of matches at offset, and the number of matches
+// as counted by count(*) OVER(): zero when the page is empty.
+func paginate(matches []*Transaction, limit, offset int) ([]*Transaction, int) {
+	if offset >= len(matches) || limit <= 0 {
+		return nil, 0
+	}
+
+	end := min(offset+limit, len(matches))
+	return matches[offset:end], len(matches)
+}
+
+func matchStatus(transaction *Transaction, status string) bool {
+	return status == "" || string(transaction.Status) == status
+}
+
+func matchUserId(transaction *Transaction, userId int) bool {
+	return userId == 0 || transaction.UserId == userId
+}
+
+// checkMemoryStatus rejects the statuses the transaction_status domain
+// doesn't allow.
+func checkMemoryStatus(status TransactionStatus) error {
+	switch status {
+	case TransactionStatusPending, TransactionStatusSucces, TransactionStatusFailed:
+		return nil
+	default:
+		return fmt.Errorf("invalid transaction status %q", status)
+	}
+}
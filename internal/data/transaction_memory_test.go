@@ -0,0 +1,339 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryTransactionModel(t *testing.T, transactions ...*Transaction) *MemoryTransactionModel {
+	t.Helper()
+
+	m := NewMemoryTransactionModel()
+	m.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	require.NoError(t, m.InsertMany(transactions))
+	return m
+}
+
+func TestMemoryTransactionModelInsert(t *testing.T) {
+	t.Run("assigns id, version and timestamps", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t)
+		transaction := &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}
+
+		// Execute
+		err := m.Insert(transaction)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, transaction.ID)
+		assert.Equal(t, 1, transaction.Version)
+		assert.Equal(t, m.now(), transaction.CreatedAt)
+		assert.Equal(t, transaction.CreatedAt, transaction.UpdatedAt)
+
+		stored, err := m.GetById(1)
+		require.NoError(t, err)
+		assert.Equal(t, transaction, stored)
+	})
+
+	t.Run("inserts nothing when one transaction is invalid", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t)
+
+		// Execute
+		err := m.InsertMany([]*Transaction{
+			{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+			{UserId: 1, Amount: 100, Status: "refunded"},
+		})
+
+		// Assert
+		require.Error(t, err)
+		_, err = m.GetById(1)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("inserts every imported line once", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t)
+		require.NoError(t, m.InsertImported(1, []TransactionImportRow{
+			{Line: 2, Transaction: &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}},
+		}))
+
+		// Execute
+		err := m.InsertImported(1, []TransactionImportRow{
+			{Line: 2, Transaction: &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}},
+			{Line: 3, Transaction: &Transaction{UserId: 1, Amount: 200, Status: TransactionStatusPending}},
+		})
+		require.NoError(t, err)
+		otherErr := m.InsertImported(2, []TransactionImportRow{
+			{Line: 2, Transaction: &Transaction{UserId: 1, Amount: 300, Status: TransactionStatusPending}},
+		})
+
+		// Assert
+		require.NoError(t, otherErr)
+		transactions, _, err := m.GetAll(TransactionGetAllParam{Page: 1, PageSize: 10, SortColumn: "id", SortDirection: "ASC"})
+		require.NoError(t, err)
+		require.Len(t, transactions, 3)
+		assert.Equal(t, []int{100, 200, 300}, []int{transactions[0].Amount, transactions[1].Amount, transactions[2].Amount})
+	})
+
+	t.Run("keeps its own copy", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t)
+		transaction := &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}
+		require.NoError(t, m.Insert(transaction))
+
+		// Execute
+		transaction.Amount = 500
+
+		// Assert
+		stored, err := m.GetById(transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 100, stored.Amount)
+	})
+}
+
+func TestMemoryTransactionModelGetAll(t *testing.T) {
+	m := newTestMemoryTransactionModel(t,
+		&Transaction{UserId: 1, Amount: 300, Status: TransactionStatusPending},
+		&Transaction{UserId: 2, Amount: 100, Status: TransactionStatusSucces},
+		&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusFailed},
+		&Transaction{UserId: 1, Amount: 200, Status: TransactionStatusPending},
+	)
+
+	ids := func(transactions []*Transaction) []int {
+		var ids []int
+		for _, transaction := range transactions {
+			ids = append(ids, transaction.ID)
+		}
+		return ids
+	}
+
+	t.Run("sorts ties by id ascending", func(t *testing.T) {
+		// Execute
+		transactions, metadata, err := m.GetAll(TransactionGetAllParam{
+			Page: 1, PageSize: 10, SortColumn: "amount", SortDirection: "DESC",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 4, 2, 3}, ids(transactions))
+		assert.Equal(t, 4, metadata.TotalRecords)
+	})
+
+	t.Run("filters and paginates", func(t *testing.T) {
+		// Execute
+		transactions, metadata, err := m.GetAll(TransactionGetAllParam{
+			Page: 2, PageSize: 2, PageOffset: 2, SortColumn: "id", SortDirection: "ASC",
+			FilterUserId: 1,
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []int{4}, ids(transactions))
+		assert.Equal(t, calculateMetadata(3, 2, 2), *metadata)
+	})
+
+	t.Run("page past the last one is empty", func(t *testing.T) {
+		// Execute
+		transactions, metadata, err := m.GetAll(TransactionGetAllParam{
+			Page: 5, PageSize: 2, PageOffset: 8, SortColumn: "id", SortDirection: "ASC",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, transactions)
+		assert.Equal(t, Metadata{}, *metadata)
+	})
+
+	t.Run("rejects unknown sort column", func(t *testing.T) {
+		// Execute
+		_, _, err := m.GetAll(TransactionGetAllParam{
+			Page: 1, PageSize: 10, SortColumn: "secret", SortDirection: "ASC",
+		})
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryTransactionModelUpdate(t *testing.T) {
+	t.Run("bumps the version", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t, &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending})
+		transaction, err := m.GetById(1)
+		require.NoError(t, err)
+
+		transaction.Status = TransactionStatusSucces
+		transaction.UpdatedAt = m.now().Add(time.Minute)
+
+		// Execute
+		err = m.Update(transaction)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, transaction.Version)
+
+		stored, err := m.GetById(1)
+		require.NoError(t, err)
+		assert.Equal(t, transaction, stored)
+	})
+
+	t.Run("returns edit conflict for stale version", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t, &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending})
+
+		// Execute
+		err := m.Update(&Transaction{ID: 1, Version: 2, Amount: 200, Status: TransactionStatusPending})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrEditConflict)
+	})
+
+	t.Run("concurrent updates of one version conflict", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t, &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending})
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+
+		// Execute
+		for i := range 10 {
+			wg.Go(func() {
+				errs <- m.Update(&Transaction{ID: 1, Version: 1, Amount: i, Status: TransactionStatusPending})
+			})
+		}
+		wg.Wait()
+		close(errs)
+
+		// Assert
+		var succeeded int
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, ErrEditConflict)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+func TestMemoryTransactionModelDeleteMany(t *testing.T) {
+	t.Run("deletes matching transactions created before", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t,
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusFailed},
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+			&Transaction{UserId: 2, Amount: 100, Status: TransactionStatusFailed},
+		)
+
+		// Execute
+		deleted, err := m.DeleteMany(TransactionDeleteManyParam{
+			FilterStatus:        string(TransactionStatusFailed),
+			FilterUserId:        1,
+			FilterCreatedBefore: m.now().Add(time.Second),
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		_, err = m.GetById(1)
+		assert.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestMemoryTransactionModelBulkUpdateStatus(t *testing.T) {
+	t.Run("updates targets and reports conflicts", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t,
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusFailed},
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending},
+		)
+
+		// Execute
+		result, err := m.BulkUpdateStatus(TransactionBulkParam{
+			Targets: []TransactionBulkTarget{{ID: 3}, {ID: 2}, {ID: 1, Version: 1}, {ID: 9}},
+		}, TransactionStatusSucces)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Affected)
+		require.Len(t, result.Transactions, 2)
+		assert.Equal(t, 1, result.Transactions[0].ID)
+		assert.Equal(t, 2, result.Transactions[0].Version)
+		assert.Equal(t, TransactionStatusSucces, result.Transactions[0].Status)
+		assert.Equal(t, 3, result.Transactions[1].ID)
+		assert.Equal(t, []TransactionBulkConflict{
+			{ID: 2, Reason: BulkConflictInvalidTransition, Version: 1, Status: TransactionStatusFailed},
+			{ID: 9, Reason: BulkConflictNotFound},
+		}, result.Conflicts)
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t, &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending})
+
+		// Execute
+		result, err := m.BulkUpdateStatus(TransactionBulkParam{DryRun: true}, TransactionStatusFailed)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Affected)
+		assert.Nil(t, result.Transactions)
+
+		stored, err := m.GetById(1)
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusPending, stored.Status)
+	})
+}
+
+func TestMemoryTransactionModelSummary(t *testing.T) {
+	t.Run("counts transactions within date range", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t,
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusSucces},
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusSucces},
+			&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusFailed},
+		)
+		m.now = func() time.Time { return time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC) }
+		require.NoError(t, m.Insert(&Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending}))
+
+		// Execute
+		all, _, err := m.Summary(TransactionSummaryParam{Page: 1, PageSize: 10, SortColumn: "id", SortDirection: "ASC"})
+		require.NoError(t, err)
+		recent, _, err := m.Summary(TransactionSummaryParam{Page: 1, PageSize: 10, SortColumn: "id", SortDirection: "ASC", FilterDateRange: 2})
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 4, all.Summary.CountTotal)
+		assert.Equal(t, 50.0, all.Summary.Success.RatePercentage)
+		assert.Equal(t, 25.0, all.Summary.Failed.RatePercentage)
+		assert.Equal(t, 25.0, all.Summary.Pending.RatePercentage)
+
+		assert.Equal(t, 1, recent.Summary.CountTotal)
+		assert.Equal(t, 100.0, recent.Summary.Pending.RatePercentage)
+	})
+}
+
+func TestMemoryTransactionModelExport(t *testing.T) {
+	t.Run("stops when context is canceled", func(t *testing.T) {
+		// Setup
+		m := newTestMemoryTransactionModel(t, &Transaction{UserId: 1, Amount: 100, Status: TransactionStatusPending})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Execute
+		err := m.Export(ctx, TransactionExportParam{SortColumn: "id", SortDirection: "ASC"}, func(*Transaction) error {
+			return nil
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,6 +15,16 @@ func (m *MockTransactionModel) Insert(transaction *Transaction) error {
 	return args.Error(0)
 }
 
+func (m *MockTransactionModel) InsertMany(transactions []*Transaction) error {
+	args := m.Called(transactions)
+	return args.Error(0)
+}
+
+func (m *MockTransactionModel) InsertImported(jobId int, rows []TransactionImportRow) error {
+	args := m.Called(jobId, rows)
+	return args.Error(0)
+}
+
 func (m *MockTransactionModel) GetAll(param TransactionGetAllParam) ([]*Transaction, *Metadata, error) {
 	args := m.Called(param)
 
@@ -36,6 +48,11 @@ func (m *MockTransactionModel) GetById(id int) (*Transaction, error) {
 	return args.Get(0).(*Transaction), args.Error(1)
 }
 
+func (m *MockTransactionModel) Export(ctx context.Context, param TransactionExportParam, fn func(*Transaction) error) error {
+	args := m.Called(ctx, param, fn)
+	return args.Error(0)
+}
+
 func (m *MockTransactionModel) Update(transaction *Transaction) error {
 	args := m.Called(transaction)
 	return args.Error(0)
@@ -46,6 +63,31 @@ func (m *MockTransactionModel) DeleteOne(id int) error {
 	return args.Error(0)
 }
 
+func (m *MockTransactionModel) DeleteMany(param TransactionDeleteManyParam) (int, error) {
+	args := m.Called(param)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTransactionModel) BulkUpdateStatus(param TransactionBulkParam, status TransactionStatus) (*TransactionBulkResult, error) {
+	args := m.Called(param, status)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*TransactionBulkResult), args.Error(1)
+}
+
+func (m *MockTransactionModel) BulkDelete(param TransactionBulkParam) (*TransactionBulkResult, error) {
+	args := m.Called(param)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*TransactionBulkResult), args.Error(1)
+}
+
 func (m *MockTransactionModel) Summary(param TransactionSummaryParam) (*TransactionSummary, *Metadata, error) {
 	args := m.Called(param)
 
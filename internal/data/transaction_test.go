@@ -0,0 +1,27 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionStatusCanTransitionTo(t *testing.T) {
+	t.Run("pending settles as success or failed", func(t *testing.T) {
+		assert.True(t, TransactionStatusPending.CanTransitionTo(TransactionStatusSucces))
+		assert.True(t, TransactionStatusPending.CanTransitionTo(TransactionStatusFailed))
+	})
+
+	t.Run("settled statuses are final", func(t *testing.T) {
+		assert.False(t, TransactionStatusSucces.CanTransitionTo(TransactionStatusPending))
+		assert.False(t, TransactionStatusSucces.CanTransitionTo(TransactionStatusFailed))
+		assert.False(t, TransactionStatusFailed.CanTransitionTo(TransactionStatusPending))
+		assert.False(t, TransactionStatusFailed.CanTransitionTo(TransactionStatusSucces))
+	})
+
+	t.Run("keeping the same status is allowed", func(t *testing.T) {
+		assert.True(t, TransactionStatusPending.CanTransitionTo(TransactionStatusPending))
+		assert.True(t, TransactionStatusSucces.CanTransitionTo(TransactionStatusSucces))
+		assert.True(t, TransactionStatusFailed.CanTransitionTo(TransactionStatusFailed))
+	})
+}
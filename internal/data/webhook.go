@@ -0,0 +1,489 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/pgtype"
+)
+
+type Webhook struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Version    int       `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookModeler interface {
+	Insert(webhook *Webhook) error
+	GetAll(param WebhookGetAllParam) ([]*Webhook, *Metadata, error)
+	GetById(id int) (*Webhook, error)
+	DeleteOne(id int) error
+}
+
+type WebhookModel struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func (m WebhookModel) Insert(webhook *Webhook) error {
+	query := `
+        INSERT INTO webhooks (url, secret, event_types)
+        VALUES ($1, $2, $3)
+        RETURNING id, version, created_at, updated_at`
+
+	var eventTypes pgtype.TextArray
+	if err := eventTypes.Set(webhook.EventTypes); err != nil {
+		return err
+	}
+
+	args := []any{webhook.URL, webhook.Secret, &eventTypes}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	return m.db.QueryRowContext(ctx, query, args...).Scan(
+		&webhook.ID,
+		&webhook.Version,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+}
+
+type WebhookGetAllParam struct {
+	Page       int
+	PageSize   int
+	PageOffset int
+}
+
+// GetAll lists the webhooks without their secrets, the secret is only
+// returned when a webhook is created.
+func (m WebhookModel) GetAll(param WebhookGetAllParam) ([]*Webhook, *Metadata, error) {
+	query := `
+	    SELECT
+			count(*) OVER() as total_count,
+			id, url, event_types, version, created_at, updated_at
+	    FROM webhooks
+	    ORDER BY id ASC
+	    LIMIT $1 OFFSET $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, param.PageSize, param.PageOffset)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var totalRecords int
+	var webhooks []*Webhook
+
+	for rows.Next() {
+		var webhook Webhook
+		var eventTypes pgtype.TextArray
+
+		err := rows.Scan(
+			&totalRecords, // count from window function
+			&webhook.ID,
+			&webhook.URL,
+			&eventTypes,
+			&webhook.Version,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := eventTypes.AssignTo(&webhook.EventTypes); err != nil {
+			return nil, nil, err
+		}
+
+		webhooks = append(webhooks, &webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	metadata := calculateMetadata(totalRecords, param.Page, param.PageSize)
+	return webhooks, &metadata, nil
+}
+
+// GetById returns the webhook without its secret.
+func (m WebhookModel) GetById(id int) (*Webhook, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, url, event_types, version, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1`
+
+	var webhook Webhook
+	var eventTypes pgtype.TextArray
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	err := m.db.QueryRowContext(ctx, query, id).Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&eventTypes,
+		&webhook.Version,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err := eventTypes.AssignTo(&webhook.EventTypes); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// DeleteOne removes the webhook together with its deliveries.
+func (m WebhookModel) DeleteOne(id int) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+	query := `DELETE FROM webhooks WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event to be sent to one webhook. Payload is the
+// request body, the encoded Event.
+type WebhookDelivery struct {
+	ID            int                   `json:"id"`
+	WebhookId     int                   `json:"webhook_id"`
+	EventId       string                `json:"event_id"`
+	EventType     EventType             `json:"event_type"`
+	Payload       json.RawMessage       `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Log           []*WebhookAttempt     `json:"log"`
+
+	// Set by Claim only.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt is the outcome of one request. StatusCode is zero when no
+// response was received.
+type WebhookAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitzero"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type WebhookDeliveryModeler interface {
+	Enqueue(event Event) (int, error)
+	Claim(limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	RecordAttempt(delivery *WebhookDelivery, attempt *WebhookAttempt) error
+	GetAll(param WebhookDeliveryGetAllParam) ([]*WebhookDelivery, *Metadata, error)
+}
+
+type WebhookDeliveryModel struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// Enqueue creates a pending delivery of event for every webhook subscribed to
+// its type, and returns how many were created. Enqueueing an event twice does
+// not create duplicate deliveries.
+func (m WebhookDeliveryModel) Enqueue(event Event) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3::jsonb
+		FROM webhooks
+		WHERE $2 = ANY(event_types)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query, event.ID, string(event.Type), string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// Claim returns up to limit pending deliveries that are due, and pushes their
+// next attempt back by lease. A delivery whose worker dies before recording
+// the attempt is therefore retried once the lease runs out.
+func (m WebhookDeliveryModel) Claim(limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2::bigint * INTERVAL '1 millisecond', updated_at = NOW()
+		FROM webhooks w
+		WHERE d.webhook_id = w.id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC, id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+			d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status,
+			d.attempts, d.next_attempt_at, d.created_at, d.updated_at, w.url, w.secret`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookId,
+			&delivery.EventId,
+			&delivery.EventType,
+			(*[]byte)(&delivery.Payload),
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+			&delivery.URL,
+			&delivery.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt logs attempt and saves the Status, Attempts and NextAttemptAt
+// of delivery.
+func (m WebhookDeliveryModel) RecordAttempt(delivery *WebhookDelivery, attempt *WebhookAttempt) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		delivery.ID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMs, attempt.AttemptedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ID,
+	).Scan(&delivery.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+type WebhookDeliveryGetAllParam struct {
+	Page         int
+	PageSize     int
+	PageOffset   int
+	WebhookId    int
+	FilterStatus string
+}
+
+// GetAll lists the deliveries of one webhook, newest first, each with the log
+// of its attempts.
+func (m WebhookDeliveryModel) GetAll(param WebhookDeliveryGetAllParam) ([]*WebhookDelivery, *Metadata, error) {
+	query := `
+	    SELECT
+			count(*) OVER() as total_count,
+			id, webhook_id, event_id, event_type, payload, status,
+			attempts, next_attempt_at, created_at, updated_at
+	    FROM webhook_deliveries
+	    WHERE
+			webhook_id = $1
+			AND
+			(CASE
+				WHEN $2 = '' THEN TRUE
+				ELSE status = $2
+			END)
+	    ORDER BY id DESC
+	    LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	defer cancel()
+
+	args := []any{param.WebhookId, param.FilterStatus, param.PageSize, param.PageOffset}
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var totalRecords int
+	var deliveries []*WebhookDelivery
+	byId := make(map[int]*WebhookDelivery)
+	var ids []int64
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err := rows.Scan(
+			&totalRecords, // count from window function
+			&delivery.ID,
+			&delivery.WebhookId,
+			&delivery.EventId,
+			&delivery.EventType,
+			(*[]byte)(&delivery.Payload),
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		delivery.Log = []*WebhookAttempt{}
+		deliveries = append(deliveries, &delivery)
+		byId[delivery.ID] = &delivery
+		ids = append(ids, int64(delivery.ID))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(ids) > 0 {
+		err = m.loadAttempts(ctx, ids, byId)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	metadata := calculateMetadata(totalRecords, param.Page, param.PageSize)
+	return deliveries, &metadata, nil
+}
+
+func (m WebhookDeliveryModel) loadAttempts(ctx context.Context, ids []int64, byId map[int]*WebhookDelivery) error {
+	query := `
+		SELECT delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = ANY($1)
+		ORDER BY delivery_id, attempt ASC`
+
+	var deliveryIds pgtype.Int8Array
+	if err := deliveryIds.Set(ids); err != nil {
+		return err
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, &deliveryIds)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryId int
+		var attempt WebhookAttempt
+		err := rows.Scan(
+			&deliveryId,
+			&attempt.Attempt,
+			&attempt.StatusCode,
+			&attempt.Error,
+			&attempt.DurationMs,
+			&attempt.AttemptedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if delivery, ok := byId[deliveryId]; ok {
+			delivery.Log = append(delivery.Log, &attempt)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookModel struct {
+	mock.Mock
+}
+
+func (m *MockWebhookModel) Insert(webhook *Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookModel) GetAll(param WebhookGetAllParam) ([]*Webhook, *Metadata, error) {
+	args := m.Called(param)
+
+	if args.Get(0) == nil {
+		if args.Get(1) == nil {
+			return nil, nil, args.Error(2)
+		}
+		return nil, args.Get(1).(*Metadata), args.Error(2)
+	}
+
+	return args.Get(0).([]*Webhook), args.Get(1).(*Metadata), args.Error(2)
+}
+
+func (m *MockWebhookModel) GetById(id int) (*Webhook, error) {
+	args := m.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Webhook), args.Error(1)
+}
+
+func (m *MockWebhookModel) DeleteOne(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockWebhookDeliveryModel struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryModel) Enqueue(event Event) (int, error) {
+	args := m.Called(event)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryModel) Claim(limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	args := m.Called(limit, lease)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryModel) RecordAttempt(delivery *WebhookDelivery, attempt *WebhookAttempt) error {
+	args := m.Called(delivery, attempt)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryModel) GetAll(param WebhookDeliveryGetAllParam) ([]*WebhookDelivery, *Metadata, error) {
+	args := m.Called(param)
+
+	if args.Get(0) == nil {
+		if args.Get(1) == nil {
+			return nil, nil, args.Error(2)
+		}
+		return nil, args.Get(1).(*Metadata), args.Error(2)
+	}
+
+	return args.Get(0).([]*WebhookDelivery), args.Get(1).(*Metadata), args.Error(2)
+}
@@ -0,0 +1,357 @@
+// Package jobs runs the background work queued in the jobs table. Any number
+// of instances can run a Pool against the same database, a job is only ever
+// run by the worker that claimed it.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+// Progress stores what a running job reports about itself and the checkpoint
+// it resumes from when it is run again. A nil progress or checkpoint keeps
+// the stored one. It also extends the job's lease.
+type Progress func(progress, checkpoint any) error
+
+// Handler runs the jobs of one kind. The result is stored as JSON once Run
+// returns without error. ctx is done when the pool shuts down, a handler
+// then either returns soon, resuming from its checkpoint next time, or
+// finishes the job.
+type Handler interface {
+	Run(ctx context.Context, job *data.Job, progress Progress) (result any, err error)
+}
+
+type HandlerFunc func(ctx context.Context, job *data.Job, progress Progress) (any, error)
+
+func (f HandlerFunc) Run(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+	return f(ctx, job, progress)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that running the job again won't fix, the job
+// fails without using its remaining attempts.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsPermanent tells whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// New creates a job of kind to be queued with JobModeler.Insert.
+func New(kind string, payload any, maxAttempts int) (*data.Job, error) {
+	document, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.Job{
+		Kind:        kind,
+		Payload:     document,
+		MaxAttempts: maxAttempts,
+	}, nil
+}
+
+// errLeaseLost cancels a job whose lease ran out and which was claimed
+// again.
+var errLeaseLost = errors.New("job lease lost")
+
+type Options struct {
+	// Workers is the number of jobs run at once.
+	Workers int
+	// PollInterval is how often due jobs are looked up while a worker is
+	// idle.
+	PollInterval time.Duration
+	// Lease is how long a claimed job is hidden from other pools. It is
+	// extended while the job runs, so it only bounds how long the job of a
+	// dead worker waits to be claimed again.
+	Lease time.Duration
+	// BackoffBase is the wait after the first failed attempt, it doubles with
+	// every further attempt up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long finished jobs are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Workers:      4,
+		PollInterval: time.Second,
+		Lease:        time.Minute,
+		BackoffBase:  10 * time.Second,
+		BackoffMax:   10 * time.Minute,
+		Retention:    7 * 24 * time.Hour,
+	}
+}
+
+// Backoff returns how long to wait before retrying after failed attempt n,
+// counting from 1.
+func (o Options) Backoff(n int) time.Duration {
+	wait := o.BackoffBase
+	for i := 1; i < n && wait < o.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, o.BackoffMax)
+}
+
+type Pool struct {
+	store    data.JobModeler
+	handlers map[string]Handler
+	logger   *tlog.Logger
+	opts     Options
+	now      func() time.Time
+	running  atomic.Int64
+	wg       sync.WaitGroup
+}
+
+func NewPool(store data.JobModeler, logger *tlog.Logger, opts Options) *Pool {
+	return &Pool{
+		store:    store,
+		handlers: make(map[string]Handler),
+		logger:   logger,
+		opts:     opts,
+		now:      time.Now,
+	}
+}
+
+// Register sets the handler of a job kind. It must not be called once the
+// pool runs.
+func (p *Pool) Register(kind string, handler Handler) {
+	p.handlers[kind] = handler
+}
+
+// Run claims due jobs every PollInterval until ctx is done. It then waits for
+// the running jobs, which see ctx done too, to checkpoint or finish.
+func (p *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.wg.Wait()
+			return
+		case <-ticker.C:
+			if _, err := p.ClaimDue(ctx); err != nil {
+				p.logger.Errorj(tlog.JSON{"message": "failed claiming jobs", "error": err})
+			}
+
+			if p.opts.Retention > 0 && p.now().Sub(lastPrune) >= time.Hour {
+				lastPrune = p.now()
+				p.prune()
+			}
+		}
+	}
+}
+
+// ClaimDue claims a due job for every idle worker and starts them. It
+// returns the number of jobs claimed.
+func (p *Pool) ClaimDue(ctx context.Context) (int, error) {
+	idle := p.opts.Workers - int(p.running.Load())
+	if idle <= 0 || ctx.Err() != nil {
+		return 0, nil
+	}
+
+	jobs, err := p.store.Claim(idle, p.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		p.running.Add(1)
+		p.wg.Go(func() {
+			defer p.running.Add(-1)
+			p.work(ctx, job)
+		})
+	}
+	return len(jobs), nil
+}
+
+func (p *Pool) work(ctx context.Context, job *data.Job) {
+	handler, ok := p.handlers[job.Kind]
+	if !ok {
+		// Another instance may know the kind, give it the chance to claim
+		// it.
+		p.finish(job, fmt.Errorf("no handler for job kind %q", job.Kind))
+		return
+	}
+
+	// The attempts of a job whose worker died are used up without a
+	// failure being recorded.
+	if job.Attempts > job.MaxAttempts {
+		p.finish(job, Permanent(errors.New("job stopped running before finishing on every attempt")))
+		return
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	a := &attempt{pool: p, job: job, cancel: cancel}
+
+	stop := make(chan struct{})
+	var heartbeats sync.WaitGroup
+	heartbeats.Go(func() { a.keepAlive(stop) })
+
+	result, err := handler.Run(jobCtx, job, a.save)
+
+	close(stop)
+	heartbeats.Wait()
+
+	switch {
+	case errors.Is(context.Cause(jobCtx), errLeaseLost):
+		p.logger.Warnj(tlog.JSON{"message": "job lease lost", "job_id": job.ID, "kind": job.Kind, "attempt": job.Attempts})
+	case err != nil && ctx.Err() != nil:
+		p.release(job)
+	case err != nil:
+		p.finish(job, err)
+	default:
+		p.complete(job, result)
+	}
+}
+
+func (p *Pool) complete(job *data.Job, result any) {
+	document, err := json.Marshal(result)
+	if err != nil {
+		p.finish(job, Permanent(fmt.Errorf("failed encoding result: %w", err)))
+		return
+	}
+	job.Result = document
+
+	if err := p.store.Complete(job); err != nil {
+		p.logger.Errorj(tlog.JSON{"message": "failed completing job", "job_id": job.ID, "error": err})
+		return
+	}
+	p.logger.Infoj(tlog.JSON{"message": "job completed", "job_id": job.ID, "kind": job.Kind, "attempt": job.Attempts})
+}
+
+// finish records a failed attempt, the job is retried after a backoff while
+// it has attempts left.
+func (p *Pool) finish(job *data.Job, cause error) {
+	if IsPermanent(cause) || job.Attempts >= job.MaxAttempts {
+		p.logger.Errorj(tlog.JSON{"message": "job failed", "job_id": job.ID, "kind": job.Kind, "attempts": job.Attempts, "error": cause})
+
+		if err := p.store.Fail(job, cause.Error()); err != nil {
+			p.logger.Errorj(tlog.JSON{"message": "failed marking job", "job_id": job.ID, "error": err})
+		}
+		return
+	}
+
+	next := p.now().Add(p.opts.Backoff(job.Attempts))
+	p.logger.Warnj(tlog.JSON{
+		"message":  "job attempt failed",
+		"job_id":   job.ID,
+		"kind":     job.Kind,
+		"attempts": job.Attempts,
+		"retry_at": next,
+		"error":    cause,
+	})
+
+	if err := p.store.Retry(job, next, cause.Error()); err != nil {
+		p.logger.Errorj(tlog.JSON{"message": "failed marking job", "job_id": job.ID, "error": err})
+	}
+}
+
+// release gives back a job stopped by shutdown, it is claimed again from its
+// last checkpoint and the attempt doesn't count.
+func (p *Pool) release(job *data.Job) {
+	if err := p.store.Release(job); err != nil {
+		// The job is claimed again once the lease runs out.
+		p.logger.Errorj(tlog.JSON{"message": "failed releasing job", "job_id": job.ID, "error": err})
+		return
+	}
+	p.logger.Infoj(tlog.JSON{"message": "job released on shutdown", "job_id": job.ID, "kind": job.Kind})
+}
+
+func (p *Pool) prune() {
+	deleted, err := p.store.DeleteFinished(p.now().Add(-p.opts.Retention))
+	if err != nil {
+		p.logger.Errorj(tlog.JSON{"message": "failed pruning jobs", "error": err})
+		return
+	}
+	if deleted > 0 {
+		p.logger.Infoj(tlog.JSON{"message": "pruned jobs", "deleted": deleted})
+	}
+}
+
+// attempt is one run of a job. Its progress is saved by the handler and its
+// lease extended in the background, both go through mu.
+type attempt struct {
+	pool   *Pool
+	job    *data.Job
+	cancel context.CancelCauseFunc
+	mu     sync.Mutex
+}
+
+func (a *attempt) save(progress, checkpoint any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if progress != nil {
+		document, err := json.Marshal(progress)
+		if err != nil {
+			return err
+		}
+		a.job.Progress = document
+	}
+
+	if checkpoint != nil {
+		document, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+		a.job.Checkpoint = document
+	}
+
+	return a.heartbeat()
+}
+
+// keepAlive extends the lease three times per lease until stop is closed, so
+// a job not reporting progress for a while isn't claimed again.
+func (a *attempt) keepAlive(stop <-chan struct{}) {
+	ticker := time.NewTicker(a.pool.opts.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			err := a.heartbeat()
+			a.mu.Unlock()
+
+			if err != nil && !errors.Is(err, errLeaseLost) {
+				a.pool.logger.Errorj(tlog.JSON{"message": "failed extending job lease", "job_id": a.job.ID, "error": err})
+			}
+		}
+	}
+}
+
+// heartbeat must be called with mu held.
+func (a *attempt) heartbeat() error {
+	err := a.pool.store.Heartbeat(a.job, a.pool.opts.Lease)
+	if errors.Is(err, data.ErrEditConflict) {
+		a.cancel(errLeaseLost)
+		return errLeaseLost
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+func newTestPool(store data.JobModeler, now time.Time) *Pool {
+	logger := tlog.Must(tlog.NewDevelopment())
+	logger.SetOutput(&bytes.Buffer{})
+
+	opts := DefaultOptions()
+	opts.Workers = 2
+
+	p := NewPool(store, logger, opts)
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func testJob(id int, attempts int) *data.Job {
+	return &data.Job{ID: id, Kind: "test", Status: data.JobRunning, Attempts: attempts, MaxAttempts: 3}
+}
+
+// runJob claims job and waits for its worker to be done.
+func runJob(t *testing.T, ctx context.Context, p *Pool, store *data.MockJobModel, job *data.Job) {
+	t.Helper()
+
+	store.On("Claim", 2, time.Minute).Return([]*data.Job{job}, nil).Once()
+
+	n, err := p.ClaimDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	p.wg.Wait()
+}
+
+func TestPoolClaimDue(t *testing.T) {
+	now := time.Now()
+
+	t.Run("completes job with its result", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Complete", job).Return(nil)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			return map[string]int{"rows": 2}, nil
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		assert.JSONEq(t, `{"rows": 2}`, string(job.Result))
+		store.AssertExpectations(t)
+	})
+
+	t.Run("schedules retry with backoff when attempt fails", func(t *testing.T) {
+		job := testJob(1, 2)
+
+		store := new(data.MockJobModel)
+		store.On("Retry", job, now.Add(20*time.Second), "boom").Return(nil)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			return nil, errors.New("boom")
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		store.AssertExpectations(t)
+	})
+
+	t.Run("fails job on its last attempt", func(t *testing.T) {
+		job := testJob(1, 3)
+
+		store := new(data.MockJobModel)
+		store.On("Fail", job, "boom").Return(nil)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			return nil, errors.New("boom")
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "Retry", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails job right away on permanent error", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Fail", job, "bad payload").Return(nil)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			return nil, Permanent(errors.New("bad payload"))
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		store.AssertExpectations(t)
+	})
+
+	t.Run("retries job of unknown kind", func(t *testing.T) {
+		job := testJob(1, 1)
+		job.Kind = "unknown"
+
+		store := new(data.MockJobModel)
+		store.On("Retry", job, now.Add(10*time.Second), `no handler for job kind "unknown"`).Return(nil)
+
+		pool := newTestPool(store, now)
+
+		runJob(t, context.Background(), pool, store, job)
+
+		store.AssertExpectations(t)
+	})
+
+	t.Run("fails job claimed again after its attempts ran out", func(t *testing.T) {
+		job := testJob(1, 4)
+
+		store := new(data.MockJobModel)
+		store.On("Fail", job, mock.Anything).Return(nil)
+
+		called := false
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			called = true
+			return nil, nil
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		assert.False(t, called)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("saves progress and checkpoint", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Heartbeat", job, time.Minute).Return(nil)
+		store.On("Complete", job).Return(nil)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			if err := progress(map[string]int{"rows": 1}, map[string]int{"offset": 10}); err != nil {
+				return nil, err
+			}
+			return nil, progress(map[string]int{"rows": 2}, nil)
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		assert.JSONEq(t, `{"rows": 2}`, string(job.Progress))
+		assert.JSONEq(t, `{"offset": 10}`, string(job.Checkpoint))
+		store.AssertNumberOfCalls(t, "Heartbeat", 2)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("stops job whose lease was lost", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Heartbeat", job, time.Minute).Return(data.ErrEditConflict)
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			if err := progress(nil, nil); err != nil {
+				return nil, err
+			}
+			return nil, ctx.Err()
+		}))
+
+		runJob(t, context.Background(), pool, store, job)
+
+		store.AssertNotCalled(t, "Retry", mock.Anything, mock.Anything, mock.Anything)
+		store.AssertNotCalled(t, "Fail", mock.Anything, mock.Anything)
+		store.AssertNotCalled(t, "Complete", mock.Anything)
+	})
+
+	t.Run("releases job stopped by shutdown", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Release", job).Return(nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+
+		store.On("Claim", 2, time.Minute).Return([]*data.Job{job}, nil).Once()
+		_, err := pool.ClaimDue(ctx)
+		require.NoError(t, err)
+
+		<-started
+		cancel()
+		pool.wg.Wait()
+
+		store.AssertExpectations(t)
+	})
+
+	t.Run("completes job finishing despite shutdown", func(t *testing.T) {
+		job := testJob(1, 1)
+
+		store := new(data.MockJobModel)
+		store.On("Complete", job).Return(nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		pool := newTestPool(store, now)
+		pool.Register("test", HandlerFunc(func(ctx context.Context, job *data.Job, progress Progress) (any, error) {
+			return "done", nil
+		}))
+
+		pool.work(ctx, job)
+
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "Release", mock.Anything)
+	})
+
+	t.Run("claims only for idle workers", func(t *testing.T) {
+		store := new(data.MockJobModel)
+
+		pool := newTestPool(store, now)
+		pool.running.Store(2)
+
+		n, err := pool.ClaimDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		store.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOptionsBackoff(t *testing.T) {
+	opts := Options{BackoffBase: 10 * time.Second, BackoffMax: time.Minute}
+
+	assert.Equal(t, 10*time.Second, opts.Backoff(1))
+	assert.Equal(t, 20*time.Second, opts.Backoff(2))
+	assert.Equal(t, 40*time.Second, opts.Backoff(3))
+	assert.Equal(t, time.Minute, opts.Backoff(4))
+	assert.Equal(t, time.Minute, opts.Backoff(30))
+}
@@ -0,0 +1,324 @@
+// Package migration applies the goose-annotated SQL files embedded in the
+// binary. It keeps its bookkeeping in goose's own goose_db_version table, so
+// databases migrated by the goose CLI and by this package stay interchangeable.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// lockID is the key of the Postgres advisory lock held while migrating, so
+// that concurrently starting instances don't race each other.
+const lockID int64 = 0x7463_7361_6d69_67 // "tcsamig"
+
+const versionTable = "goose_db_version"
+
+var ErrNoCurrentVersion = errors.New("no migration has been applied")
+
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, found := versions[migration.Version]; found {
+				continue
+			}
+
+			err := m.run(ctx, conn, migration, migration.UpStatements,
+				`INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, TRUE)`,
+			)
+			if err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration. It returns nil when
+// there is nothing to roll back.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.currentVersion(ctx, conn)
+		if err != nil {
+			if errors.Is(err, ErrNoCurrentVersion) {
+				return nil
+			}
+			return err
+		}
+
+		migration := m.find(current)
+		if migration == nil {
+			return fmt.Errorf("applied migration %d not found in embedded migrations", current)
+		}
+
+		err = m.run(ctx, conn, migration, migration.DownStatements,
+			`DELETE FROM `+versionTable+` WHERE version_id = $1`,
+		)
+		if err != nil {
+			return err
+		}
+
+		rolledBack = migration
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status reports every embedded migration alongside the time it was applied,
+// if it was. It only reads, a database without a version table reports every
+// migration as pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, found := versions[migration.Version]; found {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Version returns the most recently applied migration version, or 0 when
+// none has been applied yet. Like Status, it only reads.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		current, err := m.currentVersion(ctx, conn)
+		if err != nil && !errors.Is(err, ErrNoCurrentVersion) {
+			return err
+		}
+		version = current
+		return nil
+	})
+
+	return version, err
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// withLock pins a single connection, takes the advisory lock on it and makes
+// sure the version table exists before calling fn.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+		if err := m.ensureVersionTable(ctx, conn); err != nil {
+			return err
+		}
+
+		return fn(conn)
+	})
+}
+
+// withConn pins a single connection for fn without locking or writing
+// anything, for the commands that only read.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return fn(conn)
+}
+
+func (m *Migrator) versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, versionTable).Scan(&exists)
+	return exists, err
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := m.versionTableExists(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Same shape and initial row that goose creates.
+	_, err = tx.ExecContext(ctx, `
+		CREATE TABLE `+versionTable+` (
+			id SERIAL PRIMARY KEY,
+			version_id BIGINT NOT NULL,
+			is_applied BOOLEAN NOT NULL,
+			tstamp TIMESTAMP NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (0, TRUE)`)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	exists, err := m.versionTableExists(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		// Nothing was ever applied here, not even by the goose CLI.
+		return map[int64]time.Time{}, nil
+	}
+
+	query := `
+		SELECT version_id, is_applied, tstamp
+		FROM ` + versionTable + `
+		WHERE version_id > 0
+		ORDER BY id ASC`
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Older goose releases record a rollback as a new row with is_applied
+	// false instead of deleting the row, so the latest row per version wins.
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+			tstamp    sql.NullTime
+		)
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, err
+		}
+
+		if isApplied {
+			versions[version] = tstamp.Time
+		} else {
+			delete(versions, version)
+		}
+	}
+
+	return versions, rows.Err()
+}
+
+func (m *Migrator) currentVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	versions, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	var current int64
+	for version := range versions {
+		current = max(current, version)
+	}
+
+	if current == 0 {
+		return 0, ErrNoCurrentVersion
+	}
+	return current, nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// run executes statements and records the new version with bookkeeping,
+// inside a single transaction unless the migration opted out of it.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration *Migration, statements []string, bookkeeping string) error {
+	exec := func(db execer) error {
+		for _, stmt := range statements {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s: %w", migration.Name, err)
+			}
+		}
+
+		_, err := db.ExecContext(ctx, bookkeeping, migration.Version)
+		return err
+	}
+
+	if migration.NoTransaction {
+		return exec(conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := exec(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,70 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/migration"
+	"github.com/ucok-man/tcsa/internal/pgtest"
+	"github.com/ucok-man/tcsa/migrations"
+)
+
+func versionTableExists(t *testing.T, db *sql.DB) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`SELECT to_regclass('goose_db_version') IS NOT NULL`).Scan(&exists)
+	require.NoError(t, err)
+	return exists
+}
+
+func TestMigratorIntegration(t *testing.T) {
+	t.Run("reads a fresh database without writing to it", func(t *testing.T) {
+		// Setup
+		db := pgtest.NewEmpty(t)
+		migrator, err := migration.New(db, migrations.FS)
+		require.NoError(t, err)
+
+		// Execute
+		statuses, err := migrator.Status(context.Background())
+		require.NoError(t, err)
+
+		version, err := migrator.Version(context.Background())
+		require.NoError(t, err)
+
+		// Assert
+		require.NotEmpty(t, statuses)
+		for _, status := range statuses {
+			assert.Nil(t, status.AppliedAt, status.Name)
+		}
+		assert.Zero(t, version)
+		assert.False(t, versionTableExists(t, db))
+	})
+
+	t.Run("reports applied migrations", func(t *testing.T) {
+		// Setup
+		db := pgtest.NewEmpty(t)
+		migrator, err := migration.New(db, migrations.FS)
+		require.NoError(t, err)
+
+		applied, err := migrator.Up(context.Background())
+		require.NoError(t, err)
+		require.NotEmpty(t, applied)
+
+		// Execute
+		statuses, err := migrator.Status(context.Background())
+		require.NoError(t, err)
+
+		version, err := migrator.Version(context.Background())
+		require.NoError(t, err)
+
+		// Assert
+		for _, status := range statuses {
+			assert.NotNil(t, status.AppliedAt, status.Name)
+		}
+		assert.Equal(t, applied[len(applied)-1].Version, version)
+	})
+}
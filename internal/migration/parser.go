@@ -0,0 +1,186 @@
+package migration
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoVersion         = errors.New("migration filename must start with a numeric version")
+	ErrMissingUp         = errors.New("migration is missing a '-- +goose Up' annotation")
+	ErrUnterminatedBlock = errors.New("migration has a StatementBegin without a matching StatementEnd")
+)
+
+const annotationPrefix = "-- +goose"
+
+// Migration is a single goose-annotated SQL file.
+type Migration struct {
+	Version        int64
+	Name           string
+	UpStatements   []string
+	DownStatements []string
+	NoTransaction  bool
+}
+
+// Load reads every *.sql file at the root of fsys and returns them sorted by
+// version.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	filenames, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*Migration, 0, len(filenames))
+	seen := map[int64]string{}
+
+	for _, filename := range filenames {
+		version, err := parseVersion(filename)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		if other, found := seen[version]; found {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, filename)
+		}
+		seen[version] = filename
+
+		file, err := fsys.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, err := Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		migration.Version = version
+		migration.Name = filename
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Parse splits a goose-annotated SQL file into its up and down statements.
+// Statements end at a line terminated by a semicolon, unless they are wrapped
+// in StatementBegin/StatementEnd, in which case the whole block is one
+// statement.
+func Parse(r io.Reader) (*Migration, error) {
+	const (
+		sectionNone = iota
+		sectionUp
+		sectionDown
+	)
+
+	var (
+		migration Migration
+		section   = sectionNone
+		inBlock   bool
+		hasUp     bool
+		buf       strings.Builder
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt == "" {
+			return
+		}
+
+		switch section {
+		case sectionUp:
+			migration.UpStatements = append(migration.UpStatements, stmt)
+		case sectionDown:
+			migration.DownStatements = append(migration.DownStatements, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, annotationPrefix) {
+			annotation := strings.TrimSpace(strings.TrimPrefix(trimmed, annotationPrefix))
+
+			switch strings.ToUpper(annotation) {
+			case "UP":
+				flush()
+				section = sectionUp
+				hasUp = true
+			case "DOWN":
+				flush()
+				section = sectionDown
+			case "STATEMENTBEGIN":
+				flush()
+				inBlock = true
+			case "STATEMENTEND":
+				flush()
+				inBlock = false
+			case "NO TRANSACTION":
+				migration.NoTransaction = true
+			}
+			continue
+		}
+
+		if section == sectionNone {
+			continue
+		}
+
+		// Skip standalone comments between statements, they would otherwise
+		// become empty statements.
+		if !inBlock && buf.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if inBlock {
+		return nil, ErrUnterminatedBlock
+	}
+	if !hasUp {
+		return nil, ErrMissingUp
+	}
+
+	flush()
+	return &migration, nil
+}
+
+func parseVersion(filename string) (int64, error) {
+	base := path.Base(filename)
+	prefix, _, found := strings.Cut(base, "_")
+	if !found {
+		return 0, ErrNoVersion
+	}
+
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return 0, ErrNoVersion
+	}
+	return version, nil
+}
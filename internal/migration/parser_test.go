@@ -0,0 +1,138 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/migrations"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("splits up and down sections", func(t *testing.T) {
+		src := `-- +goose Up
+CREATE TABLE a (id INT);
+CREATE TABLE b (id INT);
+
+-- +goose Down
+DROP TABLE b;
+DROP TABLE a;
+`
+		migration, err := Parse(strings.NewReader(src))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);"}, migration.UpStatements)
+		assert.Equal(t, []string{"DROP TABLE b;", "DROP TABLE a;"}, migration.DownStatements)
+		assert.False(t, migration.NoTransaction)
+	})
+
+	t.Run("keeps statement blocks together", func(t *testing.T) {
+		src := `-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS INT AS $$
+BEGIN
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+`
+		migration, err := Parse(strings.NewReader(src))
+
+		require.NoError(t, err)
+		require.Len(t, migration.UpStatements, 1)
+		assert.Contains(t, migration.UpStatements[0], "RETURN 1;")
+		assert.Contains(t, migration.UpStatements[0], "LANGUAGE plpgsql;")
+		assert.Empty(t, migration.DownStatements)
+	})
+
+	t.Run("joins statements spanning multiple lines", func(t *testing.T) {
+		src := `-- +goose Up
+-- leading comment
+INSERT INTO a (id)
+VALUES (1);
+`
+		migration, err := Parse(strings.NewReader(src))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"INSERT INTO a (id)\nVALUES (1);"}, migration.UpStatements)
+	})
+
+	t.Run("detects no transaction annotation", func(t *testing.T) {
+		src := `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY idx ON a (id);
+`
+		migration, err := Parse(strings.NewReader(src))
+
+		require.NoError(t, err)
+		assert.True(t, migration.NoTransaction)
+	})
+
+	t.Run("returns error without up annotation", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("CREATE TABLE a (id INT);"))
+
+		assert.ErrorIs(t, err, ErrMissingUp)
+	})
+
+	t.Run("returns error for unterminated statement block", func(t *testing.T) {
+		src := `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE a (id INT);
+`
+		_, err := Parse(strings.NewReader(src))
+
+		assert.ErrorIs(t, err, ErrUnterminatedBlock)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("sorts migrations by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"20_second.sql": {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+			"3_first.sql":   {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"README.md":     {Data: []byte("ignored")},
+		}
+
+		migrations, err := Load(fsys)
+
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, int64(3), migrations[0].Version)
+		assert.Equal(t, "3_first.sql", migrations[0].Name)
+		assert.Equal(t, int64(20), migrations[1].Version)
+	})
+
+	t.Run("returns error for filename without version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"schema.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		}
+
+		_, err := Load(fsys)
+
+		assert.ErrorIs(t, err, ErrNoVersion)
+	})
+
+	t.Run("returns error for duplicate versions", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"1_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		}
+
+		_, err := Load(fsys)
+
+		assert.ErrorContains(t, err, "duplicate migration version")
+	})
+
+	t.Run("parses embedded project migrations", func(t *testing.T) {
+		loaded, err := Load(migrations.FS)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		for _, migration := range loaded {
+			assert.NotEmpty(t, migration.UpStatements, migration.Name)
+			assert.NotEmpty(t, migration.DownStatements, migration.Name)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+// Package openapi builds OpenAPI 3.0 documents from Go types. Schemas are
+// derived from the json, query and param struct tags, and from the validate
+// tags of go-playground/validator, so the document follows the DTOs it
+// describes. Only the parts of the specification the API uses are modelled.
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Version is the OpenAPI version of the documents.
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Info       Info                 `yaml:"info"`
+	Servers    []Server             `yaml:"servers,omitempty"`
+	Tags       []Tag                `yaml:"tags,omitempty"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+type Info struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	Version     string `yaml:"version"`
+}
+
+type Server struct {
+	URL         string `yaml:"url"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type PathItem struct {
+	Get    *Operation `yaml:"get,omitempty"`
+	Put    *Operation `yaml:"put,omitempty"`
+	Post   *Operation `yaml:"post,omitempty"`
+	Delete *Operation `yaml:"delete,omitempty"`
+	Patch  *Operation `yaml:"patch,omitempty"`
+}
+
+// Operation returns the operation of method, or nil.
+func (p *PathItem) Operation(method string) *Operation {
+	if field := p.field(method); field != nil {
+		return *field
+	}
+	return nil
+}
+
+// SetOperation sets the operation of method. It panics for a method a path
+// item can't hold.
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	field := p.field(method)
+	if field == nil {
+		panic(fmt.Sprintf("openapi: unsupported method %s", method))
+	}
+	*field = op
+}
+
+// Operations returns the operations by method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := map[string]*Operation{}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch} {
+		if op := p.Operation(method); op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+func (p *PathItem) field(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodPatch:
+		return &p.Patch
+	default:
+		return nil
+	}
+}
+
+type Operation struct {
+	Tags        []string             `yaml:"tags,omitempty"`
+	Summary     string               `yaml:"summary,omitempty"`
+	Description string               `yaml:"description,omitempty"`
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*Parameter         `yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Description string                `yaml:"description,omitempty"`
+	Required    bool                  `yaml:"required,omitempty"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Response is either a reference to a response of the components, or a
+// response of its own.
+type Response struct {
+	Ref         string                `yaml:"$ref,omitempty"`
+	Description string                `yaml:"description,omitempty"`
+	Headers     map[string]*Header    `yaml:"headers,omitempty"`
+	Content     map[string]*MediaType `yaml:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `yaml:"description,omitempty"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type Components struct {
+	Schemas   map[string]*Schema   `yaml:"schemas,omitempty"`
+	Responses map[string]*Response `yaml:"responses,omitempty"`
+}
+
+// Schema is the subset of the OpenAPI schema object used by the API. A
+// schema with Ref set has no other field.
+type Schema struct {
+	Ref                  string     `yaml:"$ref,omitempty"`
+	Type                 string     `yaml:"type,omitempty"`
+	Format               string     `yaml:"format,omitempty"`
+	Description          string     `yaml:"description,omitempty"`
+	Nullable             bool       `yaml:"nullable,omitempty"`
+	Enum                 []any      `yaml:"enum,omitempty"`
+	Minimum              *float64   `yaml:"minimum,omitempty"`
+	Maximum              *float64   `yaml:"maximum,omitempty"`
+	MinLength            *int       `yaml:"minLength,omitempty"`
+	MaxLength            *int       `yaml:"maxLength,omitempty"`
+	MinItems             *int       `yaml:"minItems,omitempty"`
+	MaxItems             *int       `yaml:"maxItems,omitempty"`
+	UniqueItems          bool       `yaml:"uniqueItems,omitempty"`
+	Items                *Schema    `yaml:"items,omitempty"`
+	Required             []string   `yaml:"required,omitempty"`
+	Properties           Properties `yaml:"properties,omitempty"`
+	AdditionalProperties *Schema    `yaml:"additionalProperties,omitempty"`
+}
+
+// Property is a property of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties keeps the properties of an object in the order of the struct
+// fields, rather than sorted as a map would be.
+type Properties []Property
+
+// Get returns the schema of the property name, or nil.
+func (p Properties) Get(name string) *Schema {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema
+		}
+	}
+	return nil
+}
+
+func (p Properties) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, prop := range p {
+		var value yaml.Node
+		if err := value.Encode(prop.Schema); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: prop.Name}, &value)
+	}
+	return node, nil
+}
+
+func (p *Properties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("openapi: line %d: properties must be a mapping", node.Line)
+	}
+
+	*p = make(Properties, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		schema := &Schema{}
+		if err := node.Content[i+1].Decode(schema); err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: node.Content[i].Value, Schema: schema})
+	}
+	return nil
+}
+
+// Marshal encodes doc as YAML.
+func Marshal(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a YAML document.
+func Unmarshal(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,468 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Direction tells whether a type is read from requests or written to
+// responses. Requests require the fields validated as required, responses
+// always hold the fields that aren't omitempty.
+type Direction int
+
+const (
+	ForRequest Direction = iota
+	ForResponse
+)
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	rawJSONType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Generator derives schemas from Go types. Named struct types, and named
+// types with an enum, become schemas of the components, referenced from
+// where they are used.
+type Generator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	owners     map[string]reflect.Type
+	directions map[reflect.Type]Direction
+	enums      map[reflect.Type][]any
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+		owners:     map[string]reflect.Type{},
+		directions: map[reflect.Type]Direction{},
+		enums:      map[reflect.Type][]any{},
+	}
+}
+
+// Name sets the component name of t. Without one, the name is the type
+// name, capitalized, with a DTO suffix replaced by Request.
+func (g *Generator) Name(t reflect.Type, name string) {
+	g.names[t] = name
+}
+
+// Enum sets the values of a named type, which becomes a component.
+func (g *Generator) Enum(t reflect.Type, values ...any) {
+	g.enums[t] = values
+}
+
+// Components returns the component schemas of every type seen so far.
+func (g *Generator) Components() map[string]*Schema {
+	return g.components
+}
+
+// Schema returns the schema of t.
+func (g *Generator) Schema(t reflect.Type, dir Direction) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType || t.Kind() == reflect.Interface:
+		return &Schema{}
+	}
+
+	if _, ok := g.enums[t]; ok || (t.Kind() == reflect.Struct && t.Name() != "" && !strings.Contains(t.Name(), "[")) {
+		return g.component(t, dir)
+	}
+	return g.inline(t, dir)
+}
+
+// component returns a reference to the component of t, creating it first.
+func (g *Generator) component(t reflect.Type, dir Direction) *Schema {
+	name := g.componentName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if owner, ok := g.owners[name]; ok {
+		if owner != t {
+			panic(fmt.Sprintf("openapi: %s and %s are both named %s", owner, t, name))
+		}
+		if _, isEnum := g.enums[t]; !isEnum && g.directions[t] != dir {
+			panic(fmt.Sprintf("openapi: %s is used by both requests and responses", t))
+		}
+		return ref
+	}
+
+	g.owners[name] = t
+	g.directions[t] = dir
+
+	// Set before building, so a type referring to itself finds it.
+	schema := &Schema{}
+	g.components[name] = schema
+	*schema = *g.inline(t, dir)
+	return ref
+}
+
+func (g *Generator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+	name := string(runes)
+	if base, ok := strings.CutSuffix(name, "DTO"); ok {
+		name = base + "Request"
+	}
+	return name
+}
+
+// inline returns the schema of t itself, never a reference to it.
+func (g *Generator) inline(t reflect.Type, dir Direction) *Schema {
+	if values, ok := g.enums[t]; ok {
+		schema := g.kindSchema(t, dir)
+		schema.Enum = values
+		return schema
+	}
+	return g.kindSchema(t, dir)
+}
+
+func (g *Generator) kindSchema(t reflect.Type, dir Direction) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.Schema(t.Elem(), dir)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem(), dir)}
+	case reflect.Struct:
+		return g.object(t, dir)
+	default:
+		panic(fmt.Sprintf("openapi: unsupported type %s", t))
+	}
+}
+
+// object returns the schema of a struct as encoding/json sees it.
+func (g *Generator) object(t reflect.Type, dir Direction) *Schema {
+	schema := &Schema{Type: "object"}
+
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+
+			name, opts, tagged := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && !tagged {
+				embedded := field.Type
+				for embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					add(embedded)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			// Fields bound from the path or the query aren't part of the
+			// body.
+			if !tagged && (field.Tag.Get("param") != "" || field.Tag.Get("query") != "") {
+				continue
+			}
+
+			prop, required := g.field(field, dir)
+			if dir == ForResponse {
+				required = !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero")
+				if required && nullable(field.Type) {
+					prop = withNullable(prop)
+				}
+			}
+
+			schema.Properties = append(schema.Properties, Property{Name: name, Schema: prop})
+			if required {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	add(t)
+
+	return schema
+}
+
+// field returns the schema of a struct field with its validate rules, and
+// whether they require it.
+func (g *Generator) field(field reflect.StructField, dir Direction) (*Schema, bool) {
+	rules := validateRules(field.Tag.Get("validate"))
+	schema, required := g.validated(field.Type, rules, dir)
+
+	if doc := field.Tag.Get("doc"); doc != "" {
+		schema = withDescription(schema, doc)
+	}
+	return schema, required
+}
+
+// validated returns the schema of t constrained by rules. Rules after dive
+// apply to the items of a slice or the values of a map.
+func (g *Generator) validated(t reflect.Type, rules []rule, dir Direction) (*Schema, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	own, items := rules, []rule(nil)
+	for i, r := range rules {
+		if r.name == "dive" {
+			own, items = rules[:i], rules[i+1:]
+			break
+		}
+	}
+
+	var schema *Schema
+	if len(items) > 0 && (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) {
+		elem, _ := g.validated(t.Elem(), items, dir)
+		schema = g.kindSchema(t, dir)
+		if t.Kind() == reflect.Slice {
+			schema.Items = elem
+		} else {
+			schema.AdditionalProperties = elem
+		}
+	} else {
+		schema = g.Schema(t, dir)
+	}
+
+	if len(own) == 0 {
+		return schema, false
+	}
+
+	// Constraints can't sit next to a reference, the referenced schema is
+	// copied instead.
+	if schema.Ref != "" {
+		if !constrains(own) {
+			return schema, hasRule(own, "required")
+		}
+		copied := *g.inline(t, dir)
+		schema = &copied
+	}
+
+	required := false
+	for _, r := range own {
+		switch r.name {
+		case "required":
+			required = true
+		case "min", "gte":
+			applyBound(schema, t.Kind(), r.param, true)
+		case "max", "lte":
+			applyBound(schema, t.Kind(), r.param, false)
+		case "len":
+			applyBound(schema, t.Kind(), r.param, true)
+			applyBound(schema, t.Kind(), r.param, false)
+		case "oneof":
+			schema.Enum = nil
+			for _, value := range strings.Fields(r.param) {
+				schema.Enum = append(schema.Enum, enumValue(t.Kind(), value))
+			}
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "unique":
+			if r.param == "" {
+				schema.UniqueItems = true
+			}
+		}
+	}
+	return schema, required
+}
+
+// Parameters returns the parameters of a DTO, its fields tagged param are
+// bound from the path and those tagged query from the query string. Nested
+// structs without a tag are walked, as echo binds them.
+func (g *Generator) Parameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []*Parameter
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var in, name string
+		switch {
+		case field.Tag.Get("param") != "":
+			in, name = "path", field.Tag.Get("param")
+		case field.Tag.Get("query") != "":
+			in, name = "query", field.Tag.Get("query")
+		case field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "":
+			params = append(params, g.Parameters(field.Type)...)
+			continue
+		default:
+			continue
+		}
+
+		schema, required := g.field(field, ForRequest)
+		param := &Parameter{Name: name, In: in, Required: required || in == "path", Schema: schema}
+
+		// The description belongs to the parameter rather than its schema.
+		if doc := field.Tag.Get("doc"); doc != "" {
+			param.Description = doc
+			if schema.Ref == "" {
+				copied := *schema
+				copied.Description = ""
+				param.Schema = &copied
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func validateRules(tag string) []rule {
+	if tag == "" || tag == "-" {
+		return nil
+	}
+
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+func hasRule(rules []rule, name string) bool {
+	for _, r := range rules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// constrains reports whether rules change a schema beyond requiring it.
+func constrains(rules []rule) bool {
+	for _, r := range rules {
+		switch r.name {
+		case "min", "gte", "max", "lte", "len", "oneof", "url", "uri", "http_url", "email", "uuid", "uuid4":
+			return true
+		case "unique":
+			if r.param == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func applyBound(schema *Schema, kind reflect.Kind, param string, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch kind {
+	case reflect.String:
+		size := int(n)
+		if lower {
+			schema.MinLength = &size
+		} else {
+			schema.MaxLength = &size
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size := int(n)
+		if lower {
+			schema.MinItems = &size
+		} else {
+			schema.MaxItems = &size
+		}
+	default:
+		if lower {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	}
+}
+
+func enumValue(kind reflect.Kind, value string) any {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// jsonName returns the name encoding/json uses for field, the options of
+// its tag, and whether it has a json tag at all.
+func jsonName(field reflect.StructField) (string, string, bool) {
+	tag, tagged := field.Tag.Lookup("json")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, opts, tagged
+}
+
+// nullable reports whether encoding/json writes null for the zero value of
+// t. Raw JSON is left out, its schema allows anything.
+func nullable(t reflect.Type) bool {
+	if t == rawJSONType {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// withNullable returns schema allowing null. A reference can't be changed,
+// it is kept as is.
+func withNullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return schema
+	}
+	copied := *schema
+	copied.Nullable = true
+	return &copied
+}
+
+// withDescription returns schema with a description. A reference can't have
+// one, it is kept as is.
+func withDescription(schema *Schema, description string) *Schema {
+	if schema.Ref != "" {
+		return schema
+	}
+	copied := *schema
+	copied.Description = description
+	return &copied
+}
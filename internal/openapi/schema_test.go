@@ -0,0 +1,154 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type status string
+
+type itemDTO struct {
+	Amount int     `json:"amount" validate:"required,min=1,max=100"`
+	Status *string `json:"status" validate:"omitempty,oneof=pending success"`
+	Note   string  `json:"note,omitempty" validate:"max=10" doc:"Free text"`
+}
+
+type createDTO struct {
+	ID    int       `param:"id" validate:"required,min=1"`
+	Tags  []string  `json:"tags" validate:"required,min=1,unique,dive,oneof=a b"`
+	Items []itemDTO `json:"items" validate:"required,dive"`
+}
+
+type listDTO struct {
+	Pagination struct {
+		Page int `query:"page" validate:"omitempty,min=1"`
+	}
+	Sort string `query:"sort" validate:"omitempty,oneof=id -id" doc:"Sort order"`
+}
+
+type record struct {
+	ID        int        `json:"id"`
+	Status    status     `json:"status"`
+	Labels    []string   `json:"labels"`
+	Parent    *record    `json:"parent,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitzero"`
+}
+
+func TestGeneratorSchema(t *testing.T) {
+	t.Run("translates validate tags of requests", func(t *testing.T) {
+		// Setup
+		gen := NewGenerator()
+
+		// Execute
+		ref := gen.Schema(reflect.TypeFor[createDTO](), ForRequest)
+
+		// Assert
+		assert.Equal(t, "#/components/schemas/CreateRequest", ref.Ref)
+
+		create := gen.Components()["CreateRequest"]
+		require.NotNil(t, create)
+		assert.Equal(t, []string{"tags", "items"}, create.Required)
+		assert.Nil(t, create.Properties.Get("ID"), "path parameters aren't part of the body")
+
+		tags := create.Properties.Get("tags")
+		assert.Equal(t, 1, *tags.MinItems)
+		assert.True(t, tags.UniqueItems)
+		assert.Equal(t, []any{"a", "b"}, tags.Items.Enum)
+
+		item := gen.Components()["ItemRequest"]
+		require.NotNil(t, item)
+		assert.Equal(t, []string{"amount"}, item.Required)
+		assert.Equal(t, 1.0, *item.Properties.Get("amount").Minimum)
+		assert.Equal(t, 100.0, *item.Properties.Get("amount").Maximum)
+		assert.Equal(t, []any{"pending", "success"}, item.Properties.Get("status").Enum)
+		assert.Equal(t, 10, *item.Properties.Get("note").MaxLength)
+		assert.Equal(t, "Free text", item.Properties.Get("note").Description)
+	})
+
+	t.Run("requires the fields responses always hold", func(t *testing.T) {
+		// Setup
+		gen := NewGenerator()
+		gen.Enum(reflect.TypeFor[status](), "pending", "success")
+
+		// Execute
+		gen.Schema(reflect.TypeFor[record](), ForResponse)
+
+		// Assert
+		rec := gen.Components()["Record"]
+		require.NotNil(t, rec)
+		assert.Equal(t, []string{"id", "status", "labels", "created_at"}, rec.Required)
+		assert.True(t, rec.Properties.Get("labels").Nullable)
+		assert.Equal(t, "#/components/schemas/Record", rec.Properties.Get("parent").Ref)
+		assert.Equal(t, "date-time", rec.Properties.Get("deleted_at").Format)
+
+		assert.Equal(t, "#/components/schemas/Status", rec.Properties.Get("status").Ref)
+		assert.Equal(t, []any{"pending", "success"}, gen.Components()["Status"].Enum)
+	})
+
+	t.Run("refuses a type used by requests and responses", func(t *testing.T) {
+		// Setup
+		gen := NewGenerator()
+		gen.Schema(reflect.TypeFor[itemDTO](), ForRequest)
+
+		// Execute & Assert
+		assert.Panics(t, func() { gen.Schema(reflect.TypeFor[itemDTO](), ForResponse) })
+	})
+}
+
+func TestGeneratorParameters(t *testing.T) {
+	// Setup
+	gen := NewGenerator()
+
+	// Execute
+	path := gen.Parameters(reflect.TypeFor[createDTO]())
+	query := gen.Parameters(reflect.TypeFor[listDTO]())
+
+	// Assert
+	require.Len(t, path, 1)
+	assert.Equal(t, "id", path[0].Name)
+	assert.Equal(t, "path", path[0].In)
+	assert.True(t, path[0].Required)
+
+	require.Len(t, query, 2)
+	assert.Equal(t, "page", query[0].Name)
+	assert.Equal(t, "query", query[0].In)
+	assert.False(t, query[0].Required)
+	assert.Equal(t, 1.0, *query[0].Schema.Minimum)
+
+	assert.Equal(t, "sort", query[1].Name)
+	assert.Equal(t, "Sort order", query[1].Description)
+	assert.Empty(t, query[1].Schema.Description)
+	assert.Equal(t, []any{"id", "-id"}, query[1].Schema.Enum)
+}
+
+func TestMarshal(t *testing.T) {
+	// Setup
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "Test", Version: "1.0.0"},
+		Paths: map[string]*PathItem{
+			"/items": {Get: &Operation{OperationID: "getItems", Responses: map[string]*Response{"200": {Description: "OK"}}}},
+		},
+		Components: Components{Schemas: map[string]*Schema{
+			"Item": {Type: "object", Properties: Properties{
+				{Name: "zeta", Schema: &Schema{Type: "string"}},
+				{Name: "alpha", Schema: &Schema{Type: "integer"}},
+			}},
+		}},
+	}
+
+	// Execute
+	encoded, err := Marshal(doc)
+	require.NoError(t, err)
+	decoded, err := Unmarshal(encoded)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, doc, decoded)
+	assert.Regexp(t, `(?s)zeta:.*alpha:`, string(encoded), "properties keep the field order")
+}
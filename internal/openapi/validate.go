@@ -0,0 +1,447 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+// Errors maps a field to the reason its value was rejected, coded and worded
+// like the English translations of internal/validator. Fields of a body are
+// paths like items[3].amount, a body rejected as a whole is "body".
+type Errors map[string]Violation
+
+// Violation is the reason a field was rejected, with the rule it failed so
+// internal/validator can word the reason in other locales.
+type Violation struct {
+	validator.FieldError
+	Rule validator.Rule
+}
+
+// add keeps the first reason of a field, as the validator reports only the
+// first failing rule. It is coded by the tag of rule.
+func (e Errors) add(field string, rule validator.Rule, format string, args ...any) {
+	if _, ok := e[field]; !ok {
+		e[field] = Violation{
+			FieldError: validator.FieldError{Code: validator.CodeOf(rule.Tag), Message: fmt.Sprintf(format, args...)},
+			Rule:       rule,
+		}
+	}
+}
+
+// Messages returns the message of every field.
+func (e Errors) Messages() map[string]string {
+	messages := make(map[string]string, len(e))
+	for field, reason := range e {
+		messages[field] = reason.Message
+	}
+	return messages
+}
+
+// Operation returns the operation of method on path, a path of the document
+// like /transactions/{id}, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item.Operation(method)
+}
+
+// Validator checks requests and responses against the operations of a
+// document.
+type Validator struct {
+	doc *Document
+}
+
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc}
+}
+
+// RequestInput is what a request holds for the parameters and the body of
+// an operation.
+type RequestInput struct {
+	Path        map[string]string
+	Query       url.Values
+	Header      http.Header
+	ContentType string
+
+	// Body is nil when the body wasn't read, and isn't validated then.
+	Body []byte
+}
+
+// Request validates the parameters and the JSON body of a request. A body
+// that isn't JSON at all is left to the handler, which reports where.
+func (v *Validator) Request(op *Operation, in RequestInput) Errors {
+	errs := Errors{}
+
+	for _, param := range op.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			if value, ok := in.Path[param.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = in.Query[param.Name]
+		case "header":
+			values = in.Header.Values(param.Name)
+		}
+		values = slices.DeleteFunc(slices.Clone(values), func(value string) bool { return value == "" })
+
+		if len(values) == 0 {
+			if param.Required {
+				errs.add(param.Name, validator.Rule{Field: param.Name, Tag: "required"}, "%s is a required field", param.Name)
+			}
+			continue
+		}
+		v.parameter(v.resolve(param.Schema), values, param.Name, errs)
+	}
+
+	if op.RequestBody == nil || in.Body == nil {
+		return errs
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || !isJSON(in.ContentType) {
+		return errs
+	}
+
+	if len(bytes.TrimSpace(in.Body)) == 0 {
+		if op.RequestBody.Required {
+			errs.add("body", validator.Rule{Field: "body", Tag: "required"}, "body is a required field")
+		}
+		return errs
+	}
+
+	var body any
+	if err := decodeJSON(in.Body, &body); err != nil {
+		return errs
+	}
+	v.Value(media.Schema, body, "", errs)
+	return errs
+}
+
+// Response validates the status and the JSON body of a response. Content
+// other than JSON is only checked for being documented.
+func (v *Validator) Response(op *Operation, status int, contentType string, body []byte) Errors {
+	errs := Errors{}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		documented := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			documented = append(documented, code)
+		}
+		slices.Sort(documented)
+		errs.add("status", validator.Rule{Field: "status", Tag: "oneof", Param: strings.Join(documented, " ")}, "status must be one of [%s]", strings.Join(documented, " "))
+		return errs
+	}
+	response = v.resolveResponse(response)
+
+	if len(response.Content) == 0 || len(body) == 0 {
+		return errs
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := response.Content[mediaType]
+	if !ok {
+		documented := make([]string, 0, len(response.Content))
+		for name := range response.Content {
+			documented = append(documented, name)
+		}
+		slices.Sort(documented)
+		errs.add("content_type", validator.Rule{Field: "content_type", Tag: "oneof", Param: strings.Join(documented, " ")}, "content_type must be one of [%s]", strings.Join(documented, " "))
+		return errs
+	}
+	if !isJSON(mediaType) {
+		return errs
+	}
+
+	var decoded any
+	if err := decodeJSON(body, &decoded); err != nil {
+		errs.add("body", validator.Rule{Field: "body", Tag: "json"}, "body must be valid JSON")
+		return errs
+	}
+	v.Value(media.Schema, decoded, "", errs)
+	return errs
+}
+
+// Value validates a value decoded from JSON, numbers as json.Number, against
+// schema. field is the path of value, empty for a body.
+func (v *Validator) Value(schema *Schema, value any, field string, errs Errors) {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	key, label := field, fieldLabel(field)
+	if key == "" {
+		key, label = "body", "body"
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			errs.add(key, validator.Rule{Field: label, Tag: "nonnull"}, "%s must not be null", label)
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-object"}, "%s must be an object", label)
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				errs.add(join(field, name), validator.Rule{Field: name, Tag: "required"}, "%s is a required field", name)
+			}
+		}
+		for _, prop := range schema.Properties {
+			if propValue, ok := object[prop.Name]; ok {
+				v.Value(prop.Schema, propValue, join(field, prop.Name), errs)
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			for name, propValue := range object {
+				if schema.Properties.Get(name) == nil {
+					v.Value(schema.AdditionalProperties, propValue, join(field, name), errs)
+				}
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-array"}, "%s must be an array", label)
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			errs.add(key, validator.Rule{Field: label, Tag: "min", Param: strconv.Itoa(*schema.MinItems), Kind: reflect.Slice}, "%s must contain at least %s", label, plural(*schema.MinItems, "item"))
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			errs.add(key, validator.Rule{Field: label, Tag: "max", Param: strconv.Itoa(*schema.MaxItems), Kind: reflect.Slice}, "%s must contain at maximum %s", label, plural(*schema.MaxItems, "item"))
+		}
+		if schema.UniqueItems && !unique(items) {
+			errs.add(key, validator.Rule{Field: label, Tag: "unique"}, "%s must contain unique values", label)
+		}
+		for i, item := range items {
+			v.Value(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-" + schema.Type}, "%s must be %s", label, article(schema.Type))
+			return
+		}
+		n, err := number.Float64()
+		if err != nil || (schema.Type == "integer" && !isInteger(number)) {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-" + schema.Type}, "%s must be %s", label, article(schema.Type))
+			return
+		}
+		v.bounds(schema, n, key, label, errs)
+
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-string"}, "%s must be a string", label)
+			return
+		}
+		v.text(schema, s, key, label, errs)
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs.add(key, validator.Rule{Field: label, Tag: "type-boolean"}, "%s must be a boolean", label)
+			return
+		}
+	}
+
+	v.enum(schema, value, key, label, errs)
+}
+
+// parameter validates the values of a parameter, which are strings until
+// the schema says otherwise.
+func (v *Validator) parameter(schema *Schema, values []string, name string, errs Errors) {
+	if schema == nil {
+		return
+	}
+
+	if schema.Type == "array" {
+		items := make([]any, len(values))
+		for i, value := range values {
+			items[i] = parameterValue(v.resolve(schema.Items), value)
+		}
+		v.Value(schema, items, name, errs)
+		return
+	}
+	v.Value(schema, parameterValue(schema, values[0]), name, errs)
+}
+
+func parameterValue(schema *Schema, value string) any {
+	if schema == nil {
+		return value
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func (v *Validator) bounds(schema *Schema, n float64, key, label string, errs Errors) {
+	if schema.Minimum != nil && n < *schema.Minimum {
+		errs.add(key, validator.Rule{Field: label, Tag: "min", Param: formatNumber(*schema.Minimum), Kind: reflect.Float64}, "%s must be %s or greater", label, formatNumber(*schema.Minimum))
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		errs.add(key, validator.Rule{Field: label, Tag: "max", Param: formatNumber(*schema.Maximum), Kind: reflect.Float64}, "%s must be %s or less", label, formatNumber(*schema.Maximum))
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (v *Validator) text(schema *Schema, s, key, label string, errs Errors) {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		errs.add(key, validator.Rule{Field: label, Tag: "min", Param: strconv.Itoa(*schema.MinLength), Kind: reflect.String}, "%s must be at least %s in length", label, plural(*schema.MinLength, "character"))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		errs.add(key, validator.Rule{Field: label, Tag: "max", Param: strconv.Itoa(*schema.MaxLength), Kind: reflect.String}, "%s must be a maximum of %s in length", label, plural(*schema.MaxLength, "character"))
+	}
+
+	switch schema.Format {
+	case "uri":
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+			errs.add(key, validator.Rule{Field: label, Tag: "url"}, "%s must be a valid URL", label)
+		}
+	case "email":
+		if _, err := mail.ParseAddress(s); err != nil {
+			errs.add(key, validator.Rule{Field: label, Tag: "email"}, "%s must be a valid email address", label)
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			errs.add(key, validator.Rule{Field: label, Tag: "uuid"}, "%s must be a valid UUID", label)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			errs.add(key, validator.Rule{Field: label, Tag: "date-time"}, "%s must be a valid date-time", label)
+		}
+	}
+}
+
+func (v *Validator) enum(schema *Schema, value any, key, label string, errs Errors) {
+	if len(schema.Enum) == 0 {
+		return
+	}
+
+	allowed := make([]string, len(schema.Enum))
+	for i, e := range schema.Enum {
+		allowed[i] = fmt.Sprint(e)
+	}
+	if !slices.Contains(allowed, fmt.Sprint(value)) {
+		errs.add(key, validator.Rule{Field: label, Tag: "oneof", Param: strings.Join(allowed, " ")}, "%s must be one of [%s]", label, strings.Join(allowed, " "))
+	}
+}
+
+// resolve follows a reference to a schema of the components. An unknown
+// reference resolves to nil, which allows anything.
+func (v *Validator) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok {
+			return nil
+		}
+		schema = v.doc.Components.Schemas[name]
+	}
+	return schema
+}
+
+func (v *Validator) resolveResponse(response *Response) *Response {
+	if name, ok := strings.CutPrefix(response.Ref, "#/components/responses/"); ok {
+		if resolved, ok := v.doc.Components.Responses[name]; ok {
+			return resolved
+		}
+	}
+	return response
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// isJSON reports whether contentType is JSON, including structured syntax
+// suffixes like application/problem+json.
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isInteger(number json.Number) bool {
+	_, err := strconv.ParseInt(number.String(), 10, 64)
+	return err == nil
+}
+
+func unique(items []any) bool {
+	seen := map[string]bool{}
+	for _, item := range items {
+		encoded, _ := json.Marshal(item)
+		if seen[string(encoded)] {
+			return false
+		}
+		seen[string(encoded)] = true
+	}
+	return true
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// fieldLabel returns the name a message uses for field, its last segment.
+func fieldLabel(field string) string {
+	return field[strings.LastIndex(field, ".")+1:]
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "an integer"
+	}
+	return "a " + typ
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
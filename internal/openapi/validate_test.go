@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+func TestValidator(t *testing.T) {
+	// Setup
+	low, high := 1.0, 100.0
+	one, two := 1, 2
+
+	components := Components{
+		Schemas: map[string]*Schema{
+			"Item": {
+				Type:     "object",
+				Required: []string{"amount"},
+				Properties: Properties{
+					{Name: "amount", Schema: &Schema{Type: "integer", Minimum: &low, Maximum: &high}},
+					{Name: "status", Schema: &Schema{Type: "string", Enum: []any{"pending", "success"}}},
+					{Name: "note", Schema: &Schema{Type: "string", MaxLength: &two, Nullable: true}},
+				},
+			},
+		},
+		Responses: map[string]*Response{
+			"Error": {Description: "Error", Content: map[string]*MediaType{
+				"application/json": {Schema: &Schema{Type: "object", Required: []string{"error"}}},
+			}},
+		},
+	}
+
+	op := &Operation{
+		Parameters: []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Minimum: &low}},
+			{Name: "tags", In: "query", Schema: &Schema{Type: "array", UniqueItems: true, Items: &Schema{Type: "string"}}},
+			{Name: "Last-Event-ID", In: "header", Schema: &Schema{Type: "integer"}},
+		},
+		RequestBody: &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: &Schema{
+				Type:     "object",
+				Required: []string{"items"},
+				Properties: Properties{
+					{Name: "items", Schema: &Schema{Type: "array", MinItems: &one, Items: &Schema{Ref: "#/components/schemas/Item"}}},
+				},
+			}},
+		}},
+		Responses: map[string]*Response{
+			"200": {Description: "OK", Content: map[string]*MediaType{
+				"application/json": {Schema: &Schema{Ref: "#/components/schemas/Item"}},
+			}},
+			"404": {Ref: "#/components/responses/Error"},
+		},
+	}
+
+	v := NewValidator(&Document{Components: components})
+
+	t.Run("accepts a matching request", func(t *testing.T) {
+		// Execute
+		errs := v.Request(op, RequestInput{
+			Path:        map[string]string{"id": "7"},
+			Query:       url.Values{"tags": {"a", "b"}},
+			Header:      http.Header{"Last-Event-Id": {"3"}},
+			ContentType: "application/json; charset=utf-8",
+			Body:        []byte(`{"items":[{"amount":5,"status":"pending","note":null}]}`),
+		})
+
+		// Assert
+		assert.Empty(t, errs)
+	})
+
+	t.Run("reports parameters", func(t *testing.T) {
+		// Execute
+		errs := v.Request(op, RequestInput{
+			Path:   map[string]string{"id": "0"},
+			Query:  url.Values{"tags": {"a", "a"}},
+			Header: http.Header{"Last-Event-Id": {"x"}},
+		})
+
+		// Assert
+		assert.Equal(t, map[string]string{
+			"id":            "id must be 1 or greater",
+			"tags":          "tags must contain unique values",
+			"Last-Event-ID": "Last-Event-ID must be an integer",
+		}, errs.Messages())
+	})
+
+	t.Run("reports body fields by path", func(t *testing.T) {
+		// Execute
+		errs := v.Request(op, RequestInput{
+			Path:        map[string]string{"id": "1"},
+			ContentType: "application/json",
+			Body:        []byte(`{"items":[{"amount":1.5},{"amount":500,"status":"done","note":"long"},{"status":null}]}`),
+		})
+
+		// Assert
+		assert.Equal(t, map[string]string{
+			"items[0].amount": "amount must be an integer",
+			"items[1].amount": "amount must be 100 or less",
+			"items[1].status": "status must be one of [pending success]",
+			"items[1].note":   "note must be a maximum of 2 characters in length",
+			"items[2].amount": "amount is a required field",
+			"items[2].status": "status must not be null",
+		}, errs.Messages())
+		assert.Equal(t, validator.FieldInvalidType, errs["items[0].amount"].Code)
+		assert.Equal(t, validator.FieldAboveMaximum, errs["items[1].amount"].Code)
+		assert.Equal(t, validator.FieldNotAllowed, errs["items[1].status"].Code)
+		assert.Equal(t, validator.FieldRequired, errs["items[2].amount"].Code)
+		assert.Equal(t, validator.Rule{Field: "amount", Tag: "max", Param: "100", Kind: reflect.Float64}, errs["items[1].amount"].Rule)
+		assert.Equal(t, validator.Rule{Field: "note", Tag: "max", Param: "2", Kind: reflect.String}, errs["items[1].note"].Rule)
+	})
+
+	t.Run("reports a missing body and leaves malformed ones to the handler", func(t *testing.T) {
+		// Execute
+		missing := v.Request(op, RequestInput{Path: map[string]string{"id": "1"}, ContentType: "application/json", Body: []byte{}})
+		malformed := v.Request(op, RequestInput{Path: map[string]string{"id": "1"}, ContentType: "application/json", Body: []byte(`{"items":`)})
+		unread := v.Request(op, RequestInput{Path: map[string]string{"id": "1"}, ContentType: "application/json"})
+
+		// Assert
+		assert.Equal(t, map[string]string{"body": "body is a required field"}, missing.Messages())
+		assert.Empty(t, malformed)
+		assert.Empty(t, unread)
+	})
+
+	t.Run("validates responses", func(t *testing.T) {
+		// Execute
+		ok := v.Response(op, http.StatusOK, "application/json", []byte(`{"amount":3}`))
+		wrongBody := v.Response(op, http.StatusOK, "application/json", []byte(`{"amount":"3"}`))
+		wrongStatus := v.Response(op, http.StatusTeapot, "application/json", []byte(`{}`))
+		wrongType := v.Response(op, http.StatusOK, "text/plain", []byte(`3`))
+		referenced := v.Response(op, http.StatusNotFound, "application/json", []byte(`{}`))
+
+		// Assert
+		assert.Empty(t, ok)
+		assert.Equal(t, map[string]string{"amount": "amount must be an integer"}, wrongBody.Messages())
+		assert.Equal(t, map[string]string{"status": "status must be one of [200 404]"}, wrongStatus.Messages())
+		assert.Equal(t, map[string]string{"content_type": "content_type must be one of [application/json]"}, wrongType.Messages())
+		assert.Equal(t, map[string]string{"error": "error is a required field"}, referenced.Messages())
+	})
+}
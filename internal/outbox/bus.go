@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// Bus fans events out to in-process subscribers. Publishing never blocks: a
+// subscriber whose buffer is full misses the event, which is counted in
+// Dropped.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[chan data.Event]struct{}
+	dropped atomic.Int64
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan data.Event]struct{})}
+}
+
+// Subscribe returns a channel receiving every event published from now on,
+// and a function that unsubscribes and closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan data.Event, func()) {
+	ch := make(chan data.Event, buffer)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *Bus) Publish(_ context.Context, event data.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of events subscribers missed so far.
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
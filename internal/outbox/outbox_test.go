@@ -0,0 +1,188 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+func newTestRelay(store data.OutboxModeler, now time.Time) *Relay {
+	logger := tlog.Must(tlog.NewDevelopment())
+	logger.SetOutput(&bytes.Buffer{})
+
+	opts := DefaultOptions()
+	opts.BatchSize = 10
+
+	r := NewRelay(store, logger, opts)
+	r.now = func() time.Time { return now }
+	return r
+}
+
+func testMessage(t *testing.T, id int, attempts int) *data.OutboxMessage {
+	t.Helper()
+
+	event, err := data.NewEvent(data.EventTransactionCreated, data.TransactionEventData{
+		Transaction: &data.Transaction{ID: id, UserId: 1, Amount: 10000, Status: data.TransactionStatusPending},
+	})
+	require.NoError(t, err)
+
+	return &data.OutboxMessage{ID: id, Event: event, Attempts: attempts}
+}
+
+// recorder is a publisher remembering the IDs of the events it got.
+type recorder struct {
+	ids []string
+	err error
+}
+
+func (r *recorder) Publish(_ context.Context, event data.Event) error {
+	r.ids = append(r.ids, event.ID)
+	return r.err
+}
+
+func TestRelayDue(t *testing.T) {
+	now := time.Now()
+
+	t.Run("publishes in order and marks published", func(t *testing.T) {
+		first, second := testMessage(t, 1, 0), testMessage(t, 2, 0)
+
+		store := new(data.MockOutboxModel)
+		store.On("Claim", 10, time.Minute).Return([]*data.OutboxMessage{first, second}, nil)
+		store.On("MarkPublished", 1).Return(nil)
+		store.On("MarkPublished", 2).Return(nil)
+
+		webhook, bus := new(recorder), new(recorder)
+		relay := newTestRelay(store, now)
+		relay.Add("webhook", webhook)
+		relay.Add("bus", bus)
+
+		n, err := relay.RelayDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{first.Event.ID, second.Event.ID}, webhook.ids)
+		assert.Equal(t, []string{first.Event.ID, second.Event.ID}, bus.ids)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("schedules retry when a publisher fails", func(t *testing.T) {
+		message := testMessage(t, 1, 2)
+
+		store := new(data.MockOutboxModel)
+		store.On("Claim", 10, time.Minute).Return([]*data.OutboxMessage{message}, nil)
+		store.On("MarkFailed", 1, now.Add(4*time.Second), "webhook: connection refused").Return(nil)
+
+		relay := newTestRelay(store, now)
+		relay.Add("webhook", &recorder{err: errors.New("connection refused")})
+		relay.Add("bus", new(recorder))
+
+		_, err := relay.RelayDue(context.Background())
+
+		require.NoError(t, err)
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "MarkPublished", mock.Anything)
+	})
+
+	t.Run("returns claim error", func(t *testing.T) {
+		store := new(data.MockOutboxModel)
+		store.On("Claim", 10, time.Minute).Return(nil, errors.New("connection reset"))
+
+		_, err := newTestRelay(store, now).RelayDue(context.Background())
+
+		assert.EqualError(t, err, "connection reset")
+	})
+}
+
+func TestRunDrainsOnShutdown(t *testing.T) {
+	message := testMessage(t, 1, 0)
+
+	store := new(data.MockOutboxModel)
+	store.On("Claim", 10, time.Minute).Return([]*data.OutboxMessage{message}, nil).Once()
+	store.On("Claim", 10, time.Minute).Return(nil, nil)
+	store.On("MarkPublished", 1).Return(nil)
+
+	published := new(recorder)
+	relay := newTestRelay(store, time.Now())
+	relay.opts.PollInterval = time.Hour
+	relay.Add("bus", published)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	relay.Run(ctx)
+
+	assert.Equal(t, []string{message.Event.ID}, published.ids)
+	store.AssertExpectations(t)
+}
+
+func TestBackoff(t *testing.T) {
+	opts := Options{BackoffBase: time.Second, BackoffMax: time.Minute}
+
+	assert.Equal(t, time.Second, opts.Backoff(1))
+	assert.Equal(t, 2*time.Second, opts.Backoff(2))
+	assert.Equal(t, 32*time.Second, opts.Backoff(6))
+	assert.Equal(t, time.Minute, opts.Backoff(7))
+	assert.Equal(t, time.Minute, opts.Backoff(100))
+}
+
+func TestBus(t *testing.T) {
+	event := testMessage(t, 1, 0).Event
+
+	t.Run("fans out to subscribers", func(t *testing.T) {
+		bus := NewBus()
+		first, unsubscribeFirst := bus.Subscribe(1)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := bus.Subscribe(1)
+		defer unsubscribeSecond()
+
+		require.NoError(t, bus.Publish(context.Background(), event))
+
+		assert.Equal(t, event, <-first)
+		assert.Equal(t, event, <-second)
+	})
+
+	t.Run("drops events for full subscribers", func(t *testing.T) {
+		bus := NewBus()
+		events, unsubscribe := bus.Subscribe(1)
+		defer unsubscribe()
+
+		require.NoError(t, bus.Publish(context.Background(), event))
+		require.NoError(t, bus.Publish(context.Background(), event))
+
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(1), bus.Dropped())
+	})
+
+	t.Run("closes channel on unsubscribe", func(t *testing.T) {
+		bus := NewBus()
+		events, unsubscribe := bus.Subscribe(1)
+		unsubscribe()
+		unsubscribe()
+
+		require.NoError(t, bus.Publish(context.Background(), event))
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+}
+
+func TestWriterPublisher(t *testing.T) {
+	event := testMessage(t, 1, 0).Event
+	var buf bytes.Buffer
+
+	require.NoError(t, NewWriterPublisher(&buf).Publish(context.Background(), event))
+
+	var got data.Event
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, event.ID, got.ID)
+	assert.Equal(t, event.Type, got.Type)
+	assert.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+}
@@ -0,0 +1,42 @@
+// Package outbox relays the events stored in the outbox table to publishers.
+// Delivery is at-least-once: an event is published again when the relay
+// fails or stops before marking it, so publishers must tolerate duplicates,
+// using Event.ID to recognise them.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, event data.Event) error
+}
+
+// PublisherFunc adapts a function to Publisher.
+type PublisherFunc func(ctx context.Context, event data.Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event data.Event) error {
+	return f(ctx, event)
+}
+
+// WriterPublisher writes every event as a line of JSON, e.g. to stdout for a
+// log shipper to pick up.
+type WriterPublisher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{enc: json.NewEncoder(w)}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, event data.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enc.Encode(event)
+}
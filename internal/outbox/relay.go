@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+type Options struct {
+	// PollInterval is how often unpublished events are looked up.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of events claimed at once.
+	BatchSize int
+	// Lease is how long claimed events are hidden from other relays.
+	Lease time.Duration
+	// BackoffBase is the wait after the first failed publish, it doubles with
+	// every further failure up to BackoffMax. Events are never given up on.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention is how long published events are kept, zero keeps them
+	// forever.
+	Retention time.Duration
+	// DrainTimeout bounds publishing the remaining events on shutdown.
+	DrainTimeout time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		PollInterval: 500 * time.Millisecond,
+		BatchSize:    100,
+		Lease:        time.Minute,
+		BackoffBase:  time.Second,
+		BackoffMax:   time.Minute,
+		Retention:    7 * 24 * time.Hour,
+		DrainTimeout: 10 * time.Second,
+	}
+}
+
+// Backoff returns how long to wait before retrying after failed attempt n,
+// counting from 1.
+func (o Options) Backoff(n int) time.Duration {
+	wait := o.BackoffBase
+	for i := 1; i < n && wait < o.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, o.BackoffMax)
+}
+
+// namedPublisher keeps the name a publisher was added with for logging.
+type namedPublisher struct {
+	name string
+	Publisher
+}
+
+type Relay struct {
+	store      data.OutboxModeler
+	publishers []namedPublisher
+	logger     *tlog.Logger
+	opts       Options
+	now        func() time.Time
+}
+
+func NewRelay(store data.OutboxModeler, logger *tlog.Logger, opts Options) *Relay {
+	return &Relay{
+		store:  store,
+		logger: logger,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Add registers a publisher. Every event is published to every publisher,
+// and counts as published only once all of them succeeded.
+func (r *Relay) Add(name string, publisher Publisher) {
+	r.publishers = append(r.publishers, namedPublisher{name: name, Publisher: publisher})
+}
+
+// Run relays events every PollInterval until ctx is done. It then relays the
+// events that are still due, for at most DrainTimeout, before returning.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain()
+			return
+		case <-ticker.C:
+			r.relayAll(ctx)
+
+			if r.opts.Retention > 0 && r.now().Sub(lastPrune) >= time.Hour {
+				lastPrune = r.now()
+				r.prune()
+			}
+		}
+	}
+}
+
+func (r *Relay) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.DrainTimeout)
+	defer cancel()
+
+	r.relayAll(ctx)
+}
+
+// relayAll keeps relaying while full batches come back, a backlog should not
+// wait a PollInterval per batch.
+func (r *Relay) relayAll(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.RelayDue(ctx)
+		if err != nil {
+			r.logger.Errorj(tlog.JSON{"message": "failed claiming outbox events", "error": err})
+			return
+		}
+		if n < r.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// RelayDue claims one batch of due events and publishes them in order. It
+// returns the number of events claimed.
+func (r *Relay) RelayDue(ctx context.Context) (int, error) {
+	messages, err := r.store.Claim(r.opts.BatchSize, r.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			// The lease runs out and the rest is claimed again later.
+			break
+		}
+		r.relay(ctx, message)
+	}
+
+	return len(messages), nil
+}
+
+func (r *Relay) relay(ctx context.Context, message *data.OutboxMessage) {
+	var errs []error
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, message.Event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", publisher.name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		next := r.now().Add(r.opts.Backoff(message.Attempts + 1))
+		r.logger.Warnj(tlog.JSON{
+			"message":  "failed publishing outbox event",
+			"event_id": message.Event.ID,
+			"attempts": message.Attempts + 1,
+			"retry_at": next,
+			"error":    err,
+		})
+
+		if err := r.store.MarkFailed(message.ID, next, err.Error()); err != nil {
+			r.logger.Errorj(tlog.JSON{"message": "failed marking outbox event", "event_id": message.Event.ID, "error": err})
+		}
+		return
+	}
+
+	if err := r.store.MarkPublished(message.ID); err != nil {
+		// The event is published again once the lease runs out.
+		r.logger.Errorj(tlog.JSON{"message": "failed marking outbox event", "event_id": message.Event.ID, "error": err})
+	}
+}
+
+func (r *Relay) prune() {
+	deleted, err := r.store.DeletePublished(r.now().Add(-r.opts.Retention))
+	if err != nil {
+		r.logger.Errorj(tlog.JSON{"message": "failed pruning outbox", "error": err})
+		return
+	}
+	if deleted > 0 {
+		r.logger.Infoj(tlog.JSON{"message": "pruned outbox", "deleted": deleted})
+	}
+}
@@ -0,0 +1,113 @@
+// Package pgtest gives tests a migrated Postgres schema of their own, in the
+// database of TCSA_TEST_DB_DSN. Tests using it are skipped when the variable
+// isn't set, so they only run where a Postgres server was started for them:
+//
+//	TCSA_TEST_DB_DSN="postgres://postgres@localhost:5432/postgres?sslmode=disable" go test ./...
+//
+// Every schema is dropped once its test ends, the database is left as it was.
+// NOTIFY channels are shared by the whole database, a test listening for
+// outbox events also sees those of the tests running next to it.
+package pgtest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/stdlib"
+	"github.com/ucok-man/tcsa/internal/migration"
+	"github.com/ucok-man/tcsa/migrations"
+)
+
+// EnvDSN names the variable holding the connection string of the test
+// database.
+const EnvDSN = "TCSA_TEST_DB_DSN"
+
+// setupTimeout bounds creating, migrating and dropping a schema.
+const setupTimeout = 30 * time.Second
+
+// New creates a schema, applies every migration to it and returns a pool
+// whose connections only see that schema. It skips t when EnvDSN is unset.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db := NewEmpty(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	migrator, err := migration.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("pgtest: load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("pgtest: apply migrations: %v", err)
+	}
+
+	return db
+}
+
+// NewEmpty is New without the migrations, for tests of a fresh database.
+func NewEmpty(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		t.Skipf("%s not set", EnvDSN)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("pgtest: open %s: %v", EnvDSN, err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := schemaName(t)
+	if _, err := admin.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("pgtest: create schema: %v", err)
+	}
+	// Registered before the pool is closed, so it runs after.
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+		defer cancel()
+
+		if _, err := admin.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+			t.Errorf("pgtest: drop schema %s: %v", schema, err)
+		}
+	})
+
+	config := &stdlib.DriverConfig{
+		ConnConfig: pgx.ConnConfig{
+			RuntimeParams: map[string]string{"search_path": schema},
+		},
+	}
+	stdlib.RegisterDriverConfig(config)
+	t.Cleanup(func() { stdlib.UnregisterDriverConfig(config) })
+
+	db, err := sql.Open("pgx", config.ConnectionString(dsn))
+	if err != nil {
+		t.Fatalf("pgtest: open schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// schemaName returns a name no other test uses, even one run by another
+// process against the same database.
+func schemaName(t testing.TB) string {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatalf("pgtest: %v", err)
+	}
+	return fmt.Sprintf("pgtest_%s", hex.EncodeToString(suffix))
+}
@@ -0,0 +1,149 @@
+// Package seed generates reproducible transaction fixtures. The same Options,
+// including Seed and Now, always produce the same transactions, which makes
+// the output usable both for local databases and as test data.
+package seed
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/validator"
+)
+
+const (
+	AmountUniform   = "uniform"
+	AmountLogNormal = "lognormal"
+)
+
+var ErrNoStatusWeight = errors.New("at least one status weight must be greater than zero")
+
+// PinnedNow is the reference time used when a seed is chosen explicitly but
+// Now is not, so that the seed alone reproduces the same rows on every run.
+var PinnedNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type StatusWeights struct {
+	Pending float64 `validate:"min=0"`
+	Success float64 `validate:"min=0"`
+	Failed  float64 `validate:"min=0"`
+}
+
+type Options struct {
+	// Seed of the random source, equal seeds generate equal transactions.
+	Seed uint64
+	// Count is the number of transactions to generate.
+	Count int `validate:"min=1,max=1000000"`
+	// UserMin and UserMax bound the generated user ids, inclusive.
+	UserMin int `validate:"min=1"`
+	UserMax int `validate:"gtefield=UserMin"`
+	// StatusWeights are relative, they don't need to add up to 1.
+	StatusWeights StatusWeights
+	// AmountMin and AmountMax bound the generated amounts, inclusive.
+	AmountMin int `validate:"min=1"`
+	AmountMax int `validate:"gtefield=AmountMin"`
+	// AmountDistribution is either AmountUniform or AmountLogNormal. The
+	// log-normal distribution clusters amounts towards the lower end, like
+	// real payments do.
+	AmountDistribution string `validate:"oneof=uniform lognormal"`
+	// DateSpread is how far back from Now created_at may go.
+	DateSpread time.Duration `validate:"min=0"`
+	// Now is the reference time for created_at and updated_at.
+	Now time.Time `validate:"required"`
+}
+
+// DefaultOptions mirrors the data the old SQL seed migration produced: 60
+// transactions for users 1-10 over the last 30 days, mostly successful.
+func DefaultOptions() Options {
+	return Options{
+		Seed:    1,
+		Count:   60,
+		UserMin: 1,
+		UserMax: 10,
+		StatusWeights: StatusWeights{
+			Pending: 0.2,
+			Success: 0.7,
+			Failed:  0.1,
+		},
+		AmountMin:          10_000,
+		AmountMax:          5_000_000,
+		AmountDistribution: AmountUniform,
+		DateSpread:         30 * 24 * time.Hour,
+		Now:                time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func (o Options) Validate() error {
+	if err := validator.New().Struct(o); err != nil {
+		return err
+	}
+
+	w := o.StatusWeights
+	if w.Pending+w.Success+w.Failed <= 0 {
+		return ErrNoStatusWeight
+	}
+	return nil
+}
+
+// Generate returns opts.Count transactions. It does not touch the database,
+// the result can be stored with data.SeedModeler.
+func Generate(opts Options) ([]*data.Transaction, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	transactions := make([]*data.Transaction, opts.Count)
+
+	for i := range transactions {
+		createdAt := opts.Now
+		if opts.DateSpread > 0 {
+			createdAt = opts.Now.Add(-time.Duration(rng.Int64N(int64(opts.DateSpread))))
+		}
+		createdAt = createdAt.Truncate(time.Microsecond) // postgres precision
+
+		transactions[i] = &data.Transaction{
+			UserId:    opts.UserMin + rng.IntN(opts.UserMax-opts.UserMin+1),
+			Amount:    amount(rng, opts),
+			Status:    status(rng, opts.StatusWeights),
+			Version:   1,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+	}
+
+	return transactions, nil
+}
+
+func status(rng *rand.Rand, w StatusWeights) data.TransactionStatus {
+	n := rng.Float64() * (w.Pending + w.Success + w.Failed)
+
+	switch {
+	case n < w.Success:
+		return data.TransactionStatusSucces
+	case n < w.Success+w.Pending:
+		return data.TransactionStatusPending
+	default:
+		return data.TransactionStatusFailed
+	}
+}
+
+func amount(rng *rand.Rand, opts Options) int {
+	if opts.AmountMin == opts.AmountMax {
+		return opts.AmountMin
+	}
+
+	switch opts.AmountDistribution {
+	case AmountLogNormal:
+		// Centre the distribution on the geometric mean of the bounds, with
+		// three standard deviations reaching each bound.
+		lo, hi := math.Log(float64(opts.AmountMin)), math.Log(float64(opts.AmountMax))
+		mu, sigma := (lo+hi)/2, (hi-lo)/6
+
+		v := int(math.Round(math.Exp(mu + sigma*rng.NormFloat64())))
+		return min(max(v, opts.AmountMin), opts.AmountMax)
+	default:
+		return opts.AmountMin + rng.IntN(opts.AmountMax-opts.AmountMin+1)
+	}
+}
@@ -0,0 +1,139 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.Now = time.Date(2025, 12, 14, 8, 0, 0, 0, time.UTC)
+	return opts
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("same options generate same transactions", func(t *testing.T) {
+		first, err := Generate(testOptions())
+		require.NoError(t, err)
+
+		second, err := Generate(testOptions())
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("different seeds generate different transactions", func(t *testing.T) {
+		opts := testOptions()
+		first, err := Generate(opts)
+		require.NoError(t, err)
+
+		opts.Seed = 2
+		second, err := Generate(opts)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("respects count and bounds", func(t *testing.T) {
+		opts := testOptions()
+		opts.Count = 500
+		opts.UserMin = 5
+		opts.UserMax = 7
+		opts.AmountMin = 100
+		opts.AmountMax = 200
+		opts.DateSpread = 48 * time.Hour
+
+		transactions, err := Generate(opts)
+		require.NoError(t, err)
+		require.Len(t, transactions, 500)
+
+		for _, tx := range transactions {
+			assert.GreaterOrEqual(t, tx.UserId, 5)
+			assert.LessOrEqual(t, tx.UserId, 7)
+			assert.GreaterOrEqual(t, tx.Amount, 100)
+			assert.LessOrEqual(t, tx.Amount, 200)
+			assert.False(t, tx.CreatedAt.After(opts.Now))
+			assert.True(t, tx.CreatedAt.After(opts.Now.Add(-opts.DateSpread)))
+			assert.Equal(t, tx.CreatedAt, tx.UpdatedAt)
+			assert.Equal(t, 1, tx.Version)
+		}
+	})
+
+	t.Run("log-normal amounts stay within bounds", func(t *testing.T) {
+		opts := testOptions()
+		opts.Count = 1000
+		opts.AmountDistribution = AmountLogNormal
+
+		transactions, err := Generate(opts)
+		require.NoError(t, err)
+
+		for _, tx := range transactions {
+			assert.GreaterOrEqual(t, tx.Amount, opts.AmountMin)
+			assert.LessOrEqual(t, tx.Amount, opts.AmountMax)
+		}
+	})
+
+	t.Run("follows status weights", func(t *testing.T) {
+		opts := testOptions()
+		opts.Count = 10_000
+		opts.StatusWeights = StatusWeights{Pending: 1, Success: 3, Failed: 0}
+
+		transactions, err := Generate(opts)
+		require.NoError(t, err)
+
+		counts := map[data.TransactionStatus]int{}
+		for _, tx := range transactions {
+			counts[tx.Status]++
+		}
+
+		assert.Zero(t, counts[data.TransactionStatusFailed])
+		assert.InDelta(t, 7500, counts[data.TransactionStatusSucces], 300)
+		assert.InDelta(t, 2500, counts[data.TransactionStatusPending], 300)
+	})
+
+	t.Run("zero date spread uses now", func(t *testing.T) {
+		opts := testOptions()
+		opts.DateSpread = 0
+
+		transactions, err := Generate(opts)
+		require.NoError(t, err)
+
+		for _, tx := range transactions {
+			assert.Equal(t, opts.Now, tx.CreatedAt)
+		}
+	})
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{name: "zero count", modify: func(o *Options) { o.Count = 0 }},
+		{name: "user max below user min", modify: func(o *Options) { o.UserMin, o.UserMax = 5, 4 }},
+		{name: "amount max below amount min", modify: func(o *Options) { o.AmountMin, o.AmountMax = 10, 9 }},
+		{name: "unknown distribution", modify: func(o *Options) { o.AmountDistribution = "normal" }},
+		{name: "negative weight", modify: func(o *Options) { o.StatusWeights.Failed = -1 }},
+		{name: "zero weights", modify: func(o *Options) { o.StatusWeights = StatusWeights{} }},
+		{name: "zero now", modify: func(o *Options) { o.Now = time.Time{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			tt.modify(&opts)
+
+			_, err := Generate(opts)
+
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("default options are valid", func(t *testing.T) {
+		assert.NoError(t, DefaultOptions().Validate())
+	})
+}
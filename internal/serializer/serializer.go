@@ -11,6 +11,27 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// DefaultMaxBytes is the largest request body Deserialize accepts, unless a
+// route raised it with SetMaxBytes.
+const DefaultMaxBytes int64 = 1_048_576
+
+const maxBytesKey = "serializer.max_bytes"
+
+// SetMaxBytes changes the largest request body Deserialize accepts for the
+// request of c.
+func SetMaxBytes(c echo.Context, n int64) {
+	c.Set(maxBytesKey, n)
+}
+
+// MaxBytes returns the largest request body Deserialize accepts for the
+// request of c.
+func MaxBytes(c echo.Context) int64 {
+	if n, ok := c.Get(maxBytesKey).(int64); ok {
+		return n
+	}
+	return DefaultMaxBytes
+}
+
 type JSONSerializer struct{}
 
 func New() JSONSerializer {
@@ -26,8 +47,7 @@ func (js JSONSerializer) Serialize(c echo.Context, i any, indent string) error {
 }
 
 func (d JSONSerializer) Deserialize(c echo.Context, i any) error {
-	maxBytes := 1_048_576
-	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, int64(maxBytes))
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, MaxBytes(c))
 
 	dec := json.NewDecoder(c.Request().Body)
 	dec.DisallowUnknownFields()
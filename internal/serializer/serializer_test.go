@@ -107,6 +107,22 @@ func TestJSONDeserializer_ErrorHandling(t *testing.T) {
 		assert.Contains(t, err.Error(), "must not be larger than")
 	})
 
+	t.Run("body limit can be raised per request", func(t *testing.T) {
+		e := echo.New()
+		largeValue := strings.Repeat("a", 1_048_577)
+		body := strings.NewReader(`{"data":"` + largeValue + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		SetMaxBytes(c, 2*DefaultMaxBytes)
+
+		var result map[string]string
+		err := js.Deserialize(c, &result)
+
+		require.NoError(t, err)
+		assert.Len(t, result["data"], 1_048_577)
+	})
+
 	t.Run("valid JSON deserializes successfully", func(t *testing.T) {
 		e := echo.New()
 		body := strings.NewReader(`{"name": "test", "age": 30}`)
@@ -0,0 +1,122 @@
+// Package stream fans the outbox messages of every API instance out to live
+// subscribers, fed by Postgres LISTEN/NOTIFY.
+//
+// Subscribers never block the stream. One that falls behind, or that may have
+// missed messages because the listener reconnected, is evicted: its channel
+// is closed and it is expected to catch up from the outbox table, starting
+// after the last message it received.
+package stream
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+var ErrClosed = errors.New("stream closed")
+
+type Subscription struct {
+	ch      chan *data.OutboxMessage
+	broker  *Broker
+	once    sync.Once
+	evicted bool
+}
+
+// C receives the messages. It is closed on eviction, Close and Broker.Close.
+func (s *Subscription) C() <-chan *data.OutboxMessage {
+	return s.ch
+}
+
+// Evicted reports whether the subscription was closed because it fell behind
+// or messages may have been lost. Only meaningful once C is closed.
+func (s *Subscription) Evicted() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.evicted
+}
+
+// Close unsubscribes, it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription receiving every message published from now
+// on, buffering up to buffer of them.
+func (b *Broker) Subscribe(buffer int) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &Subscription{ch: make(chan *data.OutboxMessage, buffer), broker: b}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish hands message to every subscriber, evicting the ones whose buffer
+// is full.
+func (b *Broker) Publish(message *data.OutboxMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- message:
+		default:
+			sub.evicted = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Reset evicts every subscriber, e.g. after the listener lost messages.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		sub.evicted = true
+		b.remove(sub)
+	}
+}
+
+// Close ends every subscription and refuses new ones.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// Len returns the number of subscribers.
+func (b *Broker) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(sub *Subscription) {
+	sub.once.Do(func() {
+		delete(b.subs, sub)
+		close(sub.ch)
+	})
+}
@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+// Listener publishes to a Broker the outbox messages announced on
+// data.OutboxNotifyChannel, by any API instance.
+type Listener struct {
+	dsn    string
+	store  data.OutboxModeler
+	broker *Broker
+	logger *tlog.Logger
+
+	// ConnectTimeout bounds dialing Postgres.
+	ConnectTimeout time.Duration
+	// RetryMax is the longest wait between reconnects, starting at a second.
+	RetryMax time.Duration
+}
+
+func NewListener(dsn string, store data.OutboxModeler, broker *Broker, logger *tlog.Logger) *Listener {
+	return &Listener{
+		dsn:            dsn,
+		store:          store,
+		broker:         broker,
+		logger:         logger,
+		ConnectTimeout: 5 * time.Second,
+		RetryMax:       30 * time.Second,
+	}
+}
+
+// Run listens until ctx is done, reconnecting whenever the connection fails.
+// Notifications sent while disconnected are lost, so every subscriber is
+// evicted on a failure to make it catch up from the outbox.
+func (l *Listener) Run(ctx context.Context) {
+	wait := time.Second
+
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.broker.Reset()
+		l.logger.Errorj(tlog.JSON{"message": "outbox listener failed", "retry_in": wait.String(), "error": err})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, l.RetryMax)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	config, err := pgx.ParseConnectionString(l.dsn)
+	if err != nil {
+		return err
+	}
+	config.Dial = (&net.Dialer{Timeout: l.ConnectTimeout, KeepAlive: time.Minute}).Dial
+
+	conn, err := pgx.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Listen(data.OutboxNotifyChannel); err != nil {
+		return err
+	}
+
+	l.logger.Infoj(tlog.JSON{"message": "listening for outbox messages", "channel": data.OutboxNotifyChannel})
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.handle(notification.Payload)
+	}
+}
+
+// handle loads the message whose ID is payload and publishes it.
+func (l *Listener) handle(payload string) {
+	id, err := strconv.Atoi(payload)
+	if err != nil {
+		l.logger.Warnj(tlog.JSON{"message": "ignoring malformed outbox notification", "payload": payload})
+		return
+	}
+
+	message, err := l.store.GetById(id)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			// Pruned already, nobody can be waiting for it.
+			return
+		}
+
+		// Subscribers would silently miss the message.
+		l.broker.Reset()
+		l.logger.Errorj(tlog.JSON{"message": "failed loading outbox message", "id": id, "error": err})
+		return
+	}
+
+	l.broker.Publish(message)
+}
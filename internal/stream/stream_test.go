@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("fans out to subscribers", func(t *testing.T) {
+		broker := NewBroker()
+		first, err := broker.Subscribe(1)
+		require.NoError(t, err)
+		second, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		message := &data.OutboxMessage{ID: 1}
+		broker.Publish(message)
+
+		assert.Same(t, message, <-first.C())
+		assert.Same(t, message, <-second.C())
+	})
+
+	t.Run("evicts lagging subscriber", func(t *testing.T) {
+		broker := NewBroker()
+		lagging, err := broker.Subscribe(1)
+		require.NoError(t, err)
+		keeping, err := broker.Subscribe(2)
+		require.NoError(t, err)
+
+		broker.Publish(&data.OutboxMessage{ID: 1})
+		broker.Publish(&data.OutboxMessage{ID: 2})
+
+		assert.Equal(t, 1, (<-lagging.C()).ID)
+		_, ok := <-lagging.C()
+		assert.False(t, ok)
+		assert.True(t, lagging.Evicted())
+
+		assert.Len(t, keeping.C(), 2)
+		assert.False(t, keeping.Evicted())
+		assert.Equal(t, 1, broker.Len())
+	})
+
+	t.Run("reset evicts everyone", func(t *testing.T) {
+		broker := NewBroker()
+		sub, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		broker.Reset()
+
+		_, ok := <-sub.C()
+		assert.False(t, ok)
+		assert.True(t, sub.Evicted())
+		assert.Zero(t, broker.Len())
+	})
+
+	t.Run("close ends subscriptions and refuses new ones", func(t *testing.T) {
+		broker := NewBroker()
+		sub, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		broker.Close()
+		sub.Close()
+
+		_, ok := <-sub.C()
+		assert.False(t, ok)
+		assert.False(t, sub.Evicted())
+
+		_, err = broker.Subscribe(1)
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+}
+
+func TestListenerHandle(t *testing.T) {
+	logger := tlog.Must(tlog.NewDevelopment())
+	logger.SetOutput(&bytes.Buffer{})
+
+	t.Run("publishes the notified message", func(t *testing.T) {
+		message := &data.OutboxMessage{ID: 42}
+		store := new(data.MockOutboxModel)
+		store.On("GetById", 42).Return(message, nil)
+
+		broker := NewBroker()
+		sub, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		NewListener("", store, broker, logger).handle("42")
+
+		assert.Same(t, message, <-sub.C())
+	})
+
+	t.Run("ignores malformed payload", func(t *testing.T) {
+		store := new(data.MockOutboxModel)
+		broker := NewBroker()
+		sub, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		NewListener("", store, broker, logger).handle("nope")
+
+		assert.Empty(t, sub.C())
+		assert.False(t, sub.Evicted())
+		store.AssertNotCalled(t, "GetById", 0)
+	})
+
+	t.Run("evicts subscribers when lookup fails", func(t *testing.T) {
+		store := new(data.MockOutboxModel)
+		store.On("GetById", 42).Return(nil, errors.New("connection reset"))
+
+		broker := NewBroker()
+		sub, err := broker.Subscribe(1)
+		require.NoError(t, err)
+
+		NewListener("", store, broker, logger).handle("42")
+
+		_, ok := <-sub.C()
+		assert.False(t, ok)
+		assert.True(t, sub.Evicted())
+	})
+}
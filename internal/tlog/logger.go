@@ -1,8 +1,10 @@
 package tlog
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/labstack/gommon/log"
@@ -48,6 +50,22 @@ func NewDevelopment() (*Logger, error) {
 	return NewLogger(logger), nil
 }
 
+// ParseLevel converts a level name (debug, info, warn, error) to a log.Lvl.
+func ParseLevel(level string) (log.Lvl, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return log.DEBUG, nil
+	case "info":
+		return log.INFO, nil
+	case "warn":
+		return log.WARN, nil
+	case "error":
+		return log.ERROR, nil
+	default:
+		return log.INFO, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
 func Must(logger *Logger, err error) *Logger {
 	if err != nil {
 		panic(err)
@@ -132,3 +132,30 @@ func TestLogger(t *testing.T) {
 		assert.Contains(t, out.String(), "warn message")
 	})
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected log.Lvl
+	}{
+		{input: "debug", expected: log.DEBUG},
+		{input: "info", expected: log.INFO},
+		{input: "WARN", expected: log.WARN},
+		{input: "error", expected: log.ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lvl, err := ParseLevel(tt.input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, lvl)
+		})
+	}
+
+	t.Run("unknown level returns error", func(t *testing.T) {
+		_, err := ParseLevel("verbose")
+
+		assert.Error(t, err)
+	})
+}
@@ -3,34 +3,31 @@ package validator
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
-
-	"github.com/ucok-man/tcsa/internal/utility"
 )
 
 type ValidationErrorMap map[string]string
 
-// Error implements the error interface and returns a formatted string of all validation errors
+// Error implements the error interface and returns a formatted string of all
+// validation errors, sorted by field so the output is stable.
 func (e ValidationErrorMap) Error() string {
 	if len(e) == 0 {
 		return "map[]"
 	}
 
-	if len(e) == 1 {
-		for field, msg := range e {
-			return fmt.Sprintf("%s: %s", field, msg)
-		}
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
 	}
+	sort.Strings(fields)
 
 	var builder strings.Builder
-	count := 0
-
-	for field, msg := range e {
-		if count > 0 {
+	for i, field := range fields {
+		if i > 0 {
 			builder.WriteString("; ")
 		}
-		builder.WriteString(fmt.Sprintf("%s: %s", field, msg))
-		count++
+		builder.WriteString(fmt.Sprintf("%s: %s", field, e[field]))
 	}
 
 	return builder.String()
@@ -42,11 +39,118 @@ func (e ValidationErrorMap) MarshalJSON() ([]byte, error) {
 
 	formatted := map[string]string{}
 	for key, val := range m {
-		keys := strings.Split(key, ".")
-		keys = utility.SlicesMap(keys, strings.ToLower)
-		key = strings.Join(keys[1:], ".")
-
-		formatted[key] = val
+		formatted[fieldName(key)] = val
 	}
 	return json.Marshal(formatted)
 }
+
+// fieldName is the name a field is reported by, its namespace without the
+// struct name.
+func fieldName(key string) string {
+	_, name, _ := strings.Cut(key, ".")
+	return name
+}
+
+// FieldCode is the code of the rule a field failed. Codes are stable and the
+// same in every language, unlike messages.
+type FieldCode string
+
+const (
+	FieldRequired      FieldCode = "REQUIRED"
+	FieldBelowMinimum  FieldCode = "BELOW_MINIMUM"
+	FieldAboveMaximum  FieldCode = "ABOVE_MAXIMUM"
+	FieldNotAllowed    FieldCode = "NOT_ALLOWED"
+	FieldNotUnique     FieldCode = "NOT_UNIQUE"
+	FieldInvalidType   FieldCode = "INVALID_TYPE"
+	FieldInvalidFormat FieldCode = "INVALID_FORMAT"
+	FieldInvalid       FieldCode = "INVALID"
+)
+
+// FieldCodes lists every FieldCode.
+var FieldCodes = []FieldCode{
+	FieldRequired, FieldBelowMinimum, FieldAboveMaximum, FieldNotAllowed,
+	FieldNotUnique, FieldInvalidType, FieldInvalidFormat, FieldInvalid,
+}
+
+// tagCodes maps validate tags to the code of their failure, other tags are
+// FieldInvalid.
+var tagCodes = map[string]FieldCode{
+	"required":         FieldRequired,
+	"required_if":      FieldRequired,
+	"required_unless":  FieldRequired,
+	"required_with":    FieldRequired,
+	"required_without": FieldRequired,
+	"min":              FieldBelowMinimum,
+	"gt":               FieldBelowMinimum,
+	"gte":              FieldBelowMinimum,
+	"gtfield":          FieldBelowMinimum,
+	"gtefield":         FieldBelowMinimum,
+	"max":              FieldAboveMaximum,
+	"lt":               FieldAboveMaximum,
+	"lte":              FieldAboveMaximum,
+	"ltfield":          FieldAboveMaximum,
+	"ltefield":         FieldAboveMaximum,
+	"oneof":            FieldNotAllowed,
+	"excluded_with":    FieldNotAllowed,
+	"unique":           FieldNotUnique,
+	"email":            FieldInvalidFormat,
+	"url":              FieldInvalidFormat,
+	"http_url":         FieldInvalidFormat,
+	"uuid":             FieldInvalidFormat,
+	"datetime":         FieldInvalidFormat,
+	"date-time":        FieldInvalidFormat,
+	"type-integer":     FieldInvalidType,
+	"type-number":      FieldInvalidType,
+	"type-string":      FieldInvalidType,
+	"type-boolean":     FieldInvalidType,
+	"type-array":       FieldInvalidType,
+	"type-object":      FieldInvalidType,
+	"nonnull":          FieldInvalidType,
+	"json":             FieldInvalidType,
+}
+
+// CodeOf returns the code of a failed validate tag.
+func CodeOf(tag string) FieldCode {
+	if code, ok := tagCodes[tag]; ok {
+		return code
+	}
+	return FieldInvalid
+}
+
+// FieldError is why a field failed validation.
+type FieldError struct {
+	Code    FieldCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// ValidationErrors are the messages of ValidationErrorMap with the code of
+// every failed rule, keyed alike, and the rule itself so Localize can word
+// the messages in another locale. It marshals as its messages, and
+// errors.As gives its ValidationErrorMap.
+type ValidationErrors struct {
+	ValidationErrorMap
+	Codes map[string]FieldCode
+	Rules map[string]Translatable
+}
+
+func (e ValidationErrors) As(target any) bool {
+	errmap, ok := target.(*ValidationErrorMap)
+	if ok {
+		*errmap = e.ValidationErrorMap
+	}
+	return ok
+}
+
+// Fields returns the code and the message of every field, by the name
+// MarshalJSON reports it by. Fields without a code are FieldInvalid.
+func (e ValidationErrors) Fields() map[string]FieldError {
+	fields := make(map[string]FieldError, len(e.ValidationErrorMap))
+	for key, message := range e.ValidationErrorMap {
+		code, ok := e.Codes[key]
+		if !ok {
+			code = FieldInvalid
+		}
+		fields[fieldName(key)] = FieldError{Code: code, Message: message}
+	}
+	return fields
+}
@@ -0,0 +1,148 @@
+package validator
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+)
+
+// customTags are the tags of translations that validate tags rather than
+// name a Rule. {1} of their messages is the value.
+var customTags = []string{"port", "http_url"}
+
+// translations are the messages, by locale, that the default translations
+// lack: those of the custom tags and of the rules only internal/openapi
+// checks. {0} is the field.
+var translations = map[string]map[string]string{
+	"en": {
+		"port":         "{0} has invalid value of {1}",
+		"http_url":     "{0} must be a valid HTTP URL",
+		"type-integer": "{0} must be an integer",
+		"type-number":  "{0} must be a number",
+		"type-string":  "{0} must be a string",
+		"type-boolean": "{0} must be a boolean",
+		"type-array":   "{0} must be an array",
+		"type-object":  "{0} must be an object",
+		"nonnull":      "{0} must not be null",
+		"date-time":    "{0} must be a valid date-time",
+	},
+	"id": {
+		"port":         "{0} memiliki nilai {1} yang tidak valid",
+		"type-integer": "{0} harus berupa bilangan bulat",
+		"type-number":  "{0} harus berupa angka",
+		"type-string":  "{0} harus berupa string",
+		"type-boolean": "{0} harus berupa boolean",
+		"type-array":   "{0} harus berupa array",
+		"type-object":  "{0} harus berupa objek",
+		"nonnull":      "{0} tidak boleh null",
+		"date-time":    "{0} harus berupa tanggal dan waktu yang valid",
+	},
+}
+
+// Translatable is a failed rule that can be worded by a translator of the
+// validator, like the FieldError of go-playground/validator or a Rule.
+type Translatable interface {
+	Translate(trans ut.Translator) string
+}
+
+// Rule is a rule that a field failed outside of the validator, like a rule
+// of an OpenAPI schema, to be worded like the validate tag of the same name.
+// Tag is a validate tag like "min" or "oneof", or a key of translations like
+// "type-integer". Kind tells whether the Param of min and max counts
+// characters, items or is a number.
+type Rule struct {
+	Field string
+	Tag   string
+	Param string
+	Kind  reflect.Kind
+}
+
+// Translate words r by trans, it is empty when trans has no message for it.
+func (r Rule) Translate(trans ut.Translator) string {
+	var (
+		msg string
+		err error
+	)
+
+	switch r.Tag {
+	case "min", "max":
+		var digits uint64
+		if _, decimals, ok := strings.Cut(r.Param, "."); ok {
+			digits = uint64(len(decimals))
+		}
+		n, perr := strconv.ParseFloat(r.Param, 64)
+		if perr != nil {
+			return ""
+		}
+
+		switch r.Kind {
+		case reflect.String:
+			var c string
+			if c, err = trans.C(r.Tag+"-string-character", n, digits, trans.FmtNumber(n, digits)); err == nil {
+				msg, err = trans.T(r.Tag+"-string", r.Field, c)
+			}
+		case reflect.Slice:
+			var c string
+			if c, err = trans.C(r.Tag+"-items-item", n, digits, trans.FmtNumber(n, digits)); err == nil {
+				msg, err = trans.T(r.Tag+"-items", r.Field, c)
+			}
+		default:
+			msg, err = trans.T(r.Tag+"-number", r.Field, trans.FmtNumber(n, digits))
+		}
+	case "oneof":
+		msg, err = trans.T(r.Tag, r.Field, r.Param)
+	default:
+		msg, err = trans.T(r.Tag, r.Field)
+	}
+
+	if err != nil {
+		return ""
+	}
+	return msg
+}
+
+// Locale returns the first of Locales that an Accept-Language header
+// prefers, matching its tags by primary language so id-ID is id, or
+// DefaultLocale.
+func (v *Validator) Locale(acceptLanguage string) string {
+	locale, best := DefaultLocale, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, found := v.uni.GetTranslator(language); found && q > best {
+			locale, best = language, q
+		}
+	}
+	return locale
+}
+
+// Localize returns errs with its messages in locale. Messages without a
+// rule, or whose rule has no message in locale, are kept.
+func (v *Validator) Localize(errs ValidationErrors, locale string) ValidationErrors {
+	trans, found := v.uni.GetTranslator(locale)
+	if !found || locale == DefaultLocale || len(errs.Rules) == 0 {
+		return errs
+	}
+
+	localized := errs
+	localized.ValidationErrorMap = make(ValidationErrorMap, len(errs.ValidationErrorMap))
+	for key, message := range errs.ValidationErrorMap {
+		if rule, ok := errs.Rules[key]; ok {
+			if translated := rule.Translate(trans); translated != "" {
+				message = translated
+			}
+		}
+		localized.ValidationErrorMap[key] = message
+	}
+	return localized
+}
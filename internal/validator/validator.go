@@ -3,69 +3,158 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
 	ut "github.com/go-playground/universal-translator"
 	govalidator "github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
 )
 
+// DefaultLocale is the locale of the messages when a request prefers none
+// of Locales.
+const DefaultLocale = "en"
+
+// Locales lists the locales messages are translated to.
+var Locales = []string{"en", "id"}
+
 type Validator struct {
 	validate *govalidator.Validate
+	uni      *ut.UniversalTranslator
 	trans    ut.Translator
 }
 
 func New() *Validator {
 	en := en.New()
-	uni := ut.New(en, en)
-
-	trans, found := uni.GetTranslator("en")
-	if !found {
-		// The validator is call on config app first, so is ok.
-		panic("validator translator not found")
-	}
+	uni := ut.New(en, en, id.New())
 
 	validate := govalidator.New()
-	en_translations.RegisterDefaultTranslations(validate, trans)
+	registerDefaults := map[string]func(*govalidator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"id": id_translations.RegisterDefaultTranslations,
+	}
+	for _, locale := range Locales {
+		trans, found := uni.GetTranslator(locale)
+		if !found {
+			// The validator is call on config app first, so is ok.
+			panic("validator translator not found: " + locale)
+		}
+		registerDefaults[locale](validate, trans)
+	}
 
+	trans, _ := uni.GetTranslator(DefaultLocale)
 	v := &Validator{
 		validate: validate,
+		uni:      uni,
 		trans:    trans,
 	}
 	v.registerTranslation()
+	v.UseTagNames("json", "query", "param")
 	return v
 }
 
+// registerTranslation registers the messages of translations in every
+// locale, for the custom tags as well as for the keys of Rule.
 func (v *Validator) registerTranslation() {
-	v.validate.RegisterTranslation("port", v.trans,
-		func(ut ut.Translator) error {
-			return ut.Add("port", "{0} has invalid value of {1}", true)
-		},
-		func(ut ut.Translator, fe govalidator.FieldError) string {
-			t, _ := ut.T("port", fe.Field(), fmt.Sprintf("%v", fe.Value()))
-			return t
-		},
-	)
+	for _, locale := range Locales {
+		trans, _ := v.uni.GetTranslator(locale)
+		for key, text := range translations[locale] {
+			if !slices.Contains(customTags, key) {
+				if err := trans.Add(key, text, true); err != nil {
+					panic(fmt.Sprintf("validator translation %s of %s: %v", key, locale, err))
+				}
+				continue
+			}
+
+			v.validate.RegisterTranslation(key, trans,
+				func(ut ut.Translator) error {
+					return ut.Add(key, text, true)
+				},
+				func(ut ut.Translator, fe govalidator.FieldError) string {
+					t, _ := ut.T(key, fe.Field(), fmt.Sprintf("%v", fe.Value()))
+					return t
+				},
+			)
+		}
+	}
+}
+
+// UseTagNames reports fields by the first of the given struct tags that is
+// set, instead of their Go name. Tag options after a comma are ignored, and a
+// field without any of the tags keeps its Go name. New uses the json, query
+// and param tags, the names clients send fields by.
+func (v *Validator) UseTagNames(tags ...string) {
+	v.validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range tags {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
 }
 
+// Struct validates input and reports its failures in the default locale,
+// keyed by their namespace like Config.Server.PORT.
 func (v *Validator) Struct(input any) error {
 	err := v.validate.Struct(input)
+
+	var validationErrs govalidator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return ValidationErrorMap(validationErrs.Translate(v.trans))
+	}
+	return err
+}
+
+// Implement validator interface from echo. Unlike Struct, the errors carry
+// the code and the rule of every failure, and are keyed by the tag names of
+// the fields without the grouping structs that have none, e.g.
+// TransactionListDTO.page_size for the Pagination.PageSize of a query.
+func (v *Validator) Validate(i any) error {
+	err := v.validate.Struct(i)
 	if err != nil {
 		var validationErrs govalidator.ValidationErrors
 
 		switch {
 		case errors.As(err, &validationErrs):
-			errs := err.(govalidator.ValidationErrors)
-			return ValidationErrorMap(errs.Translate(v.trans))
+			verrs := ValidationErrors{
+				ValidationErrorMap: make(ValidationErrorMap, len(validationErrs)),
+				Codes:              make(map[string]FieldCode, len(validationErrs)),
+				Rules:              make(map[string]Translatable, len(validationErrs)),
+			}
+			for _, fe := range validationErrs {
+				key := namespace(fe)
+				verrs.ValidationErrorMap[key] = fe.Translate(v.trans)
+				verrs.Codes[key] = CodeOf(fe.Tag())
+				verrs.Rules[key] = fe
+			}
+			return verrs
 		default:
 			return err
 		}
-
 	}
 	return nil
 }
 
-// Implement validator interface from echo
-func (v *Validator) Validate(i any) error {
-	return v.Struct(i)
+// namespace is the namespace of fe by the tag names of the fields, without
+// the structs between that have no tag name.
+func namespace(fe govalidator.FieldError) string {
+	names := strings.Split(fe.Namespace(), ".")
+	goNames := strings.Split(fe.StructNamespace(), ".")
+	if len(names) != len(goNames) {
+		return fe.Namespace()
+	}
+
+	kept := []string{names[0]}
+	for i := 1; i < len(names)-1; i++ {
+		if names[i] != goNames[i] {
+			kept = append(kept, names[i])
+		}
+	}
+	return strings.Join(append(kept, names[len(names)-1]), ".")
 }
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -73,10 +74,10 @@ func TestValidationErrorMap_Error(t *testing.T) {
 }
 
 func TestValidationErrorMap_MarshalJSON(t *testing.T) {
-	t.Run("strips struct name and lowercases keys", func(t *testing.T) {
+	t.Run("strips struct name and keeps the tag names", func(t *testing.T) {
 		errMap := ValidationErrorMap{
-			"User.Email": "invalid email format",
-			"User.Name":  "required field",
+			"User.email":     "invalid email format",
+			"User.full_name": "required field",
 		}
 
 		data, err := json.Marshal(errMap)
@@ -87,12 +88,12 @@ func TestValidationErrorMap_MarshalJSON(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "invalid email format", result["email"])
-		assert.Equal(t, "required field", result["name"])
+		assert.Equal(t, "required field", result["full_name"])
 	})
 
 	t.Run("handles nested struct paths", func(t *testing.T) {
 		errMap := ValidationErrorMap{
-			"User.Address.City": "required field",
+			"User.address.city": "required field",
 		}
 
 		data, err := json.Marshal(errMap)
@@ -114,3 +115,164 @@ func TestValidationErrorMap_MarshalJSON(t *testing.T) {
 		assert.Equal(t, "{}", string(data))
 	})
 }
+
+func TestValidator_UseTagNames(t *testing.T) {
+	type Input struct {
+		Port    uint   `mapstructure:"PORT" validate:"required"`
+		Env     string `json:"env,omitempty" validate:"required"`
+		Ignored string `json:"-" validate:"required"`
+		Nested  struct {
+			Level string `mapstructure:"LOG_LEVEL" validate:"required"`
+		} `mapstructure:",squash"`
+	}
+
+	v := New()
+	v.UseTagNames("mapstructure", "json")
+
+	err := v.Struct(Input{})
+	require.Error(t, err)
+
+	errMap, ok := err.(ValidationErrorMap)
+	require.True(t, ok)
+
+	assert.Equal(t, "PORT is a required field", errMap["Input.PORT"])
+	assert.Equal(t, "env is a required field", errMap["Input.env"])
+	assert.Equal(t, "Ignored is a required field", errMap["Input.Ignored"])
+	assert.Equal(t, "LOG_LEVEL is a required field", errMap["Input.Nested.LOG_LEVEL"])
+}
+
+func TestValidator_ValidateCodes(t *testing.T) {
+	type Input struct {
+		Amount int    `json:"amount" validate:"min=1"`
+		Status string `json:"status" validate:"required,oneof=pending success"`
+		Port   uint   `json:"port" validate:"omitempty,port"`
+	}
+
+	v := New()
+	v.UseTagNames("json")
+
+	t.Run("codes every failed rule", func(t *testing.T) {
+		err := v.Validate(Input{Status: "done", Port: 99999})
+		require.Error(t, err)
+
+		var verrs ValidationErrors
+		require.ErrorAs(t, err, &verrs)
+
+		assert.Equal(t, map[string]FieldError{
+			"amount": {Code: FieldBelowMinimum, Message: "amount must be 1 or greater"},
+			"status": {Code: FieldNotAllowed, Message: "status must be one of [pending success]"},
+			"port":   {Code: FieldInvalid, Message: "port has invalid value of 99999"},
+		}, verrs.Fields())
+	})
+
+	t.Run("gives its messages as a ValidationErrorMap", func(t *testing.T) {
+		err := v.Validate(Input{Amount: 1})
+
+		var errMap ValidationErrorMap
+		require.ErrorAs(t, err, &errMap)
+		assert.Equal(t, "status is a required field", errMap["Input.status"])
+
+		data, err := json.Marshal(err)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"status is a required field"}`, string(data))
+	})
+}
+
+func TestValidator_ValidateKeys(t *testing.T) {
+	type Query struct {
+		Pagination struct {
+			PageSize *int `query:"page_size" validate:"omitempty,max=100"`
+		}
+		Filter struct {
+			URL string `json:"url" validate:"omitempty,http_url"`
+		} `json:"filter"`
+	}
+
+	v := New()
+	size := 101
+
+	err := v.Validate(Query{
+		Pagination: struct {
+			PageSize *int `query:"page_size" validate:"omitempty,max=100"`
+		}{PageSize: &size},
+		Filter: struct {
+			URL string `json:"url" validate:"omitempty,http_url"`
+		}{URL: "ftp://example.com"},
+	})
+
+	var errMap ValidationErrorMap
+	require.ErrorAs(t, err, &errMap)
+	assert.Equal(t, ValidationErrorMap{
+		"Query.page_size":  "page_size must be 100 or less",
+		"Query.filter.url": "url must be a valid HTTP URL",
+	}, errMap)
+}
+
+func TestValidator_Localize(t *testing.T) {
+	type Input struct {
+		Amount int      `json:"amount" validate:"min=1"`
+		Status string   `json:"status" validate:"required"`
+		Tags   []string `json:"tags" validate:"min=2"`
+		Port   uint     `json:"port" validate:"omitempty,port"`
+	}
+
+	v := New()
+
+	err := v.Validate(Input{Tags: []string{"a"}, Port: 99999})
+	var verrs ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+
+	t.Run("words the messages in the locale", func(t *testing.T) {
+		localized := v.Localize(verrs, "id")
+
+		assert.Equal(t, ValidationErrorMap{
+			"Input.amount": "amount harus 1 atau lebih besar",
+			"Input.status": "status wajib diisi",
+			"Input.tags":   "tags harus berisi minimal 2 item",
+			"Input.port":   "port memiliki nilai 99999 yang tidak valid",
+		}, localized.ValidationErrorMap)
+		assert.Equal(t, verrs.Codes, localized.Codes)
+		assert.Equal(t, "amount must be 1 or greater", verrs.ValidationErrorMap["Input.amount"])
+	})
+
+	t.Run("keeps the messages without a rule", func(t *testing.T) {
+		custom := ValidationErrors{
+			ValidationErrorMap: ValidationErrorMap{"Input.status": "status cannot change"},
+		}
+
+		assert.Equal(t, custom, v.Localize(custom, "id"))
+	})
+
+	t.Run("words rules of other validators", func(t *testing.T) {
+		trans, _ := v.uni.GetTranslator("id")
+
+		assert.Equal(t, "amount harus berupa bilangan bulat", Rule{Field: "amount", Tag: "type-integer"}.Translate(trans))
+		assert.Equal(t, "page harus 1.000 atau kurang", Rule{Field: "page", Tag: "max", Param: "1000", Kind: reflect.Int}.Translate(trans))
+		assert.Equal(t, "panjang minimal secret adalah 16 karakter", Rule{Field: "secret", Tag: "min", Param: "16", Kind: reflect.String}.Translate(trans))
+		assert.Empty(t, Rule{Field: "amount", Tag: "unknown"}.Translate(trans))
+	})
+}
+
+func TestValidator_Locale(t *testing.T) {
+	v := New()
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID,id;q=0.9,en-US;q=0.8", "id"},
+		{"en-US,en;q=0.9,id;q=0.8", "en"},
+		{"fr-FR, id;q=0.5", "id"},
+		{"fr", "en"},
+		{"en;q=0.2, ID;q=0.7", "id"},
+		{"id;q=x", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.want, v.Locale(tt.acceptLanguage))
+		})
+	}
+}
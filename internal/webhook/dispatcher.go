@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+type Options struct {
+	// PollInterval is how often due deliveries are looked up.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of deliveries sent per poll, they are
+	// sent concurrently.
+	BatchSize int
+	// Timeout bounds a single request to a subscriber.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts before a delivery is dead.
+	MaxAttempts int
+	// BackoffBase is the wait after the first failed attempt, it doubles
+	// with every further attempt up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		PollInterval: time.Second,
+		BatchSize:    20,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  8,
+		BackoffBase:  30 * time.Second,
+		BackoffMax:   6 * time.Hour,
+	}
+}
+
+// Backoff returns how long to wait before retrying after failed attempt n,
+// counting from 1.
+func (o Options) Backoff(n int) time.Duration {
+	wait := o.BackoffBase
+	for i := 1; i < n && wait < o.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, o.BackoffMax)
+}
+
+type Dispatcher struct {
+	store  data.WebhookDeliveryModeler
+	client *http.Client
+	logger *tlog.Logger
+	opts   Options
+	now    func() time.Time
+}
+
+func NewDispatcher(store data.WebhookDeliveryModeler, logger *tlog.Logger, opts Options) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: opts.Timeout},
+		logger: logger,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Run sends due deliveries every PollInterval until ctx is done. A batch in
+// flight when ctx is done is finished first.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep going while full batches come back, a backlog should not
+			// wait a PollInterval per batch.
+			for {
+				n, err := d.DispatchDue()
+				if err != nil {
+					d.logger.Errorj(tlog.JSON{"message": "failed claiming webhook deliveries", "error": err})
+				}
+				if n < d.opts.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// DispatchDue claims one batch of due deliveries, sends them and records the
+// outcome. It returns the number of deliveries claimed.
+func (d *Dispatcher) DispatchDue() (int, error) {
+	// The lease covers every attempt of the batch plus some slack, so a
+	// delivery is not claimed twice while it is still being sent.
+	lease := 2*d.opts.Timeout + time.Minute
+
+	deliveries, err := d.store.Claim(d.opts.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Go(func() {
+			d.dispatch(delivery)
+		})
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) dispatch(delivery *data.WebhookDelivery) {
+	attempt := d.send(delivery)
+
+	delivery.Attempts = attempt.Attempt
+	switch {
+	case attempt.Error == "":
+		delivery.Status = data.WebhookDeliverySucceeded
+	case attempt.Attempt >= d.opts.MaxAttempts:
+		delivery.Status = data.WebhookDeliveryDead
+	default:
+		delivery.Status = data.WebhookDeliveryPending
+		delivery.NextAttemptAt = attempt.AttemptedAt.Add(d.opts.Backoff(attempt.Attempt))
+	}
+
+	err := d.store.RecordAttempt(delivery, attempt)
+	if err != nil {
+		d.logger.Errorj(tlog.JSON{"message": "failed recording webhook attempt", "delivery_id": delivery.ID, "error": err})
+		return
+	}
+
+	if delivery.Status == data.WebhookDeliveryDead {
+		d.logger.Warnj(tlog.JSON{
+			"message":     "webhook delivery dead-lettered",
+			"delivery_id": delivery.ID,
+			"webhook_id":  delivery.WebhookId,
+			"attempts":    delivery.Attempts,
+			"error":       attempt.Error,
+		})
+	}
+}
+
+// send makes one attempt. Any 2xx response is a success, the response body
+// is ignored.
+func (d *Dispatcher) send(delivery *data.WebhookDelivery) *data.WebhookAttempt {
+	attempt := &data.WebhookAttempt{
+		Attempt:     delivery.Attempts + 1,
+		AttemptedAt: d.now(),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tcsa-webhook/1")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderEventId, delivery.EventId)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, attempt.AttemptedAt, delivery.Payload))
+
+	res, err := d.client.Do(req)
+	attempt.DurationMs = d.now().Sub(attempt.AttemptedAt).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	// Drain a little of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %s", res.Status)
+	}
+	return attempt
+}
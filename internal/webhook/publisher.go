@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/ucok-man/tcsa/internal/data"
+)
+
+// Publisher queues events for delivery to the subscribed webhooks. Queueing
+// an event twice is a no-op, a webhook gets one delivery per event ID.
+type Publisher struct {
+	store data.WebhookDeliveryModeler
+}
+
+func NewPublisher(store data.WebhookDeliveryModeler) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (p *Publisher) Publish(_ context.Context, event data.Event) error {
+	_, err := p.store.Enqueue(event)
+	return err
+}
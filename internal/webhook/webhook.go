@@ -0,0 +1,91 @@
+// Package webhook delivers transaction events to subscriber URLs. Every
+// request is signed with the subscriber's secret, and failed deliveries are
+// retried with exponential backoff until they are dead-lettered.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-TCSA-Event"
+	HeaderEventId   = "X-TCSA-Event-Id"
+	HeaderDelivery  = "X-TCSA-Delivery"
+	HeaderSignature = "X-TCSA-Signature"
+)
+
+var (
+	ErrMalformedSignature = errors.New("malformed signature header")
+	ErrSignatureMismatch  = errors.New("signature does not match")
+	ErrSignatureExpired   = errors.New("signature timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp, in the
+// form "t=<unix seconds>,v1=<hex hmac>". The HMAC-SHA256 covers
+// "<unix seconds>.<body>", so a captured request can't be replayed later
+// with a fresh timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(signature(secret, ts, body)))
+}
+
+// Verify checks a signature header produced by Sign. It is what a receiver
+// does with every request, and rejects timestamps more than tolerance away
+// from now.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts int64
+	var signatures [][]byte
+
+	for part := range strings.SplitSeq(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformedSignature
+		}
+
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrMalformedSignature
+			}
+			ts = parsed
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrMalformedSignature
+			}
+			signatures = append(signatures, sig)
+		}
+	}
+
+	if ts == 0 || len(signatures) == 0 {
+		return ErrMalformedSignature
+	}
+
+	if diff := now.Sub(time.Unix(ts, 0)); diff > tolerance || diff < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := signature(secret, ts, body)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+func signature(secret string, ts int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
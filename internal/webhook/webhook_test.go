@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/tcsa/internal/data"
+	"github.com/ucok-man/tcsa/internal/tlog"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"1"}`)
+	header := Sign("s3cr3t", now, body)
+
+	t.Run("accepts valid signature", func(t *testing.T) {
+		assert.NoError(t, Verify("s3cr3t", header, body, 5*time.Minute, now.Add(time.Minute)))
+	})
+
+	t.Run("rejects other secret", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("other", header, body, 5*time.Minute, now), ErrSignatureMismatch)
+	})
+
+	t.Run("rejects modified body", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("s3cr3t", header, []byte(`{"id":"2"}`), 5*time.Minute, now), ErrSignatureMismatch)
+	})
+
+	t.Run("rejects old timestamp", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("s3cr3t", header, body, 5*time.Minute, now.Add(time.Hour)), ErrSignatureExpired)
+	})
+
+	t.Run("rejects malformed header", func(t *testing.T) {
+		for _, header := range []string{"", "v1=abc", "t=1", "t=x,v1=00", "t=1,v1=zz"} {
+			assert.ErrorIs(t, Verify("s3cr3t", header, body, 5*time.Minute, now), ErrMalformedSignature, header)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	opts := Options{BackoffBase: 30 * time.Second, BackoffMax: 10 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, opts.Backoff(1))
+	assert.Equal(t, time.Minute, opts.Backoff(2))
+	assert.Equal(t, 4*time.Minute, opts.Backoff(4))
+	assert.Equal(t, 8*time.Minute, opts.Backoff(5))
+	assert.Equal(t, 10*time.Minute, opts.Backoff(6))
+	assert.Equal(t, 10*time.Minute, opts.Backoff(50))
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a subscriber that records every request and answers
+// with status.
+func newReceiver(t *testing.T, status int) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+func newTestDispatcher(store data.WebhookDeliveryModeler, now time.Time) *Dispatcher {
+	logger := tlog.Must(tlog.NewDevelopment())
+	logger.SetOutput(&bytes.Buffer{})
+
+	opts := DefaultOptions()
+	opts.MaxAttempts = 3
+
+	d := NewDispatcher(store, logger, opts)
+	d.now = func() time.Time { return now }
+	return d
+}
+
+func testDelivery(t *testing.T, url string, attempts int) *data.WebhookDelivery {
+	t.Helper()
+
+	event, err := data.NewEvent(data.EventTransactionCreated, data.TransactionEventData{
+		Transaction: &data.Transaction{ID: 1, UserId: 1, Amount: 10000, Status: data.TransactionStatusPending},
+	})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	return &data.WebhookDelivery{
+		ID:        7,
+		WebhookId: 3,
+		EventId:   event.ID,
+		EventType: event.Type,
+		Payload:   payload,
+		Status:    data.WebhookDeliveryPending,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "s3cr3t",
+	}
+}
+
+func TestDispatchDue(t *testing.T) {
+	now := time.Now()
+
+	t.Run("delivers signed payload", func(t *testing.T) {
+		srv, received := newReceiver(t, http.StatusNoContent)
+		delivery := testDelivery(t, srv.URL, 0)
+
+		store := new(data.MockWebhookDeliveryModel)
+		store.On("Claim", 20, mock.AnythingOfType("time.Duration")).Return([]*data.WebhookDelivery{delivery}, nil)
+		store.On("RecordAttempt", delivery, mock.AnythingOfType("*data.WebhookAttempt")).Return(nil)
+
+		n, err := newTestDispatcher(store, now).DispatchDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		requests := received()
+		require.Len(t, requests, 1)
+		assert.Equal(t, "transaction.created", requests[0].header.Get(HeaderEvent))
+		assert.Equal(t, delivery.EventId, requests[0].header.Get(HeaderEventId))
+		assert.Equal(t, "7", requests[0].header.Get(HeaderDelivery))
+		assert.JSONEq(t, string(delivery.Payload), string(requests[0].body))
+		assert.NoError(t, Verify("s3cr3t", requests[0].header.Get(HeaderSignature), requests[0].body, time.Minute, now))
+
+		assert.Equal(t, data.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+
+		attempt := store.Calls[1].Arguments.Get(1).(*data.WebhookAttempt)
+		assert.Equal(t, 1, attempt.Attempt)
+		assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+		assert.Empty(t, attempt.Error)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("schedules retry after failure", func(t *testing.T) {
+		srv, _ := newReceiver(t, http.StatusInternalServerError)
+		delivery := testDelivery(t, srv.URL, 1)
+
+		store := new(data.MockWebhookDeliveryModel)
+		store.On("Claim", 20, mock.AnythingOfType("time.Duration")).Return([]*data.WebhookDelivery{delivery}, nil)
+		store.On("RecordAttempt", delivery, mock.AnythingOfType("*data.WebhookAttempt")).Return(nil)
+
+		_, err := newTestDispatcher(store, now).DispatchDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+
+		attempt := store.Calls[1].Arguments.Get(1).(*data.WebhookAttempt)
+		assert.Equal(t, http.StatusInternalServerError, attempt.StatusCode)
+		assert.Contains(t, attempt.Error, "500")
+	})
+
+	t.Run("dead-letters after last attempt", func(t *testing.T) {
+		srv, _ := newReceiver(t, http.StatusBadRequest)
+		delivery := testDelivery(t, srv.URL, 2)
+
+		store := new(data.MockWebhookDeliveryModel)
+		store.On("Claim", 20, mock.AnythingOfType("time.Duration")).Return([]*data.WebhookDelivery{delivery}, nil)
+		store.On("RecordAttempt", delivery, mock.AnythingOfType("*data.WebhookAttempt")).Return(nil)
+
+		_, err := newTestDispatcher(store, now).DispatchDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryDead, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+	})
+
+	t.Run("records unreachable subscriber", func(t *testing.T) {
+		srv, _ := newReceiver(t, http.StatusOK)
+		srv.Close()
+		delivery := testDelivery(t, srv.URL, 0)
+
+		store := new(data.MockWebhookDeliveryModel)
+		store.On("Claim", 20, mock.AnythingOfType("time.Duration")).Return([]*data.WebhookDelivery{delivery}, nil)
+		store.On("RecordAttempt", delivery, mock.AnythingOfType("*data.WebhookAttempt")).Return(nil)
+
+		_, err := newTestDispatcher(store, now).DispatchDue()
+
+		require.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryPending, delivery.Status)
+
+		attempt := store.Calls[1].Arguments.Get(1).(*data.WebhookAttempt)
+		assert.Zero(t, attempt.StatusCode)
+		assert.NotEmpty(t, attempt.Error)
+	})
+}
+
+func TestPublisher(t *testing.T) {
+	event, err := data.NewEvent(data.EventTransactionDeleted, data.TransactionEventData{
+		Transaction: &data.Transaction{ID: 1, UserId: 1, Amount: 10000, Status: data.TransactionStatusFailed},
+	})
+	require.NoError(t, err)
+
+	store := new(data.MockWebhookDeliveryModel)
+	store.On("Enqueue", event).Return(2, nil).Once()
+	store.On("Enqueue", event).Return(0, errors.New("connection reset")).Once()
+
+	publisher := NewPublisher(store)
+
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	assert.EqualError(t, publisher.Publish(context.Background(), event), "connection reset")
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "transactions"
+    ADD COLUMN IF NOT EXISTS seeded BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS "transactions_seeded_idx"
+    ON "transactions" (id) WHERE seeded;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "transactions_seeded_idx";
+
+ALTER TABLE "transactions"
+    DROP COLUMN IF EXISTS seeded;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_outbox() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER outbox_notify
+    AFTER INSERT ON "outbox"
+    FOR EACH ROW EXECUTE FUNCTION notify_outbox();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS outbox_notify ON "outbox";
+DROP FUNCTION IF EXISTS notify_outbox();
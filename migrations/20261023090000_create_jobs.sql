@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "jobs" (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    payload JSONB NOT NULL DEFAULT '{}',
+    input BYTEA,
+    progress JSONB,
+    checkpoint JSONB,
+    result JSONB,
+    last_error TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL CHECK (max_attempts > 0),
+    run_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "jobs_due_idx"
+    ON "jobs" (run_at, id) WHERE status = 'queued';
+
+CREATE INDEX IF NOT EXISTS "jobs_running_idx"
+    ON "jobs" (locked_until) WHERE status = 'running';
+
+CREATE INDEX IF NOT EXISTS "jobs_finished_at_idx"
+    ON "jobs" (finished_at) WHERE finished_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "jobs";
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "transaction_imports" (
+    job_id BIGINT NOT NULL REFERENCES "jobs" (id) ON DELETE CASCADE,
+    line INT NOT NULL,
+    PRIMARY KEY (job_id, line)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "transaction_imports";
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the goose-annotated SQL migration files so the
+// api binary can apply them without the goose CLI being installed.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS